@@ -11,7 +11,7 @@ arch=${archs[$i]}
 echo "Building for ${arch}..."
 
 CGO_ENABLED=1 GOOS="${GOOS}" GOARCH=${arch} \
-         go build -o "../../out/${bin_name}-${arch}.exe"
+         go build -tags sqlite_fts5 -o "../../out/${bin_name}-${arch}.exe"
 done
 
 rm -rf *.syso
\ No newline at end of file
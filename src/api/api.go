@@ -30,6 +30,7 @@ func InitServer(cfg *config.Config) {
 	RegisterValidators()
 	RegisterPrometheus()
 
+	r.Use(middlewares.RequestId())
 	r.Use(middlewares.DefaultStructuredLogger(cfg))
 	r.Use(middlewares.Cors(cfg))
 	r.Use(middlewares.Prometheus())
@@ -62,6 +63,9 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 
 		gsts := v1.Group("/gsts")
 		gst := gsts.Group("/:gstin")
+		scrapeRuns := gsts.Group("/scrape-runs")
+		liabilitySummary := gsts.Group("/liabilities")
+		liabilities := gst.Group("/liabilities")
 
 		mocks := v1.Group("/mocks")
 
@@ -71,6 +75,13 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 
 		notifications := v1.Group("/notifications")
 
+		calendar := v1.Group("/calendar")
+		calendarExtensions := calendar.Group("/extensions")
+
+		audit := v1.Group("/audit")
+
+		views := v1.Group("/views")
+
 		// Test
 		routers.Health(health)
 		routers.TestRouter(test_router, cfg)
@@ -80,10 +91,17 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 		routers.User(user, cfg)
 		routers.Gsts(gsts, cfg)
 		routers.Gst(gst, cfg)
+		routers.ScrapeRuns(scrapeRuns, cfg)
+		routers.LiabilitySummary(liabilitySummary, cfg)
+		routers.Liabilities(liabilities, cfg)
 		routers.Mock(mocks, cfg)
 		routers.Streamer(streamer, cfg)
 		routers.Settings(settings, cfg)
 		routers.Notifications(notifications, cfg)
+		routers.Calendar(calendar, cfg)
+		routers.CalendarExtensions(calendarExtensions, cfg)
+		routers.Audit(audit, cfg)
+		routers.SavedViews(views, cfg)
 
 		r.Static("/static", "./uploads")
 
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type GetAuditLogsRequest struct {
+	PageNumber int                   `form:"pageNumber"`
+	PageSize   int                   `form:"pageSize"`
+	Entity     string                `form:"entity"`
+	EntityId   string                `form:"entityId"`
+	Gstin      string                `form:"gstin"`
+	Action     constants.AuditAction `form:"action" binding:"omitempty,oneof=create update delete"`
+	ActorId    int                   `form:"actorId"`
+	RequestId  string                `form:"requestId"`
+	From       time.Time             `form:"from" time_format:"2006-01-02"`
+	To         time.Time             `form:"to" time_format:"2006-01-02"`
+}
+
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type AuditLog struct {
+	Id        int                    `json:"id"`
+	Entity    string                 `json:"entity"`
+	EntityId  string                 `json:"entityId"`
+	Gstin     string                 `json:"gstin,omitempty"`
+	Action    constants.AuditAction  `json:"action"`
+	ActorId   int                    `json:"actorId"`
+	ActorName string                 `json:"actorName"`
+	RequestId string                 `json:"requestId"`
+	Changes   map[string]AuditChange `json:"changes"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type GetDueDatesRequest struct {
+	Gstin string `form:"gstin" binding:"required,gstin"`
+	// Financial year as 2024-25. Defaults to the current financial year
+	Fy          string                    `form:"fy"`
+	Frequency   constants.FilingFrequency `form:"frequency"`
+	Composition bool                      `form:"composition"`
+}
+
+type DueDate struct {
+	ReturnType   constants.GstReturnType `json:"returnType"`
+	ReturnPeriod string                  `json:"returnPeriod"`
+	DueDate      time.Time               `json:"dueDate"`
+	Extended     bool                    `json:"extended"`
+	Notification string                  `json:"notification,omitempty"`
+}
+
+type CreateDueDateExtensionRequest struct {
+	BaseDto
+	ReturnType   constants.GstReturnType `json:"returnType" binding:"required"`
+	ReturnPeriod string                  `json:"returnPeriod" binding:"required,len=6,numeric"`
+	StateCodes   []string                `json:"stateCodes"`
+	DueDate      time.Time               `json:"dueDate" binding:"required"`
+	Notification string                  `json:"notification" binding:"required,max=200"`
+}
+
+type DueDateExtension struct {
+	Id           int                     `json:"id"`
+	ReturnType   constants.GstReturnType `json:"returnType"`
+	ReturnPeriod string                  `json:"returnPeriod"`
+	StateCodes   []string                `json:"stateCodes"`
+	DueDate      time.Time               `json:"dueDate"`
+	Notification string                  `json:"notification"`
+}
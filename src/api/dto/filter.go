@@ -6,17 +6,37 @@ type Sort struct {
 }
 
 type Filter struct {
-	// contains notContains equals notEqual startsWith lessThan lessThanOrEqual greaterThan greaterThanOrEqual inRange endsWith
+	// contains notContains equals notEqual startsWith lessThan lessThanOrEqual greaterThan greaterThanOrEqual inRange endsWith blank notBlank
 	Type string `json:"type"`
 	From string `json:"from"`
 	To   string `json:"to"`
-	// text number
+	// Values of a set filter, any of them matches
+	Values []string `json:"values"`
+	// text number date boolean set
 	FilterType string `json:"filterType"`
+	// Conditions of a filter with more than one condition on the field, the grid joins them by Operator
+	Conditions []Filter `json:"conditions"`
+	// AND OR
+	Operator string `json:"operator"`
+}
+
+// FilterNode is a node of a filter tree, a group of nodes or a filter on a field
+type FilterNode struct {
+	// and or not, the nodes of a group are ANDed unless told otherwise and NOT negates their AND
+	Operator string       `json:"operator"`
+	Nodes    []FilterNode `json:"nodes"`
+	// Field the filter is on. The nodes of a group on a relation, like GstStatuses, filter the same
+	// related row
+	Field  string  `json:"field"`
+	Filter *Filter `json:"filter"`
 }
 
 type DynamicFilter struct {
-	Sort   *[]Sort           `json:"sort"`
+	Sort *[]Sort `json:"sort"`
+	// Filter by field name, a dotted name like GstStatuses.Status filters on a relation
 	Filter map[string]Filter `json:"filter"`
+	// Where is a tree of filters ANDed with the ones of Filter
+	Where *FilterNode `json:"where"`
 }
 
 type PagedList[T any] struct {
@@ -25,12 +45,19 @@ type PagedList[T any] struct {
 	TotalPages      int   `json:"totalPages"`
 	HasPreviousPage bool  `json:"hasPreviousPage"`
 	HasNextPage     bool  `json:"hasNextPage"`
-	Items           *[]T  `json:"items"`
+	// NextCursor points to the last item of a keyset page, it is sent back for the page after it
+	NextCursor string `json:"nextCursor,omitempty"`
+	Items      *[]T   `json:"items"`
 }
 
 type PaginationInput struct {
 	PageSize   int `json:"pageSize"`
 	PageNumber int `json:"pageNumber"`
+	// Keyset pages through the items after Cursor instead of by the page number. The first page has no cursor
+	Keyset bool   `json:"keyset"`
+	Cursor string `json:"cursor"`
+	// WithCount counts the matching items of a keyset page, the pages by number are always counted
+	WithCount bool `json:"withCount"`
 }
 
 type PaginationInputWithFilter struct {
@@ -50,6 +77,25 @@ func (p *PaginationInputWithFilter) GetPageSize() int {
 	return p.PageSize
 }
 
+// DefaultKeysetPageSize is the size of a keyset page without one, a keyset page never has all the items
+const DefaultKeysetPageSize = 100
+
+// MaxKeysetPageSize bounds the items of a keyset page whatever the page size asked for
+const MaxKeysetPageSize = 1000
+
+// GetKeysetPageSize is the number of items of a keyset page
+func (p *PaginationInputWithFilter) GetKeysetPageSize() int {
+	if p.PageSize <= 0 {
+		return DefaultKeysetPageSize
+	}
+	return min(p.PageSize, MaxKeysetPageSize)
+}
+
+// IsKeyset tells whether the items are paged by a cursor
+func (p *PaginationInputWithFilter) IsKeyset() bool {
+	return p.Keyset || p.Cursor != ""
+}
+
 func (p *PaginationInputWithFilter) GetPageNumber() int {
 	if p.PageNumber == 0 {
 		p.PageNumber = 1
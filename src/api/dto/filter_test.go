@@ -0,0 +1,25 @@
+package dto
+
+import "testing"
+
+func TestGetKeysetPageSize(t *testing.T) {
+	tests := []struct {
+		pageSize int
+		want     int
+	}{
+		{0, DefaultKeysetPageSize},
+		{-1, DefaultKeysetPageSize},
+		{25, 25},
+		{MaxKeysetPageSize, MaxKeysetPageSize},
+		{MaxKeysetPageSize + 1, MaxKeysetPageSize},
+		{1 << 30, MaxKeysetPageSize},
+	}
+
+	for _, test := range tests {
+		p := PaginationInputWithFilter{PaginationInput: PaginationInput{PageSize: test.pageSize, Keyset: true}}
+
+		if got := p.GetKeysetPageSize(); got != test.want {
+			t.Errorf("page size of %d = %d, want %d", test.pageSize, got, test.want)
+		}
+	}
+}
@@ -25,19 +25,123 @@ type GstFiledCount struct {
 	GSTR2Count  int64 `json:"gstr2Count"`
 	GSTR9Count  int64 `json:"gstr9Count"`
 	TotalGsts   int64 `json:"totalGsts"`
+	// GSTINs with a pending return past its due date
+	GSTR1OverdueCount  int64 `json:"gstr1OverdueCount"`
+	GSTR3BOverdueCount int64 `json:"gstr3bOverdueCount"`
+	GSTR9OverdueCount  int64 `json:"gstr9OverdueCount"`
+	// QRMP filers with the IFF or PMT-06 of the month just ended still due
+	IFFDueCount   int64 `json:"iffDueCount"`
+	PMT06DueCount int64 `json:"pmt06DueCount"`
+	QuarterlyGsts int64 `json:"quarterlyGsts"`
+}
+
+type ExportGstsRequest struct {
+	PaginationInputWithFilter
+	// Format of the file, csv, xlsx or pdf
+	Format string `json:"-" form:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+	// View is the saved view of the grid exported, applied the way the grid does
+	View int `json:"-" form:"view"`
+}
+
+type SearchGstsRequest struct {
+	// Q is the words to look up, each of them has to match the start of a word of a GST
+	Q     string `form:"q" binding:"required,max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// DefaultSearchLimit is the number of GSTs a search returns without a limit
+const DefaultSearchLimit = 20
+
+// GetLimit is the number of GSTs to return, the best matches
+func (r *SearchGstsRequest) GetLimit() int {
+	if r.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return r.Limit
+}
+
+type GstSearchResult struct {
+	Gst
+	// Score of the match, the higher the better
+	Score float64 `json:"score"`
+}
+
+type ImportGstsRequest struct {
+	BaseDto
+	FileFormRequest
+	// Mapping is a JSON object of the column headers of the file to the GST fields, e.g. {"GST No": "gstin"}.
+	// Without it the columns named like the fields are read
+	Mapping string `json:"mapping" form:"mapping"`
+	// Sheet of the workbook to read, the first one when empty
+	Sheet string `json:"sheet" form:"sheet"`
+	// DryRun validates the rows without importing them
+	DryRun bool `json:"dryRun" form:"dryRun"`
+}
+
+type GstImportResult struct {
+	Headers []string `json:"headers"`
+	// Mapping the rows were read with, of the column headers to the GST fields
+	Mapping     map[string]string `json:"mapping"`
+	TotalRows   int               `json:"totalRows"`
+	ValidRows   int               `json:"validRows"`
+	InvalidRows int               `json:"invalidRows"`
+	Errors      []GstImportError  `json:"errors"`
+	DryRun      bool              `json:"dryRun"`
+	Imported    bool              `json:"imported"`
+	Message     string            `json:"message,omitempty"`
+}
+
+type GstImportError struct {
+	// Row of the file, the header is row 1
+	Row     int    `json:"row"`
+	Gstin   string `json:"gstin"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type UpdateGstReturnStatusRequest struct {
 	BaseDto
 	Gstin      string                    `json:"gstin" binding:"required,gstin"`
-	ReturnType constants.GstReturnType   `json:"returnType"`
-	Status     constants.GstReturnStatus `json:"status"`
+	ReturnType constants.GstReturnType   `json:"returnType" binding:"required"`
+	Status     constants.GstReturnStatus `json:"status" binding:"required"`
+	Note       string                    `json:"note" binding:"max=500"`
+}
+
+type GetGstStatusTransitionsRequest struct {
+	Gstin      string                  `form:"-"`
+	ReturnType constants.GstReturnType `form:"returnType"`
+}
+
+type GstStatusTransition struct {
+	ReturnType    constants.GstReturnType   `json:"returnType"`
+	ReturnPeriod  string                    `json:"returnPeriod"`
+	FromStatus    constants.GstReturnStatus `json:"fromStatus"`
+	ToStatus      constants.GstReturnStatus `json:"toStatus"`
+	Note          string                    `json:"note"`
+	ChangedBy     int                       `json:"changedBy"`
+	ChangedByName string                    `json:"changedByName"`
+	ChangedAt     time.Time                 `json:"changedAt"`
 }
 
 type UpdateGstLockStatusRequest struct {
 	BaseDto
 	Gstin  string `json:"gstin"`
 	Locked bool   `json:"locked"`
+	Reason string `json:"reason"`
+}
+
+type UpdateGstFilingFrequencyRequest struct {
+	BaseDto
+	Gstin string `json:"gstin"`
+	// Empty detects the frequency from the portal again
+	FilingFrequency constants.FilingFrequency `json:"filingFrequency" binding:"omitempty,oneof=Monthly Quarterly"`
+}
+
+type UpdateGstTurnoverRequest struct {
+	BaseDto
+	Gstin string `json:"gstin"`
+	// Aggregate turnover of the previous year, empty estimates the late fees without the caps of the turnover
+	Turnover *float64 `json:"turnover" binding:"omitempty,min=0"`
 }
 
 type RemoveGstRequest struct {
@@ -46,21 +150,26 @@ type RemoveGstRequest struct {
 }
 
 type Gst struct {
-	Sno              string           `json:"sno"`
-	Fno              string           `json:"fno"`
-	Gstin            string           `json:"gstin"`
-	Name             string           `json:"name"`
-	TradeName        string           `json:"tradeName"`
-	Email            string           `json:"email"`
-	RegistrationDate string           `json:"registrationDate"`
-	Type             string           `json:"type"`
-	LastUpdateDate   time.Time        `json:"lastUpdateDate"`
-	Locked           bool             `json:"locked"`
-	MobileNumber     string           `json:"mobileNumber"`
-	Username         string           `json:"username"`
-	Password         string           `json:"password"`
-	GstStatuses      []GstStatus      `json:"gstStatuses"`
-	PermenantAddress PermenantAddress `json:"permenantAddress"`
+	Sno              string    `json:"sno"`
+	Fno              string    `json:"fno"`
+	Gstin            string    `json:"gstin"`
+	Name             string    `json:"name"`
+	TradeName        string    `json:"tradeName"`
+	Email            string    `json:"email"`
+	RegistrationDate string    `json:"registrationDate"`
+	Type             string    `json:"type"`
+	LastUpdateDate   time.Time `json:"lastUpdateDate"`
+	Locked           bool      `json:"locked"`
+	LockReason       string    `json:"lockReason,omitempty"`
+	MobileNumber     string    `json:"mobileNumber"`
+	// FilingFrequency set on create is kept, the portal does not override it
+	FilingFrequency       constants.FilingFrequency `json:"filingFrequency"`
+	FilingFrequencyManual bool                      `json:"filingFrequencyManual"`
+	Turnover              *float64                  `json:"turnover"`
+	Username              string                    `json:"username"`
+	Password              string                    `json:"password"`
+	GstStatuses           []GstStatus               `json:"gstStatuses"`
+	PermenantAddress      PermenantAddress          `json:"permenantAddress"`
 }
 
 type PermenantAddress struct {
@@ -85,4 +194,36 @@ type GstStatus struct {
 	Status         constants.GstReturnStatus `json:"status"`
 	Notes          string                    `json:"notes"`
 	PendingReturns []string                  `json:"pendingReturns"`
+	DueDate        time.Time                 `json:"dueDate"`
+}
+
+type GetGstReturnFilingsRequest struct {
+	Gstin string `form:"-"`
+	// Financial year as 2024-25. Empty lists the filings of all the years
+	Fy         string                  `form:"fy"`
+	ReturnType constants.GstReturnType `form:"returnType"`
+}
+
+type GstReturnFiling struct {
+	ReturnType    constants.GstReturnType `json:"returnType"`
+	ReturnPeriod  string                  `json:"returnPeriod"`
+	TaxPrd        string                  `json:"taxp"`
+	FinancialYear string                  `json:"fy"`
+	Arn           string                  `json:"arn"`
+	FiledDate     string                  `json:"filedDate"`
+	ModeOfFiling  string                  `json:"modeOfFiling"`
+	Valid         string                  `json:"valid"`
+	DueDate       time.Time               `json:"dueDate"`
+	DaysLate      int                     `json:"daysLate"`
+}
+
+type CaptchaChallenge struct {
+	Id    string `json:"id"`
+	Gstin string `json:"gstin"`
+	Image string `json:"image"`
+}
+
+type CaptchaAnswerRequest struct {
+	Id   string `json:"id"`
+	Code string `json:"code" binding:"required"`
 }
@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type GetLiabilitiesRequest struct {
+	Gstin string `form:"-"`
+	// Liabilities till the date (yyyy-mm-dd), defaults to today
+	AsOf time.Time `form:"asOf" time_format:"2006-01-02"`
+	// Aggregate turnover of the previous year, decides the late fee caps. The turnover of the GSTIN is used when it
+	// is not given, the highest caps without either
+	Turnover *float64 `form:"turnover" binding:"omitempty,min=0"`
+}
+
+type GetLiabilitySummaryRequest struct {
+	AsOf time.Time `form:"asOf" time_format:"2006-01-02"`
+}
+
+type UpdateTaxAmountRequest struct {
+	BaseDto
+	Gstin        string                  `json:"gstin"`
+	ReturnType   constants.GstReturnType `json:"returnType" binding:"required"`
+	ReturnPeriod string                  `json:"returnPeriod" binding:"required,len=6,numeric"`
+	TaxAmount    float64                 `json:"taxAmount" binding:"min=0"`
+	Nil          bool                    `json:"nil"`
+}
+
+type Liability struct {
+	ReturnType    constants.GstReturnType `json:"returnType"`
+	ReturnPeriod  string                  `json:"returnPeriod"`
+	DueDate       time.Time               `json:"dueDate"`
+	DaysLate      int                     `json:"daysLate"`
+	Nil           bool                    `json:"nil"`
+	TaxAmount     float64                 `json:"taxAmount"`
+	LateFee       float64                 `json:"lateFee"`
+	LateFeeCapped bool                    `json:"lateFeeCapped"`
+	Interest      float64                 `json:"interest"`
+	Total         float64                 `json:"total"`
+	// LateFeeUncapped tells the late fee is an estimate without its cap, which needs the turnover of the GSTIN
+	LateFeeUncapped bool `json:"lateFeeUncapped"`
+}
+
+type GstLiabilities struct {
+	Gstin         string      `json:"gstin"`
+	Name          string      `json:"name"`
+	AsOf          time.Time   `json:"asOf"`
+	TotalLateFee  float64     `json:"totalLateFee"`
+	TotalInterest float64     `json:"totalInterest"`
+	Total         float64     `json:"total"`
+	Liabilities   []Liability `json:"liabilities,omitempty"`
+	// Estimate tells some late fees are not capped as the turnover of the GSTIN is not known
+	Estimate bool `json:"estimate"`
+}
+
+type ReturnTypeLiability struct {
+	ReturnType constants.GstReturnType `json:"returnType"`
+	Returns    int                     `json:"returns"`
+	LateFee    float64                 `json:"lateFee"`
+	Interest   float64                 `json:"interest"`
+}
+
+type LiabilitySummary struct {
+	AsOf          time.Time             `json:"asOf"`
+	TotalLateFee  float64               `json:"totalLateFee"`
+	TotalInterest float64               `json:"totalInterest"`
+	Total         float64               `json:"total"`
+	ReturnTypes   []ReturnTypeLiability `json:"returnTypes"`
+	// Estimate tells some late fees are not capped as the turnover of their GSTIN is not known
+	Estimate bool `json:"estimate"`
+	// GSTINs with a liability, highest first
+	Gsts []GstLiabilities `json:"gsts"`
+}
@@ -11,4 +11,6 @@ type NotificationsPayload struct {
 	Title       string                            `json:"title"`
 	IsRead      bool                              `json:"isRead"`
 	UserId      int                               `json:"userId"`
+	Gstin       string                            `json:"gstin,omitempty"`
+	ErrorCode   constants.ScrapeErrorCode         `json:"errorCode,omitempty"`
 }
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type CreateSavedViewRequest struct {
+	BaseDto
+	Resource constants.ViewResource `json:"resource" binding:"required,oneof=gsts"`
+	UpdateSavedViewRequest
+}
+
+type UpdateSavedViewRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	DynamicFilter
+	// Columns shown or hidden by colId
+	Columns map[string]bool `json:"columns"`
+	// SharedRoles are the roles the view is listed to besides its owner
+	SharedRoles []string `json:"sharedRoles"`
+}
+
+type GetSavedViewsRequest struct {
+	Resource constants.ViewResource `form:"resource" binding:"omitempty,oneof=gsts"`
+}
+
+// SavedViewQuery applies a saved view to a page of a grid
+type SavedViewQuery struct {
+	View int `form:"view"`
+}
+
+type SavedView struct {
+	Id       int                    `json:"id"`
+	Resource constants.ViewResource `json:"resource"`
+	Name     string                 `json:"name"`
+	DynamicFilter
+	Columns     map[string]bool `json:"columns"`
+	SharedRoles []string        `json:"sharedRoles"`
+	// Owned tells whether the view is of the user, the views shared to the user can only be applied
+	Owned      bool      `json:"owned"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type GetScrapeRunsRequest struct {
+	PageNumber int                        `form:"pageNumber"`
+	PageSize   int                        `form:"pageSize"`
+	Trigger    constants.ScrapeRunTrigger `form:"trigger"`
+	Status     constants.ScrapeRunStatus  `form:"status"`
+	Gstin      string                     `form:"gstin"`
+	ErrorCode  constants.ScrapeErrorCode  `form:"errorCode"`
+}
+
+type GetScrapeErrorSummaryRequest struct {
+	RunId int       `form:"runId"`
+	From  time.Time `form:"from" time_format:"2006-01-02"`
+	To    time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+type ScrapeErrorSummary struct {
+	Code   constants.ScrapeErrorCode  `json:"code"`
+	Class  constants.ScrapeErrorClass `json:"class"`
+	Count  int                        `json:"count"`
+	Gstins []string                   `json:"gstins"`
+}
+
+type ScrapeRun struct {
+	Id         int                        `json:"id"`
+	Trigger    constants.ScrapeRunTrigger `json:"trigger"`
+	Status     constants.ScrapeRunStatus  `json:"status"`
+	StartedBy  int                        `json:"startedBy"`
+	StartedAt  time.Time                  `json:"startedAt"`
+	FinishedAt time.Time                  `json:"finishedAt"`
+	Total      int                        `json:"total"`
+	Succeeded  int                        `json:"succeeded"`
+	Failed     int                        `json:"failed"`
+	Items      []ScrapeRunItem            `json:"items,omitempty"`
+}
+
+type ScrapeRunItem struct {
+	Id           int                           `json:"id"`
+	Gstin        string                        `json:"gstin"`
+	Status       constants.ScrapeRunItemStatus `json:"status"`
+	ErrorMessage string                        `json:"errorMessage"`
+	ErrorClass   constants.ScrapeErrorClass    `json:"errorClass,omitempty"`
+	ErrorCode    constants.ScrapeErrorCode     `json:"errorCode,omitempty"`
+	Attempts     int                           `json:"attempts"`
+	DurationMs   int64                         `json:"durationMs"`
+	StartedAt    time.Time                     `json:"startedAt"`
+	FinishedAt   time.Time                     `json:"finishedAt"`
+	Artifacts    []ScrapeRunArtifact           `json:"artifacts,omitempty"`
+}
+
+type ScrapeRunArtifact struct {
+	Id          int    `json:"id"`
+	Kind        string `json:"kind"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/api/helper"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/services"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(cfg *config.Config) *AuditHandler {
+	service := services.NewAuditService(cfg)
+
+	return &AuditHandler{service: service}
+}
+
+// GetAuditLogs godoc
+// @Summary Gets the audit log
+// @Description Gets the changes made to the GSTs, return statuses, settings, users and notifications, latest first
+// @Tags Audit
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param pageNumber query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param entity query string false "Entity" Enums(Gst, GstStatus, Settings, User, Notifications)
+// @Param entityId query string false "Id of the entity"
+// @Param gstin query string false "Gstin"
+// @Param action query string false "Action" Enums(create, update, delete)
+// @Param actorId query int false "User who made the change"
+// @Param requestId query string false "Request the change was made for"
+// @Param from query string false "Changes made from the date (yyyy-mm-dd)"
+// @Param to query string false "Changes made till the date (yyyy-mm-dd)"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.PagedList[dto.AuditLog]} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/audit [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	req := new(dto.GetAuditLogsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	logs, err := h.service.GetAuditLogs(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(logs, true, helper.Success))
+}
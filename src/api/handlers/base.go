@@ -9,7 +9,9 @@ import (
 	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/api/helper"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
 )
 
 var logger = logging.NewLogger(config.GetConfig())
@@ -27,6 +29,25 @@ func GetHeaderValues(c *gin.Context) (dto.DAppsHeader, bool) {
 	return header, true
 }
 
+// GetUserId returns the user of the request. The id of the token is trusted when the authentication is on,
+// the dapps-user-id header is read only without it
+func GetUserId(c *gin.Context) (int, bool) {
+	claim, authenticated := c.Get(constants.UserIdKey)
+	if !authenticated {
+		header, ok := GetHeaderValues(c)
+		return header.DappsUserId, ok
+	}
+
+	id, ok := claim.(float64)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, helper.GenerateBaseResponseWithError(
+			nil, false, helper.AuthError, &service_errors.ServiceError{EndUserMessage: service_errors.TokenInvalid}))
+		return 0, false
+	}
+
+	return int(id), true
+}
+
 func Create[Ti any, To any](c *gin.Context, caller func(ctx context.Context, req *Ti) (*To, error)) {
 	req := new(Ti)
 	err := c.ShouldBindJSON(&req)
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/api/helper"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/services"
+)
+
+type CalendarHandler struct {
+	service *services.CalendarService
+}
+
+func NewCalendarHandler(cfg *config.Config) *CalendarHandler {
+	service := services.NewCalendarService(cfg)
+
+	return &CalendarHandler{service: service}
+}
+
+// GetDueDates godoc
+// @Summary Gets the return due dates of a GSTIN
+// @Description Gets the due dates of the returns a GSTIN files in a financial year, including the extensions notified
+// @Tags Calendar
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin query string true "Gstin"
+// @Param fy query string false "Financial year as 2024-25, defaults to the current one"
+// @Param frequency query string false "Filing frequency, defaults to the one of the GSTIN" Enums(Monthly, Quarterly)
+// @Param composition query bool false "Composition taxpayer, files CMP-08 and GSTR-4"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.DueDate} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/calendar/due-dates [get]
+func (h *CalendarHandler) GetDueDates(c *gin.Context) {
+	req := new(dto.GetDueDatesRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	dues, err := h.service.GetDueDates(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(dues, true, helper.Success))
+}
+
+// GetDueDateExtensions godoc
+// @Summary Gets the due date extensions
+// @Description Gets the due date extensions notified by the government
+// @Tags Calendar
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.DueDateExtension} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/calendar/extensions [get]
+func (h *CalendarHandler) GetDueDateExtensions(c *gin.Context) {
+	extensions, err := h.service.GetExtensions()
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(extensions, true, helper.Success))
+}
+
+// CreateDueDateExtension godoc
+// @Summary Adds a due date extension
+// @Description Adds a due date extension notified by the government. Pending and overdue returns are computed with it
+// @Tags Calendar
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param Request body dto.CreateDueDateExtensionRequest true "CreateDueDateExtensionRequest"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.DueDateExtension} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/calendar/extensions [post]
+func (h *CalendarHandler) CreateDueDateExtension(c *gin.Context) {
+	req := new(dto.CreateDueDateExtensionRequest)
+	err := c.ShouldBindJSON(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	header, ok := GetHeaderValues(c)
+	if !ok {
+		return
+	}
+
+	req.CreatedBy = header.DappsUserId
+	extension, err := h.service.CreateExtension(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(extension, true, helper.Success))
+}
+
+// DeleteDueDateExtension godoc
+// @Summary Removes a due date extension
+// @Description Removes a due date extension, the statutory due date applies again
+// @Tags Calendar
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Extension id"
+// @Success 200 {object} helper.BaseHttpResponse "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/calendar/extensions/{id} [delete]
+func (h *CalendarHandler) DeleteDueDateExtension(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	if id == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	res, err := h.service.DeleteExtension(id)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, helper.Success))
+}
@@ -1,41 +1,50 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/api/helper"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/pkg/spreadsheet"
 	"github.com/jaganathanb/dapps-api/services"
 )
 
 type GstsHandler struct {
-	service         *services.GstService
-	scrapperService *services.ScrapperService
+	service          *services.GstService
+	scrapperService  *services.ScrapperService
+	savedViewService *services.SavedViewService
 }
 
 func NewGstsHandler(cfg *config.Config) *GstsHandler {
 	service := services.NewGstService(cfg)
 	scrapperService := services.NewScrapperService(cfg)
+	savedViewService := services.NewSavedViewService(cfg)
 
-	return &GstsHandler{service: service, scrapperService: scrapperService}
+	return &GstsHandler{service: service, scrapperService: scrapperService, savedViewService: savedViewService}
 }
 
 // GetGsts godoc
 // @Summary Gets GST
-// @Description Gets all available GSTs from the system
+// @Description Gets all available GSTs from the system. A saved view applies its sort and filters under the ones of the request
 // @Tags GSTs
 // @Accept json
 // @produces json
 // @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param view query int false "Saved view id"
 // @Param Request body dto.PaginationInputWithFilter true "Request"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.PagedList[dto.GetGstResponse]} "GetGst response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Router /v{version}/gsts/page [post]
 // @Security AuthBearer
 func (h *GstsHandler) GetGsts(c *gin.Context) {
-	GetByFilter(c, h.service.GetByFilter)
+	GetByFilterWithView(c, constants.GstsView, h.savedViewService, h.service.GetByFilter)
 }
 
 // CreateGsts godoc
@@ -67,7 +76,7 @@ func (h *GstsHandler) CreateGsts(c *gin.Context) {
 	}
 
 	req.CreatedBy = header.DappsUserId
-	msg, err := h.service.CreateGsts(req)
+	msg, err := h.service.CreateGsts(c, req)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
 			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
@@ -77,9 +86,158 @@ func (h *GstsHandler) CreateGsts(c *gin.Context) {
 	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(msg, true, helper.Success))
 }
 
+// ExportGsts godoc
+// @Summary Exports GSTs
+// @Description Exports the GSTs matching the filter of the grid, with the status and the pending periods of their returns, as CSV, XLSX or PDF. A saved view applies its sort and filters under the ones of the request like on the grid. The paging is ignored
+// @Tags GSTs
+// @Accept json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param format query string false "Format of the file" Enums(csv, xlsx, pdf) default(csv)
+// @Param view query int false "Saved view id"
+// @Param Request body dto.PaginationInputWithFilter true "Request"
+// @Success 200 {file} file "Exported file"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v{version}/gsts/export [post]
+// @Security AuthBearer
+func (h *GstsHandler) ExportGsts(c *gin.Context) {
+	req := new(dto.ExportGstsRequest)
+	err := c.ShouldBindQuery(req)
+	if err == nil {
+		err = c.ShouldBindJSON(&req.PaginationInputWithFilter)
+		// the body can be left out with a view
+		if req.View != 0 && errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	if req.View != 0 {
+		userId, ok := GetUserId(c)
+		if !ok {
+			return
+		}
+
+		err = h.savedViewService.ApplySavedView(c, userId, req.View, constants.GstsView, &req.PaginationInputWithFilter)
+		if err != nil {
+			c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+				helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			return
+		}
+	}
+
+	table, err := h.service.ExportGsts(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	format := spreadsheet.CSV
+	if req.Format != "" {
+		format = spreadsheet.Format(req.Format)
+	}
+
+	c.Header("Content-Type", spreadsheet.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=gsts-%s.%s", time.Now().Format("2006-01-02"), format))
+	c.Status(http.StatusOK)
+
+	err = spreadsheet.Write(c.Writer, format, *table)
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// SearchGsts godoc
+// @Summary Searches GSTs
+// @Description Looks the GSTs up by the words of the query, the best matches first. Every word has to match the start of a word of the GSTIN, legal or trade name, email, mobile number, Sno, Fno or address
+// @Tags GSTs
+// @Accept json
+// @produces json
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param q query string true "Words to look up"
+// @Param limit query int false "Number of GSTs, 20 by default"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.GstSearchResult} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v{version}/gsts/search [get]
+// @Security AuthBearer
+func (h *GstsHandler) SearchGsts(c *gin.Context) {
+	req := new(dto.SearchGstsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	results, err := h.service.SearchGsts(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(results, true, helper.Success))
+}
+
+// ImportGsts godoc
+// @Summary Imports GSTs
+// @Description Imports GSTs from a CSV or XLSX file like CreateGsts. The columns are read by the mapping of the column headers to the GST fields (sno, fno, gstin, name, tradeName, email, mobileNumber, type, username, password, filingFrequency), columns named like the fields are read without one. Nothing is imported when a row is not valid, a dry run only validates the rows
+// @Tags GSTs
+// @Accept  multipart/form-data
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param file formData file true "CSV or XLSX file"
+// @Param mapping formData string false "Column headers to GST fields as JSON, e.g. {\"GST No\": \"gstin\"}"
+// @Param sheet formData string false "Sheet of the workbook, the first one by default"
+// @Param dryRun formData bool false "Validate the rows without importing them"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.GstImportResult} "Dry run"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.GstImportResult} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse{result=dto.GstImportResult} "Failed"
+// @Router /v{version}/gsts/import [post]
+func (h *GstsHandler) ImportGsts(c *gin.Context) {
+	req := new(dto.ImportGstsRequest)
+	err := c.ShouldBind(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	header, ok := GetHeaderValues(c)
+	if !ok {
+		return
+	}
+
+	req.CreatedBy = header.DappsUserId
+	res, err := h.service.ImportGsts(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(res, false, helper.ValidationError, err))
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, helper.Success))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(res, true, helper.Success))
+}
+
 // UpdateGstStatus godoc
 // @Summary Updates GST statuses
-// @Description Updates the statuses of the GST entry into the system
+// @Description Moves a return of the GST to the next status of its workflow. GSTR-1 goes CallForInvoice, InvoiceReceived, InvoiceEntry, Filed and GSTR-3B goes TaxPayable, CustomerIntimated, TaxAmountReceived, Filed
 // @Tags GSTs
 // @Accept  json
 // @Produce  json
@@ -115,7 +273,7 @@ func (h *GstsHandler) UpdateGstStatus(c *gin.Context) {
 	}
 
 	req.ModifiedBy = header.DappsUserId
-	ok, err = h.service.UpdateGstStatus(req)
+	ok, err = h.service.UpdateGstStatus(c, req)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
@@ -125,6 +283,49 @@ func (h *GstsHandler) UpdateGstStatus(c *gin.Context) {
 	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(ok, true, helper.Success))
 }
 
+// GetGstStatusTransitions godoc
+// @Summary Gets the return status history of a GST
+// @Description Gets the workflow status changes of the returns of the GST with who made them, when and why, latest first
+// @Tags GSTs
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param returnType query string false "Return type"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.GstStatusTransition} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/return-status/history [get]
+func (h *GstsHandler) GetGstStatusTransitions(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.GetGstStatusTransitionsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	req.Gstin = gstin
+	transitions, err := h.service.GetGstStatusTransitions(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(transitions, true, helper.Success))
+}
+
 // LockGstById godoc
 // @Summary Updates GST lock status
 // @Description Updates the lock status of GST in system
@@ -164,7 +365,105 @@ func (h *GstsHandler) LockGstById(c *gin.Context) {
 	}
 	req.ModifiedBy = header.DappsUserId
 
-	ok, err = h.service.LockGstById(req)
+	ok, err = h.service.LockGstById(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(ok, true, helper.Success))
+}
+
+// UpdateGstFilingFrequency godoc
+// @Summary Sets the filing frequency of a GST
+// @Description Sets whether the GSTIN files GSTR-1 and GSTR-3B monthly or quarterly under QRMP. An empty frequency detects it from the portal again
+// @Tags GSTs
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param Request body dto.UpdateGstFilingFrequencyRequest true "UpdateGstFilingFrequencyRequest"
+// @Success 201 {object} helper.BaseHttpResponse "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/filing-frequency [put]
+func (h *GstsHandler) UpdateGstFilingFrequency(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.UpdateGstFilingFrequencyRequest)
+	req.Gstin = gstin
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	header, ok := GetHeaderValues(c)
+	if !ok {
+		return
+	}
+	req.ModifiedBy = header.DappsUserId
+
+	ok, err = h.service.UpdateGstFilingFrequency(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(ok, true, helper.Success))
+}
+
+// UpdateGstTurnover godoc
+// @Summary Sets the turnover of a GST
+// @Description Sets the aggregate turnover of the previous year of the GSTIN, it decides the late fee caps. An empty turnover clears it
+// @Tags GSTs
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param Request body dto.UpdateGstTurnoverRequest true "UpdateGstTurnoverRequest"
+// @Success 201 {object} helper.BaseHttpResponse "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/turnover [put]
+func (h *GstsHandler) UpdateGstTurnover(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.UpdateGstTurnoverRequest)
+	req.Gstin = gstin
+
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	header, ok := GetHeaderValues(c)
+	if !ok {
+		return
+	}
+	req.ModifiedBy = header.DappsUserId
+
+	ok, err = h.service.UpdateGstTurnover(c, req)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
@@ -174,6 +473,50 @@ func (h *GstsHandler) LockGstById(c *gin.Context) {
 	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(ok, true, helper.Success))
 }
 
+// GetGstReturnFilings godoc
+// @Summary Gets the return filings of a GST
+// @Description Gets every return the GSTIN filed as seen on the portal, latest period first, with its due date and the days it was filed late
+// @Tags GSTs
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param fy query string false "Financial year as 2024-25, all the years when not given"
+// @Param returnType query string false "Return type"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.GstReturnFiling} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/returns [get]
+func (h *GstsHandler) GetGstReturnFilings(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.GetGstReturnFilingsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	req.Gstin = gstin
+	filings, err := h.service.GetGstReturnFilings(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(filings, true, helper.Success))
+}
+
 // DeleteGstById godoc
 // @Summary Deletes GST by id
 // @Description Deletes the given GST from system
@@ -205,7 +548,7 @@ func (h *GstsHandler) DeleteGstById(c *gin.Context) {
 
 	req.DeletedBy = header.DappsUserId
 
-	ok, err := h.service.DeleteGstById(req)
+	ok, err := h.service.DeleteGstById(c, req)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
@@ -267,3 +610,39 @@ func (h *GstsHandler) RefreshGstReturns(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(nil, true, helper.Success))
 }
+
+// AnswerCaptcha godoc
+// @Summary Answers GST portal captcha
+// @Description Answers the captcha streamed to the operator while logging into GST portal. Only used by the manual captcha solver
+// @Tags GSTs
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path string true "Captcha id"
+// @Param Request body dto.CaptchaAnswerRequest true "CaptchaAnswerRequest"
+// @Success 200 {object} helper.BaseHttpResponse "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/captcha/{id} [post]
+func (h *GstsHandler) AnswerCaptcha(c *gin.Context) {
+	req := new(dto.CaptchaAnswerRequest)
+	err := c.ShouldBindJSON(&req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	req.Id = c.Params.ByName("id")
+
+	ok, err := h.scrapperService.AnswerCaptcha(req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(ok, true, helper.Success))
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/api/helper"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/services"
+)
+
+type LiabilitiesHandler struct {
+	service *services.LiabilityService
+}
+
+func NewLiabilitiesHandler(cfg *config.Config) *LiabilitiesHandler {
+	service := services.NewLiabilityService(cfg)
+
+	return &LiabilitiesHandler{service: service}
+}
+
+// GetLiabilities godoc
+// @Summary Gets the late fee and interest of a GST
+// @Description Estimates the late fee and the 18% interest of the returns of the GSTIN pending past their due date
+// @Tags Liabilities
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param asOf query string false "Liabilities till the date (yyyy-mm-dd), defaults to today"
+// @Param turnover query number false "Aggregate turnover of the previous year, the turnover set on the GSTIN is used without it"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.GstLiabilities} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/liabilities [get]
+func (h *LiabilitiesHandler) GetLiabilities(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.GetLiabilitiesRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	req.Gstin = gstin
+	liabilities, err := h.service.GetLiabilities(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(liabilities, true, helper.Success))
+}
+
+// UpdateTaxAmount godoc
+// @Summary Sets the tax amount of a return
+// @Description Sets the tax the GSTIN has to pay with a return, the interest is charged on it. Nil returns have a lower late fee
+// @Tags Liabilities
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param gstin path string true "Gstin"
+// @Param Request body dto.UpdateTaxAmountRequest true "UpdateTaxAmountRequest"
+// @Success 201 {object} helper.BaseHttpResponse "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/{gstin}/liabilities [put]
+func (h *LiabilitiesHandler) UpdateTaxAmount(c *gin.Context) {
+	gstin := c.Params.ByName("gstin")
+	if gstin == "" {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	req := new(dto.UpdateTaxAmountRequest)
+	err := c.ShouldBindJSON(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	header, ok := GetHeaderValues(c)
+	if !ok {
+		return
+	}
+
+	req.Gstin = gstin
+	req.ModifiedBy = header.DappsUserId
+
+	ok, err = h.service.UpdateTaxAmount(req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(ok, true, helper.Success))
+}
+
+// GetLiabilitySummary godoc
+// @Summary Gets the late fee and interest of all GSTs
+// @Description Estimates the late fee and interest of the pending returns of all the active GSTINs, by return type and by GSTIN. The late fees are capped by the turnover set on each GSTIN, without it the GSTR-9 fees are uncapped estimates
+// @Tags Liabilities
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param asOf query string false "Liabilities till the date (yyyy-mm-dd), defaults to today"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.LiabilitySummary} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/liabilities [get]
+func (h *LiabilitiesHandler) GetLiabilitySummary(c *gin.Context) {
+	req := new(dto.GetLiabilitySummaryRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	summary, err := h.service.GetLiabilitySummary(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(summary, true, helper.Success))
+}
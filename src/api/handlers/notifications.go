@@ -7,6 +7,7 @@ import (
 	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/api/helper"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/services"
 )
 
@@ -29,6 +30,7 @@ func NewNotificationsHandler(cfg *config.Config) *NotificationsHandler {
 // @Security AuthBearer
 // @Param dapps-user-id header int true "UserId"
 // @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param errorCode query string false "Only the notifications of the scrape error code"
 // @Success 200 {object} helper.BaseHttpResponse "Success"
 // @Failure 400 {object} helper.BaseHttpResponse "Failed"
 // @Router /v{version}/notifications [get]
@@ -38,7 +40,7 @@ func (h *NotificationsHandler) GetNotifications(c *gin.Context) {
 		return
 	}
 
-	notifications, err := h.service.GetNotifications(header.DappsUserId)
+	notifications, err := h.service.GetNotifications(header.DappsUserId, constants.ScrapeErrorCode(c.Query("errorCode")))
 
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
@@ -80,7 +82,7 @@ func (h *NotificationsHandler) AddNotifications(c *gin.Context) {
 	req.BaseDto.CreatedBy = header.DappsUserId
 	req.UserId = header.DappsUserId
 
-	notifications, err := h.service.AddNotification(req)
+	notifications, err := h.service.AddNotification(c, req)
 
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
@@ -121,7 +123,7 @@ func (h *NotificationsHandler) UpdateNotifications(c *gin.Context) {
 
 	req.BaseDto.ModifiedBy = header.DappsUserId
 	req.ModifiedBy = header.DappsUserId
-	notifications, err := h.service.UpdateNotifications(req)
+	notifications, err := h.service.UpdateNotifications(c, req)
 
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
@@ -162,7 +164,7 @@ func (h *NotificationsHandler) DeleteNotifications(c *gin.Context) {
 
 	req.BaseDto.ModifiedBy = header.DappsUserId
 	req.ModifiedBy = header.DappsUserId
-	notifications, err := h.service.DeleteNotifications(req)
+	notifications, err := h.service.DeleteNotifications(c, req)
 
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
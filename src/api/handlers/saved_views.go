@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/api/helper"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/services"
+)
+
+type SavedViewsHandler struct {
+	service *services.SavedViewService
+}
+
+func NewSavedViewsHandler(cfg *config.Config) *SavedViewsHandler {
+	service := services.NewSavedViewService(cfg)
+
+	return &SavedViewsHandler{service: service}
+}
+
+// GetSavedViews godoc
+// @Summary Gets the saved views
+// @Description Gets the saved grid views of the user and the ones shared to the roles of the user
+// @Tags Views
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param resource query string false "Grid of the views" Enums(gsts)
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.SavedView} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/views [get]
+func (h *SavedViewsHandler) GetSavedViews(c *gin.Context) {
+	req := new(dto.GetSavedViewsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	views, err := h.service.GetSavedViews(c, userId, req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(views, true, helper.Success))
+}
+
+// GetSavedView godoc
+// @Summary Gets a saved view
+// @Description Gets a saved grid view of the user or shared to a role of the user
+// @Tags Views
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "View id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.SavedView} "Success"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/views/{id} [get]
+func (h *SavedViewsHandler) GetSavedView(c *gin.Context) {
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	GetById(c, func(ctx context.Context, id int) (*dto.SavedView, error) {
+		return h.service.GetSavedView(ctx, userId, id)
+	})
+}
+
+// CreateSavedView godoc
+// @Summary Saves a view
+// @Description Saves the sort, filters and visible columns of a grid as a named view of the user, optionally shared to roles
+// @Tags Views
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param Request body dto.CreateSavedViewRequest true "CreateSavedViewRequest"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.SavedView} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/views [post]
+func (h *SavedViewsHandler) CreateSavedView(c *gin.Context) {
+	req := new(dto.CreateSavedViewRequest)
+	err := c.ShouldBindJSON(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	req.CreatedBy = userId
+	view, err := h.service.CreateSavedView(c, req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.GenerateBaseResponse(view, true, helper.Success))
+}
+
+// UpdateSavedView godoc
+// @Summary Updates a saved view
+// @Description Updates a saved view of the user, the views shared to the user can not be changed
+// @Tags Views
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "View id"
+// @Param Request body dto.UpdateSavedViewRequest true "UpdateSavedViewRequest"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.SavedView} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 403 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/views/{id} [put]
+func (h *SavedViewsHandler) UpdateSavedView(c *gin.Context) {
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	Update(c, func(ctx context.Context, id int, req *dto.UpdateSavedViewRequest) (*dto.SavedView, error) {
+		return h.service.UpdateSavedView(ctx, userId, id, req)
+	})
+}
+
+// DeleteSavedView godoc
+// @Summary Deletes a saved view
+// @Description Deletes a saved view of the user
+// @Tags Views
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "View id"
+// @Success 200 {object} helper.BaseHttpResponse "Success"
+// @Failure 403 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/views/{id} [delete]
+func (h *SavedViewsHandler) DeleteSavedView(c *gin.Context) {
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	Delete(c, func(ctx context.Context, id int) error {
+		_, err := h.service.DeleteSavedView(ctx, userId, id)
+		return err
+	})
+}
+
+// GetByFilterWithView pages through a grid like GetByFilter, with the saved view of the view query
+// parameter applied under the sort and filters of the request. The body can be left out with a view
+func GetByFilterWithView[To any](c *gin.Context, resource constants.ViewResource, views *services.SavedViewService, caller func(c context.Context, req *dto.PaginationInputWithFilter) (*To, error)) {
+	query := new(dto.SavedViewQuery)
+	err := c.ShouldBindQuery(query)
+	if err == nil && query.View == 0 {
+		GetByFilter(c, caller)
+		return
+	}
+
+	req := new(dto.PaginationInputWithFilter)
+	if err == nil {
+		err = c.ShouldBindJSON(req)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	userId, ok := GetUserId(c)
+	if !ok {
+		return
+	}
+
+	err = views.ApplySavedView(c, userId, query.View, resource, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	res, err := caller(c, req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(res, true, 0))
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/api/helper"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/services"
+)
+
+type ScrapeRunsHandler struct {
+	service *services.ScrapeRunService
+}
+
+func NewScrapeRunsHandler(cfg *config.Config) *ScrapeRunsHandler {
+	service := services.NewScrapeRunService(cfg)
+
+	return &ScrapeRunsHandler{service: service}
+}
+
+// GetScrapeRuns godoc
+// @Summary Gets scrape runs
+// @Description Gets the history of GST portal scrape runs, latest first
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param pageNumber query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param trigger query string false "Trigger" Enums(cron, manual, statusChange)
+// @Param status query string false "Status" Enums(Running, Completed, Failed)
+// @Param gstin query string false "Runs which included the GSTIN"
+// @Param errorCode query string false "Runs in which a GSTIN failed with the error code"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.PagedList[dto.ScrapeRun]} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs [get]
+func (h *ScrapeRunsHandler) GetScrapeRuns(c *gin.Context) {
+	req := new(dto.GetScrapeRunsRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	runs, err := h.service.GetScrapeRuns(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(runs, true, helper.Success))
+}
+
+// GetScrapeErrorSummary godoc
+// @Summary Gets scrape failures by cause
+// @Description Counts the GSTINs failed to be scrapped by error code, most frequent first
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param runId query int false "Only the failures of the run"
+// @Param from query string false "Failures from the date (yyyy-mm-dd)"
+// @Param to query string false "Failures till the date (yyyy-mm-dd)"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.ScrapeErrorSummary} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/errors [get]
+func (h *ScrapeRunsHandler) GetScrapeErrorSummary(c *gin.Context) {
+	req := new(dto.GetScrapeErrorSummaryRequest)
+	err := c.ShouldBindQuery(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	summary, err := h.service.GetScrapeErrorSummary(req)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(summary, true, helper.Success))
+}
+
+// GetScrapeRunById godoc
+// @Summary Gets a scrape run
+// @Description Gets a scrape run along with the outcome of each GSTIN in it
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Scrape run id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ScrapeRun} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/{id} [get]
+func (h *ScrapeRunsHandler) GetScrapeRunById(c *gin.Context) {
+	scrapeRunAction(c, h.service.GetScrapeRunById)
+}
+
+// CancelScrapeRun godoc
+// @Summary Cancels a scrape run
+// @Description Cancels a scrape run in progress. GSTINs not yet scrapped are reported as cancelled
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Scrape run id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ScrapeRun} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/{id} [delete]
+func (h *ScrapeRunsHandler) CancelScrapeRun(c *gin.Context) {
+	scrapeRunAction(c, h.service.CancelRun)
+}
+
+// PauseScrapeRun godoc
+// @Summary Pauses a scrape run
+// @Description Pauses a scrape run in progress. GSTINs already being scrapped are finished, the rest wait for the run to be resumed
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Scrape run id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ScrapeRun} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/{id}/pause [put]
+func (h *ScrapeRunsHandler) PauseScrapeRun(c *gin.Context) {
+	scrapeRunAction(c, h.service.PauseRun)
+}
+
+// ResumeScrapeRun godoc
+// @Summary Resumes a scrape run
+// @Description Resumes a paused scrape run
+// @Tags ScrapeRuns
+// @Accept  json
+// @Produce  json
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Scrape run id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ScrapeRun} "Success"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/{id}/resume [put]
+func (h *ScrapeRunsHandler) ResumeScrapeRun(c *gin.Context) {
+	scrapeRunAction(c, h.service.ResumeRun)
+}
+
+// DownloadScrapeRunArtifact godoc
+// @Summary Downloads a failure artifact
+// @Description Downloads the screenshot, page HTML or HAR captured when scrapping a GSTIN of the run failed. Only admins can download them, the cookies and authorization headers of the HAR are masked
+// @Tags ScrapeRuns
+// @Produce  octet-stream
+// @Security AuthBearer
+// @Param version path int true "Version" Enums(1, 2) default(1)
+// @Param id path int true "Scrape run id"
+// @Param artifactId path int true "Artifact id"
+// @Success 200 {file} file "Artifact"
+// @Failure 400 {object} helper.BaseHttpResponse "Failed"
+// @Failure 404 {object} helper.BaseHttpResponse "Failed"
+// @Router /v{version}/gsts/scrape-runs/{id}/artifacts/{artifactId} [get]
+func (h *ScrapeRunsHandler) DownloadScrapeRunArtifact(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	artifactId, _ := strconv.Atoi(c.Params.ByName("artifactId"))
+	if id == 0 || artifactId == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	artifact, err := h.service.GetScrapeRunArtifact(id, artifactId)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.Header("Content-Type", artifact.ContentType)
+	c.FileAttachment(artifact.Path, fmt.Sprintf("%d-%s", artifact.ScrapeRunItemId, artifact.FileName))
+}
+
+func scrapeRunAction(c *gin.Context, action func(id int) (*dto.ScrapeRun, error)) {
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	if id == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	run, err := action(id)
+
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(run, true, helper.Success))
+}
@@ -73,7 +73,7 @@ func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
 
 	req.ModifiedBy = header.DappsUserId
 	req.CreatedBy = header.DappsUserId
-	settings, err := h.service.UpdateSettings(req)
+	settings, err := h.service.UpdateSettings(c, req)
 
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
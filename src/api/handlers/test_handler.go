@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -75,7 +76,7 @@ func (h *TestHandler) Test(c *gin.Context) {
 		return
 	}
 
-	quit, err := h.scrapper.ScrapGstSite([]models.Gst{{Username: p[0].Username, Password: p[0].Password, Gstin: p[0].Gstin}, {Username: p[1].Username, Password: p[1].Password, Gstin: p[1].Gstin}}, false)
+	quit, err := h.scrapper.ScrapGstSite(context.Background(), []models.Gst{{Username: p[0].Username, Password: p[0].Password, Gstin: p[0].Gstin}, {Username: p[1].Username, Password: p[1].Password, Gstin: p[1].Gstin}}, false)
 
 	go func() {
 		for {
@@ -23,6 +23,35 @@ var StatusCodeMapping = map[string]int{
 	service_errors.GstNotFound: 404,
 	service_errors.GstExists:   409,
 	service_errors.GstsExists:  409,
+
+	// Captcha
+	service_errors.CaptchaNotManual: 409,
+	service_errors.CaptchaNotFound:  404,
+
+	// Scrape runs
+	service_errors.ScrapeRunNotActive: 409,
+
+	// Calendar
+	service_errors.InvalidFinancialYear: 400,
+	service_errors.InvalidReturnPeriod:  400,
+
+	// Workflow
+	service_errors.InvalidStatusTransition: 400,
+	service_errors.StatusChanged:           409,
+
+	// Filter
+	service_errors.InvalidFilter: 400,
+	service_errors.InvalidCursor: 400,
+
+	// Import
+	service_errors.UnsupportedImportFile: 400,
+	service_errors.InvalidImportMapping:  400,
+	service_errors.EmptyImportFile:       400,
+	service_errors.InvalidImportRows:     400,
+
+	// Saved views
+	service_errors.SavedViewExists: 409,
+	service_errors.RoleNotFound:    400,
 }
 
 func TranslateErrorToStatusCode(err error) int {
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// RequestId tags the request with the id the client sent in X-Request-Id, or a new one. The
+// changes made for the request are audited with it
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(constants.RequestIdHeaderKey)
+		if id == "" || len(id) > 64 {
+			id = uuid.NewString()
+		}
+
+		c.Set(constants.RequestIdKey, id)
+		c.Header(constants.RequestIdHeaderKey, id)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/handlers"
+	"github.com/jaganathanb/dapps-api/api/middlewares"
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+func Audit(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewAuditHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin"}))
+	}
+
+	router.GET("", h.GetAuditLogs)
+}
@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/handlers"
+	"github.com/jaganathanb/dapps-api/api/middlewares"
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+func Calendar(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewCalendarHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin", "default"}))
+	}
+
+	router.GET("/due-dates", h.GetDueDates)
+	router.GET("/extensions", h.GetDueDateExtensions)
+}
+
+func CalendarExtensions(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewCalendarHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin"}))
+	}
+
+	router.POST("", h.CreateDueDateExtension)
+	router.DELETE("/:id", h.DeleteDueDateExtension)
+}
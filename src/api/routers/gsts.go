@@ -16,8 +16,12 @@ func Gsts(router *gin.RouterGroup, cfg *config.Config) {
 
 	router.POST("", h.CreateGsts)
 	router.POST("/page", h.GetGsts)
+	router.POST("/import", h.ImportGsts)
+	router.POST("/export", h.ExportGsts)
+	router.GET("/search", h.SearchGsts)
 	router.GET("/statistics", h.GetGstStatistics)
 	router.GET("/refresh-returns", h.RefreshGstReturns)
+	router.POST("/captcha/:id", h.AnswerCaptcha)
 }
 
 func Gst(router *gin.RouterGroup, cfg *config.Config) {
@@ -28,6 +32,10 @@ func Gst(router *gin.RouterGroup, cfg *config.Config) {
 	}
 
 	router.PUT("/return-status", h.UpdateGstStatus)
+	router.GET("/return-status/history", h.GetGstStatusTransitions)
 	router.PUT("/lock", h.LockGstById)
+	router.PUT("/filing-frequency", h.UpdateGstFilingFrequency)
+	router.PUT("/turnover", h.UpdateGstTurnover)
+	router.GET("/returns", h.GetGstReturnFilings)
 	router.DELETE("", h.DeleteGstById)
 }
@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/handlers"
+	"github.com/jaganathanb/dapps-api/api/middlewares"
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+func Liabilities(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewLiabilitiesHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin", "default"}))
+	}
+
+	router.GET("", h.GetLiabilities)
+	router.PUT("", h.UpdateTaxAmount)
+}
+
+func LiabilitySummary(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewLiabilitiesHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin", "default"}))
+	}
+
+	router.GET("", h.GetLiabilitySummary)
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/handlers"
+	"github.com/jaganathanb/dapps-api/api/middlewares"
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+func SavedViews(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewSavedViewsHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin", "default"}))
+	}
+
+	router.GET("", h.GetSavedViews)
+	router.POST("", h.CreateSavedView)
+	router.GET("/:id", h.GetSavedView)
+	router.PUT("/:id", h.UpdateSavedView)
+	router.DELETE("/:id", h.DeleteSavedView)
+}
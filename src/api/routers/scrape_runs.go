@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jaganathanb/dapps-api/api/handlers"
+	"github.com/jaganathanb/dapps-api/api/middlewares"
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+func ScrapeRuns(router *gin.RouterGroup, cfg *config.Config) {
+	h := handlers.NewScrapeRunsHandler(cfg)
+
+	if cfg.Server.RunMode == "release" {
+		router.Use(middlewares.Authentication(cfg), middlewares.Authorization([]string{"admin", "default"}))
+	}
+
+	router.GET("", h.GetScrapeRuns)
+	router.GET("/errors", h.GetScrapeErrorSummary)
+	router.GET("/:id", h.GetScrapeRunById)
+	router.DELETE("/:id", h.CancelScrapeRun)
+	router.PUT("/:id/pause", h.PauseScrapeRun)
+	router.PUT("/:id/resume", h.ResumeScrapeRun)
+
+	// the artifacts show the portal as the GST sees it
+	download := []gin.HandlerFunc{h.DownloadScrapeRunArtifact}
+	if cfg.Server.RunMode == "release" {
+		download = append([]gin.HandlerFunc{middlewares.Authorization([]string{"admin"})}, download...)
+	}
+	router.GET("/:id/artifacts/:artifactId", download...)
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log"
+
 	"github.com/jaganathanb/dapps-api/api"
 	"github.com/jaganathanb/dapps-api/config"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/db/migrations"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/audit"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	fake_gst_portal "github.com/jaganathanb/dapps-api/pkg/fake-gst-portal"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 )
 
@@ -12,22 +20,64 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	generateKey := flag.Bool("generate-key", false, "print a new encryption master key and exit")
+	rotateKeys := flag.Bool("rotate-keys", false, "re-encrypt the stored credentials with the current master key and exit")
+	flag.Parse()
+
+	if *generateKey {
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(key)
+		return
+	}
+
 	cfg := config.GetConfig()
 
 	logger := logging.NewLogger(cfg)
 
+	keyring, err := encryption.NewKeyring(cfg)
+	if err != nil {
+		logger.Fatal(logging.General, logging.Startup, err.Error(), nil)
+	}
+	encryption.Register(keyring)
+
 	// err := cache.InitRedis(cfg)
 	// defer cache.CloseRedis()
 	// if err != nil {
 	// 	logger.Fatal(logging.Redis, logging.Startup, err.Error(), nil)
 	// }
 
-	err := db.InitDb(cfg)
+	err = db.InitDb(cfg)
 	defer db.CloseDb()
 	if err != nil {
 		logger.Fatal(logging.Postgres, logging.Startup, err.Error(), nil)
 	}
+
+	if *rotateKeys {
+		rotated, err := migrations.RotateCredentials(db.GetDb(), keyring)
+		if err != nil {
+			logger.Fatal(logging.Postgres, logging.Migration, err.Error(), nil)
+		}
+		logger.Infof("Re-encrypted the credentials of %d rows with key %s", rotated, keyring.ActiveKeyId())
+		return
+	}
 	migrations.Up_1(cfg)
 
+	err = audit.Register(db.GetDb(), models.Gst{}, models.GstStatus{}, models.Settings{}, models.User{}, models.Notifications{})
+	if err != nil {
+		logger.Fatal(logging.Postgres, logging.Startup, err.Error(), nil)
+	}
+
+	if cfg.FakeGstPortal.Enabled {
+		go func() {
+			err := fake_gst_portal.NewFakeGstPortal(cfg).Start()
+			if err != nil {
+				logger.Error(logging.General, logging.Startup, err.Error(), nil)
+			}
+		}()
+	}
+
 	api.InitServer(cfg)
 }
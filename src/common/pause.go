@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+type pauseGateKey struct{}
+
+// PauseGate lets long running jobs be held at safe points until they are resumed
+type PauseGate struct {
+	resume chan struct{}
+	mutex  sync.Mutex
+}
+
+func NewPauseGate() *PauseGate {
+	return &PauseGate{}
+}
+
+func (g *PauseGate) Pause() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.resume == nil {
+		g.resume = make(chan struct{})
+	}
+}
+
+func (g *PauseGate) Resume() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.resume != nil {
+		close(g.resume)
+		g.resume = nil
+	}
+}
+
+func (g *PauseGate) IsPaused() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.resume != nil
+}
+
+// Wait blocks while the gate is paused. Returns the context error if it is done in the meantime
+func (g *PauseGate) Wait(ctx context.Context) error {
+	g.mutex.Lock()
+	resume := g.resume
+	g.mutex.Unlock()
+
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		}
+	}
+
+	return ctx.Err()
+}
+
+func WithPauseGate(ctx context.Context, gate *PauseGate) context.Context {
+	return context.WithValue(ctx, pauseGateKey{}, gate)
+}
+
+// IsPaused tells whether the PauseGate carried by the context, if there is one, is paused
+func IsPaused(ctx context.Context) bool {
+	if gate, ok := ctx.Value(pauseGateKey{}).(*PauseGate); ok {
+		return gate.IsPaused()
+	}
+
+	return false
+}
+
+// WaitIfPaused waits on the PauseGate carried by the context, if there is one
+func WaitIfPaused(ctx context.Context) error {
+	if gate, ok := ctx.Value(pauseGateKey{}).(*PauseGate); ok {
+		return gate.Wait(ctx)
+	}
+
+	return ctx.Err()
+}
@@ -11,22 +11,56 @@ import (
 )
 
 type Config struct {
-	Server     ServerConfig
-	Postgres   PostgresConfig
-	Sqlite3    Sqlite3Config
-	Redis      RedisConfig
-	Password   PasswordConfig
-	Cors       CorsConfig
-	Logger     LoggerConfig
-	Otp        OtpConfig
-	JWT        JWTConfig
-	AssemblyAI AssemblyAI
+	Server        ServerConfig
+	Postgres      PostgresConfig
+	Sqlite3       Sqlite3Config
+	Redis         RedisConfig
+	Password      PasswordConfig
+	Cors          CorsConfig
+	Logger        LoggerConfig
+	Otp           OtpConfig
+	JWT           JWTConfig
+	AssemblyAI    AssemblyAI
+	Captcha       CaptchaConfig
+	FakeGstPortal FakeGstPortalConfig
+	Encryption    EncryptionConfig
+}
+
+// EncryptionConfig holds the master key the stored GST credentials are encrypted with.
+// MasterKey is a base64 encoded 32 byte key and wins over KeyFile. Previous keys are
+// only used to read values until they are rotated to the master key
+type EncryptionConfig struct {
+	MasterKey        string
+	KeyFile          string
+	PreviousKeys     []string
+	PreviousKeyFiles []string
 }
 
 type AssemblyAI struct {
 	ApiKey string
 }
 
+type CaptchaConfig struct {
+	Solver        string
+	OcrUrl        string
+	ManualTimeout time.Duration
+	StubCode      string
+}
+
+type FakeGstPortalConfig struct {
+	Enabled  bool
+	Port     string
+	Captcha  string
+	Accounts []FakeGstAccount
+}
+
+type FakeGstAccount struct {
+	Username string
+	Password string
+	Gstin    string
+	Scenario string
+}
+
 type ServerConfig struct {
 	InternalPort string
 	ExternalPort string
@@ -39,10 +73,37 @@ type ServerConfig struct {
 }
 
 type GstServer struct {
-	BaseUrl  string
-	Username string
-	Password string
-	Crontab  string
+	BaseUrl         string
+	Username        string
+	Password        string
+	Crontab         string
+	Fetcher         string
+	FixturePath     string
+	Concurrency     int
+	LoginsPerMinute int
+	LoginDelay      time.Duration
+	LoginJitter     time.Duration
+	ArtifactsPath   string
+	// Minutes a portal session is reused for
+	SessionTtl time.Duration
+	Retry      GstRetryConfig
+	// Locks a GSTIN which failed in that many runs in a row. 0 disables it
+	LockAfterFailedRuns int
+}
+
+// GstRetryConfig holds the retry policy of each of the scrape error classes. Permanent errors are never retried
+type GstRetryConfig struct {
+	Transient    RetryPolicy
+	Credential   RetryPolicy
+	Captcha      RetryPolicy
+	PortalDialog RetryPolicy
+}
+
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Multiplier  float64
 }
 
 type LoggerConfig struct {
@@ -26,6 +26,10 @@ const (
 	RolesKey               string = "Roles"
 	ExpireTimeKey          string = "Exp"
 
+	// Request
+	RequestIdHeaderKey string = "X-Request-Id"
+	RequestIdKey       string = "RequestId"
+
 	// API
 	Version uint = 1
 )
@@ -46,6 +50,20 @@ const (
 	GSTR2  GstReturnType = "GSTR2"
 	GSTR3B GstReturnType = "GSTR3B"
 	GSTR9  GstReturnType = "GSTR9"
+	GSTR4  GstReturnType = "GSTR4"
+	CMP08  GstReturnType = "CMP08"
+	// Invoice Furnishing Facility and the monthly tax payment of the QRMP filers
+	IFF   GstReturnType = "IFF"
+	PMT06 GstReturnType = "PMT06"
+)
+
+// FilingFrequency is how often a GSTIN files its GSTR-1 and GSTR-3B.
+// Quarterly is the Quarterly Return Monthly Payment (QRMP) scheme
+type FilingFrequency string
+
+const (
+	MonthlyFiling   FilingFrequency = "Monthly"
+	QuarterlyFiling FilingFrequency = "Quarterly"
 )
 
 type GstReturnStatus string
@@ -65,6 +83,75 @@ const (
 
 const REFRESH_GSTS_TABLE = "REFRESH_GSTS_TABLE"
 
+type ScrapeRunTrigger string
+
+const (
+	CronTrigger         ScrapeRunTrigger = "cron"
+	ManualTrigger       ScrapeRunTrigger = "manual"
+	StatusChangeTrigger ScrapeRunTrigger = "statusChange"
+)
+
+type ScrapeRunStatus string
+
+const (
+	ScrapeRunRunning   ScrapeRunStatus = "Running"
+	ScrapeRunCompleted ScrapeRunStatus = "Completed"
+	ScrapeRunFailed    ScrapeRunStatus = "Failed"
+	ScrapeRunPaused    ScrapeRunStatus = "Paused"
+	ScrapeRunCancelled ScrapeRunStatus = "Cancelled"
+)
+
+type ScrapeRunItemStatus string
+
+const (
+	ScrapeItemPending   ScrapeRunItemStatus = "Pending"
+	ScrapeItemSucceeded ScrapeRunItemStatus = "Succeeded"
+	ScrapeItemFailed    ScrapeRunItemStatus = "Failed"
+	ScrapeItemCancelled ScrapeRunItemStatus = "Cancelled"
+)
+
+// ScrapeErrorClass decides whether and how a failed GSTIN is retried
+type ScrapeErrorClass string
+
+const (
+	TransientScrapeError    ScrapeErrorClass = "transient"
+	CredentialScrapeError   ScrapeErrorClass = "credential"
+	CaptchaScrapeError      ScrapeErrorClass = "captcha"
+	PortalDialogScrapeError ScrapeErrorClass = "portalDialog"
+	PermanentScrapeError    ScrapeErrorClass = "permanent"
+)
+
+// ScrapeErrorCode tells the cause a GSTIN could not be scrapped for
+type ScrapeErrorCode string
+
+const (
+	InvalidCredential    ScrapeErrorCode = "INVALID_CREDENTIAL"
+	PasswordExpired      ScrapeErrorCode = "PASSWORD_EXPIRED"
+	BankAccountNotLinked ScrapeErrorCode = "BANK_ACCOUNT_NOT_LINKED"
+	UnknownDialog        ScrapeErrorCode = "UNKNOWN_DIALOG"
+	CaptchaNotSolved     ScrapeErrorCode = "CAPTCHA_NOT_SOLVED"
+	PortalError          ScrapeErrorCode = "PORTAL_ERROR"
+	GstinMismatch        ScrapeErrorCode = "GSTIN_MISMATCH"
+	NoRecordedResponse   ScrapeErrorCode = "NO_RECORDED_RESPONSE"
+	ScrapeCancelled      ScrapeErrorCode = "CANCELLED"
+)
+
+// AuditAction is the change an audit log entry records
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// ViewResource is the grid a saved view is of
+type ViewResource string
+
+const (
+	GstsView ViewResource = "gsts"
+)
+
 func (d GstReturnType) String() string {
 	return string(d)
 }
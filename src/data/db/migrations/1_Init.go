@@ -1,10 +1,13 @@
 package migrations
 
 import (
+	"fmt"
+
 	"github.com/jaganathanb/dapps-api/config"
 	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	gst_search "github.com/jaganathanb/dapps-api/pkg/gst-search"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -15,12 +18,16 @@ var logger = logging.NewLogger(config.GetConfig())
 func Up_1(cfg *config.Config) {
 	database := db.GetDb()
 
+	dropPlainPortalSessions(database)
 	createTables(database)
+	createSearchIndex(database)
 	createDefaultUserInformation(database, cfg)
 	createOrUpdateSettings(database, cfg)
 
 	//actual migrations
 	alterColumns(database)
+	encryptCredentials(database)
+	moveAnnualReturnPeriods(database)
 }
 
 func alterColumns(db *gorm.DB) {
@@ -51,6 +58,55 @@ func alterColumns(db *gorm.DB) {
 	} else {
 		logger.Info(logging.Sqlite3, logging.Migration, "Column Password created", nil)
 	}
+
+	err = db.Migrator().AddColumn(&models.Gst{}, "LockReason")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column LockReason created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.Gst{}, "FilingFrequency")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column FilingFrequency created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.Gst{}, "FilingFrequencyManual")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column FilingFrequencyManual created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.Gst{}, "Turnover")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column Turnover created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.GstStatus{}, "DueDate")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column DueDate created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.Notifications{}, "Gstin")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column Gstin created", nil)
+	}
+
+	err = db.Migrator().AddColumn(&models.Notifications{}, "ErrorCode")
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else {
+		logger.Info(logging.Sqlite3, logging.Migration, "Column ErrorCode created", nil)
+	}
 }
 
 func createTables(database *gorm.DB) {
@@ -67,6 +123,16 @@ func createTables(database *gorm.DB) {
 	tables = addNewTable(database, models.GstStatus{}, tables)
 	tables = addNewTable(database, models.Settings{}, tables)
 	tables = addNewTable(database, models.Notifications{}, tables)
+	tables = addNewTable(database, models.ScrapeRun{}, tables)
+	tables = addNewTable(database, models.ScrapeRunItem{}, tables)
+	tables = addNewTable(database, models.ScrapeRunArtifact{}, tables)
+	tables = addNewTable(database, models.PortalSession{}, tables)
+	tables = addNewTable(database, models.DueDateExtension{}, tables)
+	tables = addNewTable(database, models.GstTaxAmount{}, tables)
+	tables = addNewTable(database, models.GstReturnFiling{}, tables)
+	tables = addNewTable(database, models.GstStatusTransition{}, tables)
+	tables = addNewTable(database, models.AuditLog{}, tables)
+	tables = addNewTable(database, models.SavedView{}, tables)
 
 	err := database.Migrator().CreateTable(tables...)
 	if err != nil {
@@ -75,6 +141,32 @@ func createTables(database *gorm.DB) {
 	logger.Info(logging.Postgres, logging.Migration, "tables created", nil)
 }
 
+// dropPlainPortalSessions drops the portal sessions stored by plain username, they are created again keyed
+// by the hash of the username. The scrapper logs in again for the sessions dropped
+func dropPlainPortalSessions(database *gorm.DB) {
+	migrator := database.Migrator()
+	if !migrator.HasTable(&models.PortalSession{}) || migrator.HasColumn(&models.PortalSession{}, "UsernameHash") {
+		return
+	}
+
+	err := migrator.DropTable(&models.PortalSession{})
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+		return
+	}
+	logger.Info(logging.Sqlite3, logging.Migration, "portal sessions by plain username dropped", nil)
+}
+
+// createSearchIndex creates the full text index of the GSTs. Without it the GSTs are searched with LIKE
+func createSearchIndex(database *gorm.DB) {
+	err := gst_search.Migrate(database)
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, fmt.Sprintf("search index not created, GSTs are searched with LIKE. %s", err.Error()), nil)
+		return
+	}
+	logger.Info(logging.Sqlite3, logging.Migration, "search index created", nil)
+}
+
 func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []interface{} {
 	if !database.Migrator().HasTable(model) {
 		tables = append(tables, model)
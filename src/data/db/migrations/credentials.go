@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// encryptedColumns lists the columns stored with the encrypted serializer, and the columns holding the
+// keyed hash of one of them to look it up by
+var encryptedColumns = []struct {
+	model   interface{}
+	columns []string
+	hashes  map[string]string
+}{
+	{models.Gst{}, []string{"username", "password"}, nil},
+	{models.Settings{}, []string{"gst_password"}, nil},
+	{models.PortalSession{}, []string{"username", "cookies"}, map[string]string{"username_hash": "username"}},
+}
+
+// RotateCredentials re-encrypts every stored credential which is still in plain text or
+// encrypted with a previous master key, with the active master key, and hashes the looked up ones again.
+// It returns the number of rows changed
+func RotateCredentials(database *gorm.DB, keyring *encryption.Keyring) (int, error) {
+	rotated := 0
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		for _, enc := range encryptedColumns {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(enc.model); err != nil {
+				return err
+			}
+
+			selected := append([]string{"id"}, enc.columns...)
+			for column := range enc.hashes {
+				selected = append(selected, column)
+			}
+
+			rows := []map[string]interface{}{}
+			err := tx.Table(stmt.Schema.Table).Select(selected).Find(&rows).Error
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				values := map[string]interface{}{}
+				for _, column := range enc.columns {
+					value, ok := row[column].(string)
+					if !ok {
+						continue
+					}
+
+					value, changed, err := keyring.Rewrap(value)
+					if err != nil {
+						return fmt.Errorf("%s.%s of row %v: %w", stmt.Schema.Table, column, row["id"], err)
+					}
+
+					if changed {
+						values[column] = value
+					}
+				}
+
+				for column, source := range enc.hashes {
+					value, _ := row[source].(string)
+					plain, err := keyring.Decrypt(value)
+					if err != nil {
+						return fmt.Errorf("%s.%s of row %v: %w", stmt.Schema.Table, source, row["id"], err)
+					}
+
+					if hash := keyring.Hash(plain); hash != row[column] {
+						values[column] = hash
+					}
+				}
+
+				if len(values) == 0 {
+					continue
+				}
+
+				err := tx.Table(stmt.Schema.Table).Where("id = ?", row["id"]).UpdateColumns(values).Error
+				if err != nil {
+					return err
+				}
+				rotated++
+			}
+		}
+
+		return nil
+	})
+
+	return rotated, err
+}
+
+func encryptCredentials(database *gorm.DB) {
+	rotated, err := RotateCredentials(database, encryption.GetKeyring())
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else if rotated > 0 {
+		logger.Infof("Encrypted the credentials of %d rows with key %s", rotated, encryption.GetKeyring().ActiveKeyId())
+	}
+}
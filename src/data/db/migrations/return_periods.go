@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+	"gorm.io/gorm"
+)
+
+// oldAnnualReturnMonth is the month GSTR-9 periods were stored with, before they were moved to March, the
+// last month of the financial year the return is of
+const oldAnnualReturnMonth = "02"
+
+// annualReturnPeriod returns the GSTR-9 period stored with February as the period of March
+func annualReturnPeriod(retPrd string) (string, bool) {
+	if len(retPrd) != 6 || !strings.HasPrefix(retPrd, oldAnnualReturnMonth) {
+		return retPrd, false
+	}
+
+	return "03" + retPrd[2:], true
+}
+
+// MoveAnnualReturnPeriods rewrites the GSTR-9 periods stored as February of the financial year end to March,
+// in the return statuses, their pending returns and their transitions. It returns the number of rows changed
+func MoveAnnualReturnPeriods(database *gorm.DB) (int, error) {
+	moved := 0
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var statuses []models.GstStatus
+		err := tx.Model(&models.GstStatus{}).Where("rtntype = ?", constants.GSTR9).Select("id", "ret_prd", "pending_returns").Find(&statuses).Error
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			values := map[string]interface{}{}
+
+			if retPrd, ok := annualReturnPeriod(status.RetPrd); ok {
+				values["ret_prd"] = retPrd
+			}
+
+			pending, changed := sqlite_custom_type.SqliteStrArray{}, false
+			for _, retPrd := range status.PendingReturns {
+				retPrd, ok := annualReturnPeriod(retPrd)
+				pending = append(pending, retPrd)
+				changed = changed || ok
+			}
+			if changed {
+				values["pending_returns"] = pending
+			}
+
+			if len(values) == 0 {
+				continue
+			}
+
+			err = tx.Model(&models.GstStatus{}).Where("id = ?", status.Id).UpdateColumns(values).Error
+			if err != nil {
+				return err
+			}
+			moved++
+		}
+
+		result := tx.Model(&models.GstStatusTransition{}).
+			Where("rtntype = ? AND ret_prd LIKE ? AND LENGTH(ret_prd) = 6", constants.GSTR9, oldAnnualReturnMonth+"%").
+			UpdateColumn("ret_prd", gorm.Expr("'03' || SUBSTR(ret_prd, 3)"))
+		if result.Error != nil {
+			return result.Error
+		}
+		moved += int(result.RowsAffected)
+
+		return nil
+	})
+
+	return moved, err
+}
+
+func moveAnnualReturnPeriods(database *gorm.DB) {
+	moved, err := MoveAnnualReturnPeriods(database)
+	if err != nil {
+		logger.Error(logging.Sqlite3, logging.Migration, err.Error(), nil)
+	} else if moved > 0 {
+		logger.Infof("Moved the GSTR-9 period of %d rows to March", moved)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "github.com/jaganathanb/dapps-api/constants"
+
+// AuditLog is a change made to an audited entity. CreatedBy is the actor, 0 when the change was
+// made by the system
+type AuditLog struct {
+	BaseModel
+	Entity    string                `gorm:"type:string;size:50;not null;index"`
+	EntityId  string                `gorm:"type:string;size:50;index"`
+	Gstin     string                `gorm:"type:string;size:30;index"`
+	Action    constants.AuditAction `gorm:"type:string;size:10;not null"`
+	RequestId string                `gorm:"type:string;size:64;index"`
+	// Changes is the JSON of the changed columns with their value before and after
+	Changes string `gorm:"type:text"`
+}
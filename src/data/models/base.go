@@ -19,6 +19,11 @@ type BaseModel struct {
 	DeletedBy  *sql.NullInt64 `gorm:"null" json:"deletedBy"`
 }
 
+// DefaultScope is implemented by the models which list only some of their rows unless asked otherwise
+type DefaultScope interface {
+	DefaultScope(db *gorm.DB) *gorm.DB
+}
+
 func (m *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 	m.CreatedAt = time.Now().UTC()
 	return
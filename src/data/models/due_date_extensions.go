@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+)
+
+// DueDateExtension is a government notification extending the due date of a return period
+type DueDateExtension struct {
+	BaseModel
+	ReturnType   constants.GstReturnType           `gorm:"type:string;size:10;not null"`
+	RetPrd       string                            `gorm:"type:string;size:6;not null"`
+	StateCodes   sqlite_custom_type.SqliteStrArray `gorm:"type:text"`
+	DueDate      time.Time                         `gorm:"type:TIMESTAMP;not null"`
+	Notification string                            `gorm:"type:string;size:200;not null"`
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/jaganathanb/dapps-api/constants"
 	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+	"gorm.io/gorm"
 )
 
 type Gst struct {
@@ -22,19 +23,30 @@ type Gst struct {
 	Constitution     string                            `json:"ctb"`
 	Type             string                            `json:"dty"`
 	Status           string                            `json:"sts"`
-	Username         string                            `json:"username"`
-	Password         string                            `json:"password"`
+	Username         string                            `json:"username" gorm:"serializer:encrypted"`
+	Password         string                            `json:"password" gorm:"serializer:encrypted"`
 	LastUpdateDate   time.Time                         `json:"lastUpdateDate"`
 	CancellationDate time.Time                         `json:"cancellationDate"`
 	Nature           sqlite_custom_type.SqliteStrArray `json:"nba,omitempty;type:text[]"`
 	EinvoiceStatus   string                            `json:"einvoiceStatus"`
 	Adadr            []AdditionalAddress               `gorm:"foreignKey:Gstin;references:Gstin"`
 	Locked           bool                              `gorm:"type:bool;default:false"`
+	LockReason       string                            `gorm:"type:string;size:500;null;default:null"`
 	MobileNumber     string                            `gorm:"type:string;size:10;null;default:null"`
-	Email            string                            `json:"email"`
-	GstStatuses      []GstStatus                       `gorm:"foreignKey:Gstin;references:Gstin"`
-	Pradr            PermenantAddress                  `gorm:"foreignKey:Gstin;references:Gstin"`
-	Contacted        MobEmail                          `gorm:"foreignKey:Gstin;references:Gstin"`
+	// FilingFrequency is detected from the returns filed on the portal unless it is set manually. Turnover of the
+	// previous year decides the late fee caps
+	FilingFrequency       constants.FilingFrequency `gorm:"type:string;size:10;default:'Monthly'"`
+	FilingFrequencyManual bool                      `gorm:"type:bool;default:false"`
+	Turnover              *float64                  `gorm:"null;default:null"`
+	Email                 string                    `json:"email"`
+	GstStatuses           []GstStatus               `gorm:"foreignKey:Gstin;references:Gstin"`
+	Pradr                 PermenantAddress          `gorm:"foreignKey:Gstin;references:Gstin"`
+	Contacted             MobEmail                  `gorm:"foreignKey:Gstin;references:Gstin"`
+}
+
+// DefaultScope lists the GSTINs active on the portal
+func (Gst) DefaultScope(db *gorm.DB) *gorm.DB {
+	return db.Where("gsts.status = ?", "Active")
 }
 
 type MobEmail struct {
@@ -101,5 +113,6 @@ type GstStatus struct {
 	Status         constants.GstReturnStatus         `json:"status"`
 	Notes          string                            `json:"notes"`
 	PendingReturns sqlite_custom_type.SqliteStrArray `json:"pending_returns,omitempty;type:text[]"`
+	DueDate        time.Time                         `gorm:"type:TIMESTAMP;default:null"`
 	Gstin          string                            `gorm:"type:string,not null;size:30"`
 }
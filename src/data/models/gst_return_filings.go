@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// GstReturnFiling is a return the GSTIN filed for a period, as seen on the portal. Unlike GstStatus,
+// which only tracks the latest period of a return type, every filed period is kept
+type GstReturnFiling struct {
+	BaseModel
+	Gstin   string                  `gorm:"type:string;size:30;not null;uniqueIndex:idx_gst_return_filing"`
+	Rtntype constants.GstReturnType `gorm:"type:string;size:10;not null;uniqueIndex:idx_gst_return_filing"`
+	RetPrd  string                  `gorm:"type:string;size:6;not null;uniqueIndex:idx_gst_return_filing"`
+	// Period is the first day of the last month of the return period, RetPrd as a date to query by
+	Period        time.Time `gorm:"type:TIMESTAMP;not null;index"`
+	TaxPrd        string    `gorm:"type:string;size:20"`
+	FinancialYear string    `gorm:"type:string;size:10"`
+	Arn           string    `gorm:"type:string;size:30"`
+	Dof           string    `gorm:"type:string;size:10"`
+	FiledOn       time.Time `gorm:"type:TIMESTAMP;default:null"`
+	Mof           string    `gorm:"type:string;size:20"`
+	Valid         string    `gorm:"type:string;size:5"`
+}
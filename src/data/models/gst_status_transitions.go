@@ -0,0 +1,15 @@
+package models
+
+import "github.com/jaganathanb/dapps-api/constants"
+
+// GstStatusTransition records a change of the workflow status of a return. CreatedBy is who made it and
+// CreatedAt when, the changes made by the scrapper are by the user the scrape ran for
+type GstStatusTransition struct {
+	BaseModel
+	Gstin      string                    `gorm:"type:string;size:30;not null;index"`
+	Rtntype    constants.GstReturnType   `gorm:"type:string;size:10;not null"`
+	RetPrd     string                    `gorm:"type:string;size:6"`
+	FromStatus constants.GstReturnStatus `gorm:"type:string;size:30"`
+	ToStatus   constants.GstReturnStatus `gorm:"type:string;size:30;not null"`
+	Note       string                    `gorm:"type:string;size:500"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "github.com/jaganathanb/dapps-api/constants"
+
+// GstTaxAmount is the tax a GSTIN has to pay with a return, as entered by the staff
+type GstTaxAmount struct {
+	BaseModel
+	Gstin     string                  `gorm:"type:string;size:30;not null;uniqueIndex:idx_gst_tax_amount"`
+	Rtntype   constants.GstReturnType `gorm:"type:string;size:10;not null;uniqueIndex:idx_gst_tax_amount"`
+	RetPrd    string                  `gorm:"type:string;size:6;not null;uniqueIndex:idx_gst_tax_amount"`
+	TaxAmount float64                 `gorm:"not null;default:0"`
+	NilReturn bool                    `gorm:"type:bool;default:false"`
+}
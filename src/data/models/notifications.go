@@ -7,4 +7,6 @@ type Notifications struct {
 	Title       string `json:"title"`
 	IsRead      bool   `json:"isRead"`
 	UserId      int    `json:"userId"`
+	Gstin       string `gorm:"type:string;size:30;null;default:null" json:"gstin"`
+	ErrorCode   string `gorm:"type:string;size:50;null;default:null;index" json:"errorCode"`
 }
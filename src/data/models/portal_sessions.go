@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PortalSession is the GST portal cookies of a logged in username
+type PortalSession struct {
+	BaseModel
+	// UsernameHash finds the session of a username, the username is encrypted
+	UsernameHash string    `gorm:"type:string;size:64;not null;uniqueIndex"`
+	Username     string    `gorm:"type:text;not null;serializer:encrypted"`
+	Cookies      string    `gorm:"type:text;not null;serializer:encrypted"`
+	ExpiresAt    time.Time `gorm:"type:TIMESTAMP;not null"`
+}
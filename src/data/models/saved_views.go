@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/jaganathanb/dapps-api/constants"
+	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+)
+
+// SavedView is a named sort and filter of a grid, with the columns it shows, a user applies again. It is
+// listed to the users of the roles it is shared to
+type SavedView struct {
+	BaseModel
+	UserId   int                    `gorm:"not null;index"`
+	Resource constants.ViewResource `gorm:"type:string;size:30;not null;index"`
+	Name     string                 `gorm:"type:string;size:100;not null"`
+	// Filter is the sort and filters of the grid as JSON
+	Filter      string                            `gorm:"type:text"`
+	Columns     map[string]bool                   `gorm:"type:text;serializer:json"`
+	SharedRoles sqlite_custom_type.SqliteStrArray `gorm:"type:text"`
+}
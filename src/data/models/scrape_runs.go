@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+type ScrapeRun struct {
+	BaseModel
+	Trigger    constants.ScrapeRunTrigger `gorm:"type:string;size:20;not null"`
+	Status     constants.ScrapeRunStatus  `gorm:"type:string;size:20;not null;index"`
+	StartedAt  time.Time                  `gorm:"type:TIMESTAMP;not null"`
+	FinishedAt time.Time                  `gorm:"type:TIMESTAMP;default:null"`
+	Total      int
+	Succeeded  int
+	Failed     int
+	Items      []ScrapeRunItem `gorm:"foreignKey:ScrapeRunId"`
+}
+
+type ScrapeRunItem struct {
+	BaseModel
+	ScrapeRunId  int                           `gorm:"not null;index"`
+	Gstin        string                        `gorm:"type:string;size:30;not null;index"`
+	Status       constants.ScrapeRunItemStatus `gorm:"type:string;size:20;not null"`
+	ErrorMessage string
+	ErrorClass   constants.ScrapeErrorClass `gorm:"type:string;size:20;null;default:null"`
+	ErrorCode    constants.ScrapeErrorCode  `gorm:"type:string;size:50;null;default:null;index"`
+	Attempts     int
+	DurationMs   int64
+	StartedAt    time.Time           `gorm:"type:TIMESTAMP;default:null"`
+	FinishedAt   time.Time           `gorm:"type:TIMESTAMP;default:null"`
+	Artifacts    []ScrapeRunArtifact `gorm:"foreignKey:ScrapeRunItemId"`
+}
+
+// ScrapeRunArtifact is a file captured from the portal page when scrapping a GSTIN failed
+type ScrapeRunArtifact struct {
+	BaseModel
+	ScrapeRunItemId int    `gorm:"not null;index"`
+	Kind            string `gorm:"type:string;size:20;not null"`
+	FileName        string `gorm:"type:string;size:100;not null"`
+	ContentType     string `gorm:"type:string;size:50;not null"`
+	Path            string `gorm:"type:string;size:500;not null"`
+	Size            int64
+}
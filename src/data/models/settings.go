@@ -4,6 +4,6 @@ type Settings struct {
 	BaseModel
 	Crontab     string `json:"crontab"`
 	GstUsername string `json:"gstUsername"`
-	GstPassword string `json:"gstPassword"`
+	GstPassword string `json:"gstPassword" gorm:"serializer:encrypted"`
 	GstBaseUrl  string `json:"gstBaseUrl"`
 }
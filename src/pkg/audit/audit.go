@@ -0,0 +1,452 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const beforeKey = "audit:before"
+
+// bookkeeping columns change with every write, they are not changes of their own
+var bookkeeping = []string{"created_at", "created_by", "modified_at", "modified_by"}
+
+// Change is the value of a column before and after a write
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type actorKey struct{}
+type requestIdKey struct{}
+
+// WithActor tells the user a change is made by, when the context does not carry the authenticated one
+func WithActor(ctx context.Context, userId int) context.Context {
+	return context.WithValue(ctx, actorKey{}, userId)
+}
+
+// WithRequestId tells what the changes are made for, when the context is not of a request
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+type auditor struct {
+	tables map[string]bool
+}
+
+// Register writes an audit log entry for every insert, update and delete of the entities, in the
+// transaction of the write. Writes through Table() without a model are not audited
+func Register(database *gorm.DB, entities ...interface{}) error {
+	a := &auditor{tables: map[string]bool{}}
+
+	for _, entity := range entities {
+		stmt := &gorm.Statement{DB: database}
+		if err := stmt.Parse(entity); err != nil {
+			return err
+		}
+		a.tables[stmt.Schema.Table] = true
+	}
+
+	cb := database.Callback()
+
+	return errors.Join(
+		cb.Create().After("gorm:create").Register("audit:after_create", a.afterCreate),
+		cb.Update().Before("gorm:update").Register("audit:before_update", a.captureBefore),
+		cb.Update().After("gorm:update").Register("audit:after_update", a.afterUpdate),
+		cb.Delete().Before("gorm:delete").Register("audit:before_delete", a.captureBefore),
+		cb.Delete().After("gorm:delete").Register("audit:after_delete", a.afterDelete),
+	)
+}
+
+func (a *auditor) audited(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && a.tables[db.Statement.Schema.Table]
+}
+
+// captureBefore reads the rows an update or delete is going to change
+func (a *auditor) captureBefore(db *gorm.DB) {
+	if !a.audited(db) {
+		return
+	}
+
+	query, ok := a.affectedRows(db)
+	if !ok {
+		return
+	}
+
+	rows := []map[string]interface{}{}
+	err := query.Find(&rows).Error
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	db.InstanceSet(beforeKey, rows)
+}
+
+func (a *auditor) afterCreate(db *gorm.DB) {
+	if !a.audited(db) || db.RowsAffected == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	logs := []models.AuditLog{}
+
+	records := reflect.Indirect(stmt.ReflectValue)
+	if records.Kind() == reflect.Struct {
+		records = reflect.Append(reflect.MakeSlice(reflect.SliceOf(records.Type()), 0, 1), records)
+	}
+	if records.Kind() != reflect.Slice && records.Kind() != reflect.Array {
+		return
+	}
+
+	for i := range records.Len() {
+		record := reflect.Indirect(records.Index(i))
+		if record.Kind() != reflect.Struct {
+			continue
+		}
+
+		row := map[string]interface{}{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+
+			value, isZero := field.ValueOf(stmt.Context, record)
+			if !isZero {
+				row[field.DBName] = value
+			}
+		}
+
+		logs = append(logs, a.entry(db, constants.AuditCreate, row, diff(stmt.Schema, nil, row)))
+	}
+
+	a.write(db, logs)
+}
+
+func (a *auditor) afterUpdate(db *gorm.DB) {
+	before, ok := a.before(db)
+	if !ok {
+		return
+	}
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row["id"])
+	}
+
+	after := []map[string]interface{}{}
+	err := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table).Where("id IN ?", ids).Find(&after).Error
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	afterById := map[string]map[string]interface{}{}
+	for _, row := range after {
+		afterById[fmt.Sprint(normalize(row["id"]))] = row
+	}
+
+	logs := []models.AuditLog{}
+	for _, row := range before {
+		current, ok := afterById[fmt.Sprint(normalize(row["id"]))]
+		if !ok {
+			continue
+		}
+
+		changes := diff(db.Statement.Schema, row, current)
+		if len(changes) == 0 {
+			continue
+		}
+
+		// the base service deletes by setting deleted_by
+		action := constants.AuditUpdate
+		if c, ok := changes["deleted_by"]; ok && c.From == nil && c.To != nil {
+			action = constants.AuditDelete
+		}
+
+		logs = append(logs, a.entry(db, action, current, changes))
+	}
+
+	a.write(db, logs)
+}
+
+func (a *auditor) afterDelete(db *gorm.DB) {
+	before, ok := a.before(db)
+	if !ok {
+		return
+	}
+
+	logs := []models.AuditLog{}
+	for _, row := range before {
+		logs = append(logs, a.entry(db, constants.AuditDelete, row, diff(db.Statement.Schema, row, nil)))
+	}
+
+	a.write(db, logs)
+}
+
+func (a *auditor) before(db *gorm.DB) ([]map[string]interface{}, bool) {
+	if !a.audited(db) || db.RowsAffected == 0 {
+		return nil, false
+	}
+
+	value, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return nil, false
+	}
+
+	rows, ok := value.([]map[string]interface{})
+
+	return rows, ok && len(rows) > 0
+}
+
+// affectedRows queries the rows matching the conditions of the statement. The primary keys of the model
+// are conditions gorm adds itself while updating or deleting, they are added here the same way
+func (a *auditor) affectedRows(db *gorm.DB) (*gorm.DB, bool) {
+	stmt := db.Statement
+	query := db.Session(&gorm.Session{NewDB: true}).Table(stmt.Table)
+	conditions := false
+
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			query = query.Clauses(where)
+			conditions = true
+		}
+	}
+
+	if model := reflect.Indirect(stmt.ReflectValue); model.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if value, isZero := field.ValueOf(stmt.Context, model); !isZero {
+				query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: field.DBName}, Value: value})
+				conditions = true
+			}
+		}
+	}
+
+	// gorm refuses a write without conditions, unless it is allowed to change every row
+	return query, conditions || db.AllowGlobalUpdate
+}
+
+func (a *auditor) entry(db *gorm.DB, action constants.AuditAction, row map[string]interface{}, changes map[string]Change) models.AuditLog {
+	data, _ := json.Marshal(changes)
+
+	entityId := ""
+	if id := normalize(row["id"]); id != nil {
+		entityId = fmt.Sprint(id)
+	}
+
+	gstin, _ := normalize(row["gstin"]).(string)
+
+	return models.AuditLog{
+		Entity:    db.Statement.Schema.Name,
+		EntityId:  entityId,
+		Gstin:     gstin,
+		Action:    action,
+		RequestId: requestIdOf(db.Statement.Context),
+		Changes:   string(data),
+		BaseModel: models.BaseModel{CreatedBy: actorOf(db, action, row)},
+	}
+}
+
+func (a *auditor) write(db *gorm.DB, logs []models.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+
+	err := db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error
+	if err != nil {
+		db.AddError(err)
+	}
+}
+
+// diff returns the columns changed between the rows, before is nil for an insert and after for a delete
+func diff(s *schema.Schema, before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+
+	for _, field := range s.Fields {
+		if field.DBName == "" || slices.Contains(bookkeeping, field.DBName) {
+			continue
+		}
+
+		from, to := normalize(before[field.DBName]), normalize(after[field.DBName])
+
+		// a deleted row is recorded with the values it had
+		if after == nil && blank(from) {
+			continue
+		}
+
+		if sensitive(field) {
+			from, to = reveal(from), reveal(to)
+			if equal(from, to) {
+				continue
+			}
+			changes[field.DBName] = Change{From: mask(from), To: mask(to)}
+			continue
+		}
+
+		if !equal(from, to) {
+			changes[field.DBName] = Change{From: from, To: to}
+		}
+	}
+
+	return changes
+}
+
+// sensitive columns are only told to have changed, never their values
+func sensitive(field *schema.Field) bool {
+	return field.TagSettings["SERIALIZER"] == encryption.SerializerName || field.DBName == "password"
+}
+
+func reveal(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || encryption.GetKeyring() == nil {
+		return value
+	}
+
+	plain, err := encryption.GetKeyring().Decrypt(s)
+	if err != nil {
+		return value
+	}
+
+	return plain
+}
+
+func mask(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return encryption.MaskValue(fmt.Sprint(value))
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(v)
+	case driver.Valuer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil
+		}
+
+		dv, err := v.Value()
+		if err != nil {
+			return nil
+		}
+
+		return normalize(dv)
+	}
+
+	return value
+}
+
+func blank(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case time.Time:
+		return v.IsZero()
+	}
+
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	ta, okA := a.(time.Time)
+	tb, okB := b.(time.Time)
+	if okA && okB {
+		return ta.Equal(tb)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// actorOf returns the user who made the change: the authenticated user of the request, the one the
+// context was given or else the one the service recorded in the by columns. 0 is the system
+func actorOf(db *gorm.DB, action constants.AuditAction, row map[string]interface{}) int {
+	ctx := db.Statement.Context
+
+	if id, ok := toInt(ctx.Value(constants.UserIdKey)); ok {
+		return id
+	}
+
+	if id, ok := toInt(ctx.Value(actorKey{})); ok {
+		return id
+	}
+
+	switch action {
+	case constants.AuditCreate:
+		if id, ok := toInt(normalize(row["created_by"])); ok {
+			return id
+		}
+	default:
+		for _, column := range []string{"deleted_by", "modified_by"} {
+			if id, ok := assigned(db, column); ok {
+				return id
+			}
+		}
+	}
+
+	return 0
+}
+
+// assigned returns the value the write sets the column to
+func assigned(db *gorm.DB, column string) (int, bool) {
+	switch dest := db.Statement.Dest.(type) {
+	case map[string]interface{}:
+		return toInt(normalize(dest[column]))
+	case *map[string]interface{}:
+		return toInt(normalize((*dest)[column]))
+	}
+
+	field := db.Statement.Schema.LookUpField(column)
+	value := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	if field == nil || value.Kind() != reflect.Struct || value.Type() != db.Statement.Schema.ModelType {
+		return 0, false
+	}
+
+	v, isZero := field.ValueOf(db.Statement.Context, value)
+	if isZero {
+		return 0, false
+	}
+
+	return toInt(normalize(v))
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, v > 0
+	case int64:
+		return int(v), v > 0
+	case float64:
+		return int(v), v > 0
+	}
+
+	return 0, false
+}
+
+func requestIdOf(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIdKey{}).(string); ok {
+		return id
+	}
+
+	// set on the gin context by the request id middleware
+	id, _ := ctx.Value(constants.RequestIdKey).(string)
+
+	return id
+}
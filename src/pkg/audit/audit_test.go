@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"github.com/samber/lo"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testGstin = "33AOSPA7307Q1ZI"
+
+// newTestDb returns an in-memory database auditing the GSTs and the users
+func newTestDb(t *testing.T) *gorm.DB {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := encryption.NewKeyring(&config.Config{Encryption: config.EncryptionConfig{MasterKey: key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.Register(keyring)
+
+	database, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDb, _ := database.DB()
+		sqlDb.Close()
+	})
+
+	err = database.AutoMigrate(&models.Gst{}, &models.User{}, &models.Settings{}, &models.AuditLog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Register(database, &models.Gst{}, &models.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return database
+}
+
+// createGst creates a GST and clears the audit log of its creation
+func createGst(t *testing.T, database *gorm.DB) models.Gst {
+	gst := models.Gst{Gstin: testGstin, Name: "Arun", Status: "Active", Username: "arun", Password: "secret",
+		BaseModel: models.BaseModel{CreatedBy: 1}}
+	if err := database.Create(&gst).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	database.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.AuditLog{})
+
+	return gst
+}
+
+// auditLogs returns the audit log entries with their changes
+func auditLogs(t *testing.T, database *gorm.DB) ([]models.AuditLog, []map[string]Change) {
+	var logs []models.AuditLog
+	if err := database.Order("id").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make([]map[string]Change, len(logs))
+	for i, log := range logs {
+		if err := json.Unmarshal([]byte(log.Changes), &changes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return logs, changes
+}
+
+func modifiedBy(id int64) *sql.NullInt64 {
+	return &sql.NullInt64{Int64: id, Valid: true}
+}
+
+func TestAuditCreate(t *testing.T) {
+	database := newTestDb(t)
+
+	gst := models.Gst{Gstin: testGstin, Name: "Arun", Status: "Active", Password: "secret", BaseModel: models.BaseModel{CreatedBy: 4}}
+	if err := database.Create(&gst).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	logs, changes := auditLogs(t, database)
+	if len(logs) != 1 {
+		t.Fatalf("audit logs = %d, want 1", len(logs))
+	}
+
+	log := logs[0]
+	if log.Action != constants.AuditCreate || log.Entity != "Gst" || log.EntityId != fmt.Sprint(gst.Id) || log.Gstin != testGstin {
+		t.Errorf("audit log = %+v", log)
+	}
+	if log.CreatedBy != 4 {
+		t.Errorf("actor = %d, want the creator 4", log.CreatedBy)
+	}
+	if c := changes[0]["name"]; c.From != nil || c.To != "Arun" {
+		t.Errorf("name change = %+v", c)
+	}
+	if c := changes[0]["password"]; c.To != encryption.Mask {
+		t.Errorf("password change = %+v, want masked", c)
+	}
+}
+
+func TestAuditUpdate(t *testing.T) {
+	database := newTestDb(t)
+	gst := createGst(t, database)
+
+	ctx := WithRequestId(WithActor(context.Background(), 7), "request-1")
+
+	err := database.WithContext(ctx).Model(&models.Gst{}).Where("gstin = ?", testGstin).
+		Updates(models.Gst{Name: "Arun Textiles", Username: "arun.t", Password: "changed", BaseModel: models.BaseModel{ModifiedBy: modifiedBy(3)}}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, changes := auditLogs(t, database)
+	if len(logs) != 1 {
+		t.Fatalf("audit logs = %d, want 1", len(logs))
+	}
+
+	log := logs[0]
+	if log.Action != constants.AuditUpdate || log.Entity != "Gst" || log.EntityId != fmt.Sprint(gst.Id) || log.Gstin != testGstin {
+		t.Errorf("audit log = %+v", log)
+	}
+	if log.CreatedBy != 7 {
+		t.Errorf("actor = %d, want 7 of the context", log.CreatedBy)
+	}
+	if log.RequestId != "request-1" {
+		t.Errorf("request id = %q, want request-1", log.RequestId)
+	}
+
+	columns := lo.Keys(changes[0])
+	slices.Sort(columns)
+	if !slices.Equal(columns, []string{"name", "password", "username"}) {
+		t.Errorf("changed columns = %v, want the name and the credentials", columns)
+	}
+
+	if c := changes[0]["name"]; c.From != "Arun" || c.To != "Arun Textiles" {
+		t.Errorf("name change = %+v", c)
+	}
+
+	for _, column := range []string{"username", "password"} {
+		c := changes[0][column]
+		if c.From != encryption.Mask || c.To != encryption.Mask {
+			t.Errorf("%s change = %+v, want masked", column, c)
+		}
+	}
+	for _, secret := range []string{"arun.t", "secret", "changed"} {
+		if strings.Contains(log.Changes, secret) {
+			t.Errorf("changes %s reveal %q", log.Changes, secret)
+		}
+	}
+}
+
+func TestAuditUpdateActor(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		actor int
+	}{
+		{name: "authenticated user", ctx: context.WithValue(WithActor(context.Background(), 7), constants.UserIdKey, 5), actor: 5},
+		{name: "context actor", ctx: WithActor(context.Background(), 7), actor: 7},
+		{name: "modified by", ctx: context.Background(), actor: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			database := newTestDb(t)
+			createGst(t, database)
+
+			err := database.WithContext(test.ctx).Model(&models.Gst{}).Where("gstin = ?", testGstin).
+				Updates(map[string]interface{}{"name": "Arun Textiles", "modified_by": 3}).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logs, _ := auditLogs(t, database)
+			if len(logs) != 1 || logs[0].CreatedBy != test.actor {
+				t.Errorf("audit logs = %+v, want one by %d", logs, test.actor)
+			}
+		})
+	}
+}
+
+func TestAuditUserPassword(t *testing.T) {
+	database := newTestDb(t)
+
+	user := models.User{Username: "admin", Password: "hash-1", Email: "admin@dapps.in", MobileNumber: "09111112222", BaseModel: models.BaseModel{CreatedBy: 1}}
+	if err := database.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	database.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.AuditLog{})
+
+	err := database.Model(&user).Updates(map[string]interface{}{"password": "hash-2", "modified_by": 1}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, changes := auditLogs(t, database)
+	if len(logs) != 1 {
+		t.Fatalf("audit logs = %d, want 1", len(logs))
+	}
+	if c, ok := changes[0]["password"]; !ok || c.From != encryption.Mask || c.To != encryption.Mask {
+		t.Errorf("password change = %+v, want masked", c)
+	}
+	if strings.Contains(logs[0].Changes, "hash-") {
+		t.Errorf("changes %s reveal the password", logs[0].Changes)
+	}
+}
+
+func TestAuditSoftDelete(t *testing.T) {
+	database := newTestDb(t)
+	createGst(t, database)
+
+	err := database.Model(&models.Gst{}).Where("gstin = ?", testGstin).Updates(map[string]interface{}{"deleted_by": 2}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, _ := auditLogs(t, database)
+	if len(logs) != 1 || logs[0].Action != constants.AuditDelete || logs[0].CreatedBy != 2 {
+		t.Errorf("audit logs = %+v, want one delete by 2", logs)
+	}
+}
+
+func TestAuditUnchangedAndUnregistered(t *testing.T) {
+	database := newTestDb(t)
+	createGst(t, database)
+
+	// the same values and the bookkeeping columns are no change
+	err := database.Model(&models.Gst{}).Where("gstin = ?", testGstin).Updates(map[string]interface{}{"name": "Arun", "modified_by": 3}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = database.Create(&models.Settings{GstUsername: "portal"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	logs, _ := auditLogs(t, database)
+	if len(logs) != 0 {
+		t.Errorf("audit logs = %+v, want none", logs)
+	}
+}
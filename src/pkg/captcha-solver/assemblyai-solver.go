@@ -0,0 +1,44 @@
+package captcha_solver
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"sync"
+
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/pkg/s2t"
+)
+
+var numericRegex = regexp.MustCompile(`[^\p{N} ]+`)
+
+// AssemblyAISolver transcribes the audio captcha through AssemblyAI.
+type AssemblyAISolver struct {
+	speechService *s2t.DAppsSpeechToText
+}
+
+var assemblyAISolver *AssemblyAISolver
+var assemblyAISolverOnce sync.Once
+
+func NewAssemblyAISolver(cfg *config.Config) *AssemblyAISolver {
+	assemblyAISolverOnce.Do(func() {
+		assemblyAISolver = &AssemblyAISolver{
+			speechService: s2t.NewDAppsSpeechToText(cfg),
+		}
+	})
+
+	return assemblyAISolver
+}
+
+func (s *AssemblyAISolver) Medium() Medium {
+	return Audio
+}
+
+func (s *AssemblyAISolver) Solve(ctx context.Context, captcha Captcha) (string, error) {
+	code, err := s.speechService.SpeechToText(ctx, bytes.NewReader(captcha.Data))
+	if err != nil {
+		return "", err
+	}
+
+	return numericRegex.ReplaceAllString(code, ""), nil
+}
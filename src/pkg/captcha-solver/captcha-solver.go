@@ -0,0 +1,53 @@
+package captcha_solver
+
+import (
+	"context"
+
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+type Medium string
+
+const (
+	// Audio captcha served by the portal's `/audiocaptcha` endpoint
+	Audio Medium = "audio"
+	// Image captcha rendered in `#imgCaptcha`
+	Image Medium = "image"
+	// None means the solver does not need the captcha at all
+	None Medium = "none"
+)
+
+const (
+	AssemblyAISolverName = "assemblyai"
+	ImageSolverName      = "image"
+	ManualSolverName     = "manual"
+	StubSolverName       = "stub"
+)
+
+type Captcha struct {
+	Id     string
+	Gstin  string
+	Medium Medium
+	Data   []byte
+}
+
+// CaptchaSolver turns the captcha shown on the GST portal login page into the code to be typed in.
+type CaptchaSolver interface {
+	// Medium tells the scrapper which captcha it has to capture for the solver
+	Medium() Medium
+	Solve(ctx context.Context, captcha Captcha) (string, error)
+}
+
+// NewCaptchaSolver returns the solver configured in `captcha.solver`. Defaults to the AssemblyAI audio solver.
+func NewCaptchaSolver(cfg *config.Config) CaptchaSolver {
+	switch cfg.Captcha.Solver {
+	case ImageSolverName:
+		return NewImageSolver(cfg)
+	case ManualSolverName:
+		return NewManualSolver(cfg)
+	case StubSolverName:
+		return NewStubSolver(cfg)
+	default:
+		return NewAssemblyAISolver(cfg)
+	}
+}
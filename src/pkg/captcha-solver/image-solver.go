@@ -0,0 +1,65 @@
+package captcha_solver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+// ImageSolver posts the `#imgCaptcha` screenshot to the OCR service at `captcha.ocrUrl`,
+// which answers with the recognised text as plain text.
+type ImageSolver struct {
+	cfg        *config.Config
+	httpClient http.Client
+}
+
+var imageSolver *ImageSolver
+var imageSolverOnce sync.Once
+
+func NewImageSolver(cfg *config.Config) *ImageSolver {
+	imageSolverOnce.Do(func() {
+		imageSolver = &ImageSolver{cfg: cfg, httpClient: http.Client{}}
+	})
+
+	return imageSolver
+}
+
+func (s *ImageSolver) Medium() Medium {
+	return Image
+}
+
+func (s *ImageSolver) Solve(ctx context.Context, captcha Captcha) (string, error) {
+	if s.cfg.Captcha.OcrUrl == "" {
+		return "", errors.New("captcha OCR url is not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Captcha.OcrUrl, bytes.NewReader(captcha.Data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "image/png")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("captcha OCR service responded with %s", resp.Status)
+	}
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(text)), nil
+}
@@ -0,0 +1,88 @@
+package captcha_solver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+// ManualSolver hands the image captcha over to an operator and waits for them to answer it.
+type ManualSolver struct {
+	cfg     *config.Config
+	notify  func(captcha Captcha)
+	pending map[string]chan string
+	mutex   sync.Mutex
+}
+
+var manualSolver *ManualSolver
+var manualSolverOnce sync.Once
+
+func NewManualSolver(cfg *config.Config) *ManualSolver {
+	manualSolverOnce.Do(func() {
+		manualSolver = &ManualSolver{cfg: cfg, pending: map[string]chan string{}}
+	})
+
+	return manualSolver
+}
+
+// OnCaptcha sets how a captcha is pushed to the operator.
+func (s *ManualSolver) OnCaptcha(notify func(captcha Captcha)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.notify = notify
+}
+
+func (s *ManualSolver) Medium() Medium {
+	return Image
+}
+
+func (s *ManualSolver) Solve(ctx context.Context, captcha Captcha) (string, error) {
+	answer := make(chan string, 1)
+
+	s.mutex.Lock()
+	notify := s.notify
+	s.pending[captcha.Id] = answer
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.pending, captcha.Id)
+		s.mutex.Unlock()
+	}()
+
+	if notify == nil {
+		return "", fmt.Errorf("no operator is listening to solve the captcha for GSTIN %s", captcha.Gstin)
+	}
+
+	notify(captcha)
+
+	timer := time.NewTimer(s.cfg.Captcha.ManualTimeout * time.Second)
+	defer timer.Stop()
+
+	select {
+	case code := <-answer:
+		return code, nil
+	case <-timer.C:
+		return "", fmt.Errorf("captcha for GSTIN %s was not answered in time", captcha.Gstin)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Answer hands the operator's code to the scrapper waiting on the captcha. Returns false when nobody is waiting for it.
+func (s *ManualSolver) Answer(id string, code string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	answer, found := s.pending[id]
+	if found {
+		answer <- code
+		delete(s.pending, id)
+	}
+
+	return found
+}
@@ -0,0 +1,32 @@
+package captcha_solver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+// StubSolver always answers `captcha.stubCode`. Meant for the fake GST portal and tests.
+type StubSolver struct {
+	code string
+}
+
+var stubSolver *StubSolver
+var stubSolverOnce sync.Once
+
+func NewStubSolver(cfg *config.Config) *StubSolver {
+	stubSolverOnce.Do(func() {
+		stubSolver = &StubSolver{code: cfg.Captcha.StubCode}
+	})
+
+	return stubSolver
+}
+
+func (s *StubSolver) Medium() Medium {
+	return None
+}
+
+func (s *StubSolver) Solve(ctx context.Context, captcha Captcha) (string, error) {
+	return s.code, nil
+}
@@ -0,0 +1,371 @@
+package dynamic_filter
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FieldType decides how the values of a filter on a field are read
+type FieldType string
+
+const (
+	Text    FieldType = "text"
+	Number  FieldType = "number"
+	Date    FieldType = "date"
+	Boolean FieldType = "boolean"
+	// Set filters match any of a list of values, whatever the type of the field
+	Set FieldType = "set"
+)
+
+// dateLayouts are the layouts a date filter value is read in, the grid sends 2006-01-02 15:04:05
+var dateLayouts = []string{"2006-01-02 15:04:05", "2006-01-02", time.RFC3339}
+
+// operators the filters of each type of field support, the ones of the grid
+var operators = map[FieldType][]string{
+	Text:    {"contains", "notContains", "equals", "notEqual", "startsWith", "endsWith", "blank", "notBlank"},
+	Number:  {"equals", "notEqual", "lessThan", "lessThanOrEqual", "greaterThan", "greaterThanOrEqual", "inRange", "blank", "notBlank"},
+	Date:    {"equals", "notEqual", "lessThan", "lessThanOrEqual", "greaterThan", "greaterThanOrEqual", "inRange", "blank", "notBlank"},
+	Boolean: {"equals", "notEqual", "blank", "notBlank"},
+}
+
+// countSuffix names the number of items of an array field, as in PendingReturns.count
+const countSuffix = ".count"
+
+// Field is a column of a model a filter or sort refers to
+type Field struct {
+	Column clause.Column
+	Type   FieldType
+	// expr is read from the column instead of its value, like the number of items of an array
+	expr clause.Expr
+}
+
+// Schema resolves the fields of a model by the names the grid refers to them with
+type Schema struct {
+	db     *gorm.DB
+	schema *schema.Schema
+}
+
+// Parse reads the fields of the model the way gorm maps them
+func Parse(db *gorm.DB, model interface{}) (*Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	return &Schema{db: db, schema: stmt.Schema}, nil
+}
+
+// Table of the model
+func (s *Schema) Table() string {
+	return s.schema.Table
+}
+
+// Field returns the column of the model by its field name or column name. The number of items of an
+// array field is read with the count suffix
+func (s *Schema) Field(name string) (Field, bool) {
+	if name, ok := strings.CutSuffix(name, countSuffix); ok {
+		field, ok := s.lookUp(name)
+		if !ok || !strings.HasSuffix(string(field.GORMDataType), "[]") {
+			return Field{}, false
+		}
+
+		column := clause.Column{Table: s.schema.Table, Name: field.DBName}
+		return Field{Column: column, Type: Number, expr: s.arrayLength(column)}, true
+	}
+
+	field, ok := s.lookUp(name)
+	if !ok {
+		return Field{}, false
+	}
+
+	return Field{Column: clause.Column{Table: s.schema.Table, Name: field.DBName}, Type: fieldTypeOf(field)}, true
+}
+
+// lookUp returns the field of a column. Encrypted fields are left out, their values differ every time they
+// are stored so they can neither be filtered nor sorted by
+func (s *Schema) lookUp(name string) (*schema.Field, bool) {
+	field, ok := s.schema.FieldsByName[name]
+	if !ok {
+		field, ok = s.schema.FieldsByDBName[name]
+	}
+
+	return field, ok && field.DBName != "" && field.TagSettings["SERIALIZER"] != encryption.SerializerName
+}
+
+// arrayLength counts the items of an array column, it is stored as a JSON array on every database
+func (s *Schema) arrayLength(column clause.Column) clause.Expr {
+	if s.db.Dialector.Name() == "postgres" {
+		return clause.Expr{SQL: "COALESCE(json_array_length(NULLIF(?, '')::json), 0)", Vars: []interface{}{column}}
+	}
+
+	return clause.Expr{SQL: "COALESCE(json_array_length(NULLIF(?, '')), 0)", Vars: []interface{}{column}}
+}
+
+// target is what a condition on the field compares, the column or the expression read from it
+func (f Field) target() interface{} {
+	if f.expr.SQL != "" {
+		return f.expr
+	}
+
+	return f.Column
+}
+
+func fieldTypeOf(field *schema.Field) FieldType {
+	// the type of the go field, a type tag names the type of the column
+	switch field.GORMDataType {
+	case schema.Bool:
+		return Boolean
+	case schema.Int, schema.Uint, schema.Float:
+		return Number
+	case schema.Time:
+		return Date
+	}
+
+	return Text
+}
+
+// Compile returns the conditions of the filters on the fields of the model, with their values bound as
+// parameters. A dotted name filters on a field of a relation, like GstStatuses.Status. The filters on
+// unknown fields are left out, the way the grid sends the columns it shows
+func (s *Schema) Compile(filters map[string]dto.Filter) ([]clause.Expression, error) {
+	exprs := []clause.Expression{}
+	relations := []*schema.Relationship{}
+	related := map[string]map[string]dto.Filter{}
+
+	// the order of a map is random, the statements are kept the same for the same filters
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := s.Field(name)
+		if !ok {
+			relation, path, ok := s.relation(name)
+			if !ok {
+				continue
+			}
+
+			if _, ok := related[relation.Name]; !ok {
+				relations = append(relations, relation)
+				related[relation.Name] = map[string]dto.Filter{}
+			}
+			related[relation.Name][path] = filters[name]
+			continue
+		}
+
+		expr, err := Condition(field, filters[name])
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	for _, relation := range relations {
+		conditions, err := s.related(relation).Compile(related[relation.Name])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(conditions) > 0 {
+			exprs = append(exprs, s.exists(relation, conditions))
+		}
+	}
+
+	return exprs, nil
+}
+
+// Order returns the columns to sort by, the unknown fields are left out
+func (s *Schema) Order(sorts *[]dto.Sort) []clause.OrderByColumn {
+	columns := []clause.OrderByColumn{}
+	if sorts == nil {
+		return columns
+	}
+
+	for _, st := range *sorts {
+		field, ok := s.Field(st.ColId)
+		if !ok || field.expr.SQL != "" || (st.Sort != "asc" && st.Sort != "desc") {
+			continue
+		}
+
+		columns = append(columns, clause.OrderByColumn{Column: field.Column, Desc: st.Sort == "desc"})
+	}
+
+	return columns
+}
+
+// Condition compiles the filter on the field to a condition with its values bound as parameters
+func Condition(field Field, filter dto.Filter) (clause.Expression, error) {
+	if len(filter.Conditions) > 0 {
+		return conditions(field, filter)
+	}
+
+	fieldType := field.Type
+	if FieldType(filter.FilterType) == Set {
+		fieldType = Set
+	}
+
+	if fieldType == Set {
+		values := make([]interface{}, 0, len(filter.Values))
+		for _, v := range filter.Values {
+			value, err := parseValue(field.Type, v)
+			if err != nil {
+				return nil, invalidFilter(field, err.Error())
+			}
+			values = append(values, value)
+		}
+
+		return clause.IN{Column: field.target(), Values: values}, nil
+	}
+
+	if !slices.Contains(operators[fieldType], filter.Type) {
+		return nil, invalidFilter(field, fmt.Sprintf("%s is not a filter of a %s field", filter.Type, fieldType))
+	}
+
+	switch filter.Type {
+	case "blank":
+		return blank(field, fieldType), nil
+	case "notBlank":
+		return clause.Not(blank(field, fieldType)), nil
+	case "contains":
+		return like(field.target(), "%"+escapeLike(filter.From)+"%"), nil
+	case "notContains":
+		return clause.Not(like(field.target(), "%"+escapeLike(filter.From)+"%")), nil
+	case "startsWith":
+		return like(field.target(), escapeLike(filter.From)+"%"), nil
+	case "endsWith":
+		return like(field.target(), "%"+escapeLike(filter.From)), nil
+	}
+
+	from, err := parseValue(fieldType, filter.From)
+	if err != nil {
+		return nil, invalidFilter(field, err.Error())
+	}
+
+	// the grid sends a date at midnight, it matches the times of the whole day
+	if fieldType == Date {
+		day := startOfDay(from.(time.Time))
+		switch filter.Type {
+		case "equals":
+			return clause.And(clause.Gte{Column: field.target(), Value: day}, clause.Lt{Column: field.target(), Value: day.AddDate(0, 0, 1)}), nil
+		case "notEqual":
+			return clause.Or(clause.Lt{Column: field.target(), Value: day}, clause.Gte{Column: field.target(), Value: day.AddDate(0, 0, 1)}), nil
+		}
+	}
+
+	switch filter.Type {
+	case "equals":
+		return clause.Eq{Column: field.target(), Value: from}, nil
+	case "notEqual":
+		return clause.Neq{Column: field.target(), Value: from}, nil
+	case "lessThan":
+		return clause.Lt{Column: field.target(), Value: from}, nil
+	case "lessThanOrEqual":
+		return clause.Lte{Column: field.target(), Value: from}, nil
+	case "greaterThan":
+		return clause.Gt{Column: field.target(), Value: from}, nil
+	case "greaterThanOrEqual":
+		return clause.Gte{Column: field.target(), Value: from}, nil
+	}
+
+	// inRange
+	to, err := parseValue(fieldType, filter.To)
+	if err != nil {
+		return nil, invalidFilter(field, err.Error())
+	}
+
+	if fieldType == Date {
+		end := startOfDay(to.(time.Time)).AddDate(0, 0, 1)
+		return clause.And(clause.Gte{Column: field.target(), Value: from}, clause.Lt{Column: field.target(), Value: end}), nil
+	}
+
+	return clause.And(clause.Gte{Column: field.target(), Value: from}, clause.Lte{Column: field.target(), Value: to}), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// conditions compiles a filter with more than one condition on the field, joined by its operator
+func conditions(field Field, filter dto.Filter) (clause.Expression, error) {
+	operator := strings.ToLower(filter.Operator)
+	if operator != "" && operator != "and" && operator != "or" {
+		return nil, invalidFilter(field, fmt.Sprintf("%s is not an operator of conditions", filter.Operator))
+	}
+
+	exprs := make([]clause.Expression, 0, len(filter.Conditions))
+	for _, condition := range filter.Conditions {
+		// the grid sets the type of the field on the filter only
+		if condition.FilterType == "" {
+			condition.FilterType = filter.FilterType
+		}
+
+		expr, err := Condition(field, condition)
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	if operator == "or" {
+		return clause.Or(exprs...), nil
+	}
+
+	return clause.And(exprs...), nil
+}
+
+func parseValue(fieldType FieldType, value string) (interface{}, error) {
+	switch fieldType {
+	case Number:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case Boolean:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case Date:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not a date", value)
+	}
+
+	return value, nil
+}
+
+func blank(field Field, fieldType FieldType) clause.Expression {
+	if fieldType == Text {
+		return clause.Or(clause.Eq{Column: field.target(), Value: nil}, clause.Eq{Column: field.target(), Value: ""})
+	}
+
+	return clause.Eq{Column: field.target(), Value: nil}
+}
+
+// like matches the text case insensitively like the grid does, on SQLite and Postgres alike
+func like(column interface{}, pattern string) clause.Expression {
+	return clause.Expr{SQL: `LOWER(?) LIKE ? ESCAPE '\'`, Vars: []interface{}{column, strings.ToLower(pattern)}}
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func invalidFilter(field Field, reason string) error {
+	return &service_errors.ServiceError{
+		EndUserMessage:   service_errors.InvalidFilter,
+		TechnicalMessage: fmt.Sprintf("filter on %s: %s", field.Column.Name, reason),
+	}
+}
@@ -0,0 +1,484 @@
+package dynamic_filter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newTestDb opens an in-memory database. The encrypted serializer of the credentials of a GST is registered
+// for gorm to parse the model
+func newTestDb(t *testing.T) *gorm.DB {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := encryption.NewKeyring(&config.Config{Encryption: config.EncryptionConfig{MasterKey: key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.Register(keyring)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDb, _ := db.DB()
+		sqlDb.Close()
+	})
+
+	return db
+}
+
+// whereOf builds the query of the model with the conditions without running it, and returns its WHERE
+// clause and the values bound to it
+func whereOf(db *gorm.DB, model interface{}, exprs ...clause.Expression) (string, []interface{}) {
+	stmt := db.Session(&gorm.Session{DryRun: true}).Model(model).Clauses(clause.Where{Exprs: exprs}).Find(model).Statement
+
+	_, where, _ := strings.Cut(stmt.SQL.String(), " WHERE ")
+
+	return where, stmt.Vars
+}
+
+func isInvalidFilter(err error) bool {
+	var serviceErr *service_errors.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.EndUserMessage == service_errors.InvalidFilter
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+}
+
+type compileTest struct {
+	name    string
+	filters map[string]dto.Filter
+	where   string
+	vars    []interface{}
+	invalid bool
+}
+
+func runCompileTests(t *testing.T, model interface{}, tests []compileTest) {
+	db := newTestDb(t)
+
+	s, err := Parse(db, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exprs, err := s.Compile(test.filters)
+
+			if test.invalid {
+				if !isInvalidFilter(err) {
+					t.Fatalf("error = %v, want %s", err, service_errors.InvalidFilter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			where, vars := whereOf(db, model, exprs...)
+			if where != test.where {
+				t.Errorf("where = %s\nwant %s", where, test.where)
+			}
+			if !reflect.DeepEqual(vars, test.vars) && (len(vars) > 0 || len(test.vars) > 0) {
+				t.Errorf("vars = %#v, want %#v", vars, test.vars)
+			}
+		})
+	}
+}
+
+func TestCompileText(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "contains",
+			filters: map[string]dto.Filter{"Name": {FilterType: "text", Type: "contains", From: "Traders"}},
+			where:   "LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\'",
+			vars:    []interface{}{"%traders%"},
+		},
+		{
+			name:    "contains escapes wildcards",
+			filters: map[string]dto.Filter{"Name": {Type: "contains", From: `50%_a\b`}},
+			where:   "LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\'",
+			vars:    []interface{}{`%50\%\_a\\b%`},
+		},
+		{
+			name:    "notContains",
+			filters: map[string]dto.Filter{"name": {Type: "notContains", From: "x"}},
+			where:   "NOT LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\'",
+			vars:    []interface{}{"%x%"},
+		},
+		{
+			name:    "startsWith",
+			filters: map[string]dto.Filter{"Name": {Type: "startsWith", From: "a_"}},
+			where:   "LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\'",
+			vars:    []interface{}{`a\_%`},
+		},
+		{
+			name:    "endsWith",
+			filters: map[string]dto.Filter{"Name": {Type: "endsWith", From: "%"}},
+			where:   "LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\'",
+			vars:    []interface{}{`%\%`},
+		},
+		{
+			name:    "equals is bound",
+			filters: map[string]dto.Filter{"Name": {Type: "equals", From: "x' OR '1'='1"}},
+			where:   "`gsts`.`name` = ?",
+			vars:    []interface{}{"x' OR '1'='1"},
+		},
+		{
+			name:    "notEqual",
+			filters: map[string]dto.Filter{"Name": {Type: "notEqual", From: "x"}},
+			where:   "`gsts`.`name` <> ?",
+			vars:    []interface{}{"x"},
+		},
+		{
+			name:    "blank",
+			filters: map[string]dto.Filter{"Name": {Type: "blank"}},
+			where:   "(`gsts`.`name` IS NULL OR `gsts`.`name` = ?)",
+			vars:    []interface{}{""},
+		},
+		{
+			name:    "notBlank",
+			filters: map[string]dto.Filter{"Name": {Type: "notBlank"}},
+			where:   "NOT (`gsts`.`name` IS NULL OR `gsts`.`name` = ?)",
+			vars:    []interface{}{""},
+		},
+		{
+			name:    "unknown operator",
+			filters: map[string]dto.Filter{"Name": {Type: "lessThan", From: "x"}},
+			invalid: true,
+		},
+		{
+			name:    "operator of SQL",
+			filters: map[string]dto.Filter{"Name": {Type: "= '' OR 1=1 --", From: "x"}},
+			invalid: true,
+		},
+		{
+			name: "unknown columns are left out",
+			filters: map[string]dto.Filter{
+				"Unknown":                       {Type: "equals", From: "x"},
+				"name = '' OR 1=1 --":           {Type: "equals", From: "x"},
+				"gsts.name":                     {Type: "equals", From: "x"},
+				"GstStatuses":                   {Type: "equals", From: "x"},
+				"Name":                          {Type: "equals", From: "x"},
+				"GstStatuses.Unknown":           {Type: "equals", From: "x"},
+				"Username":                      {Type: "equals", From: "x"},
+				"GstStatuses.Status.Unknown.Id": {Type: "equals", From: "x"},
+			},
+			where: "`gsts`.`name` = ?",
+			vars:  []interface{}{"x"},
+		},
+	})
+}
+
+func TestCompileNumber(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "equals",
+			filters: map[string]dto.Filter{"Id": {FilterType: "number", Type: "equals", From: "5"}},
+			where:   "`gsts`.`id` = ?",
+			vars:    []interface{}{5.0},
+		},
+		{
+			name:    "notEqual",
+			filters: map[string]dto.Filter{"Id": {Type: "notEqual", From: "5"}},
+			where:   "`gsts`.`id` <> ?",
+			vars:    []interface{}{5.0},
+		},
+		{
+			name:    "lessThan",
+			filters: map[string]dto.Filter{"Id": {Type: "lessThan", From: "5"}},
+			where:   "`gsts`.`id` < ?",
+			vars:    []interface{}{5.0},
+		},
+		{
+			name:    "lessThanOrEqual",
+			filters: map[string]dto.Filter{"Id": {Type: "lessThanOrEqual", From: " 5 "}},
+			where:   "`gsts`.`id` <= ?",
+			vars:    []interface{}{5.0},
+		},
+		{
+			name:    "greaterThan",
+			filters: map[string]dto.Filter{"Id": {Type: "greaterThan", From: "5.5"}},
+			where:   "`gsts`.`id` > ?",
+			vars:    []interface{}{5.5},
+		},
+		{
+			name:    "greaterThanOrEqual",
+			filters: map[string]dto.Filter{"Id": {Type: "greaterThanOrEqual", From: "-1"}},
+			where:   "`gsts`.`id` >= ?",
+			vars:    []interface{}{-1.0},
+		},
+		{
+			name:    "inRange",
+			filters: map[string]dto.Filter{"Id": {Type: "inRange", From: "1", To: "5"}},
+			where:   "`gsts`.`id` >= ? AND `gsts`.`id` <= ?",
+			vars:    []interface{}{1.0, 5.0},
+		},
+		{
+			name:    "blank",
+			filters: map[string]dto.Filter{"Id": {Type: "blank"}},
+			where:   "`gsts`.`id` IS NULL",
+		},
+		{
+			name:    "not a number",
+			filters: map[string]dto.Filter{"Id": {Type: "equals", From: "1 OR 1=1"}},
+			invalid: true,
+		},
+		{
+			name:    "range end not a number",
+			filters: map[string]dto.Filter{"Id": {Type: "inRange", From: "1", To: "x"}},
+			invalid: true,
+		},
+		{
+			name:    "text operator",
+			filters: map[string]dto.Filter{"Id": {Type: "contains", From: "1"}},
+			invalid: true,
+		},
+	})
+}
+
+func TestCompileDate(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "equals matches the whole day",
+			filters: map[string]dto.Filter{"LastUpdateDate": {FilterType: "date", Type: "equals", From: "2024-01-02 00:00:00"}},
+			where:   "`gsts`.`last_update_date` >= ? AND `gsts`.`last_update_date` < ?",
+			vars:    []interface{}{day(2), day(3)},
+		},
+		{
+			name:    "equals at a time of the day",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "equals", From: "2024-01-02T10:30:00Z"}},
+			where:   "`gsts`.`last_update_date` >= ? AND `gsts`.`last_update_date` < ?",
+			vars:    []interface{}{day(2), day(3)},
+		},
+		{
+			name:    "notEqual leaves out the whole day",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "notEqual", From: "2024-01-02"}},
+			where:   "(`gsts`.`last_update_date` < ? OR `gsts`.`last_update_date` >= ?)",
+			vars:    []interface{}{day(2), day(3)},
+		},
+		{
+			name:    "inRange takes in the whole last day",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "inRange", From: "2024-01-02", To: "2024-01-05 00:00:00"}},
+			where:   "`gsts`.`last_update_date` >= ? AND `gsts`.`last_update_date` < ?",
+			vars:    []interface{}{day(2), day(6)},
+		},
+		{
+			name:    "lessThan",
+			filters: map[string]dto.Filter{"CreatedAt": {Type: "lessThan", From: "2024-01-02"}},
+			where:   "`gsts`.`created_at` < ?",
+			vars:    []interface{}{day(2)},
+		},
+		{
+			name:    "greaterThan",
+			filters: map[string]dto.Filter{"created_at": {Type: "greaterThan", From: "2024-01-02"}},
+			where:   "`gsts`.`created_at` > ?",
+			vars:    []interface{}{day(2)},
+		},
+		{
+			name:    "notBlank",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "notBlank"}},
+			where:   "`gsts`.`last_update_date` IS NOT NULL",
+		},
+		{
+			name:    "not a date",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "equals", From: "02/01/2024"}},
+			invalid: true,
+		},
+		{
+			name:    "range end not a date",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "inRange", From: "2024-01-02", To: "tomorrow"}},
+			invalid: true,
+		},
+		{
+			name:    "text operator",
+			filters: map[string]dto.Filter{"LastUpdateDate": {Type: "startsWith", From: "2024"}},
+			invalid: true,
+		},
+	})
+}
+
+func TestCompileBoolean(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "equals",
+			filters: map[string]dto.Filter{"Locked": {FilterType: "boolean", Type: "equals", From: "true"}},
+			where:   "`gsts`.`locked` = ?",
+			vars:    []interface{}{true},
+		},
+		{
+			name:    "notEqual",
+			filters: map[string]dto.Filter{"Locked": {Type: "notEqual", From: "false"}},
+			where:   "`gsts`.`locked` <> ?",
+			vars:    []interface{}{false},
+		},
+		{
+			name:    "not a boolean",
+			filters: map[string]dto.Filter{"Locked": {Type: "equals", From: "yes"}},
+			invalid: true,
+		},
+		{
+			name:    "number operator",
+			filters: map[string]dto.Filter{"Locked": {Type: "greaterThan", From: "true"}},
+			invalid: true,
+		},
+	})
+}
+
+func TestCompileSet(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "text values are bound",
+			filters: map[string]dto.Filter{"Status": {FilterType: "set", Values: []string{"Active", "x'); DROP TABLE gsts;--"}}},
+			where:   "`gsts`.`status` IN (?,?)",
+			vars:    []interface{}{"Active", "x'); DROP TABLE gsts;--"},
+		},
+		{
+			name:    "values read by the type of the field",
+			filters: map[string]dto.Filter{"Id": {FilterType: "set", Values: []string{"1", "2"}}},
+			where:   "`gsts`.`id` IN (?,?)",
+			vars:    []interface{}{1.0, 2.0},
+		},
+		{
+			name:    "no values",
+			filters: map[string]dto.Filter{"Status": {FilterType: "set", Values: []string{}}},
+			where:   "`gsts`.`status` IN (NULL)",
+		},
+		{
+			name:    "value not of the type of the field",
+			filters: map[string]dto.Filter{"Locked": {FilterType: "set", Values: []string{"true", "maybe"}}},
+			invalid: true,
+		},
+	})
+}
+
+func TestCompileConditions(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name: "or",
+			filters: map[string]dto.Filter{"Name": {FilterType: "text", Operator: "OR", Conditions: []dto.Filter{
+				{Type: "startsWith", From: "a"},
+				{Type: "equals", From: "b"},
+			}}},
+			where: "(LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\' OR `gsts`.`name` = ?)",
+			vars:  []interface{}{"a%", "b"},
+		},
+		{
+			name: "and",
+			filters: map[string]dto.Filter{"Id": {FilterType: "number", Operator: "AND", Conditions: []dto.Filter{
+				{Type: "greaterThan", From: "1"},
+				{Type: "lessThan", From: "5"},
+			}}},
+			where: "`gsts`.`id` > ? AND `gsts`.`id` < ?",
+			vars:  []interface{}{1.0, 5.0},
+		},
+		{
+			name: "unknown operator",
+			filters: map[string]dto.Filter{"Id": {Operator: "XOR", Conditions: []dto.Filter{
+				{Type: "greaterThan", From: "1"},
+			}}},
+			invalid: true,
+		},
+		{
+			name: "invalid condition",
+			filters: map[string]dto.Filter{"Id": {Operator: "AND", Conditions: []dto.Filter{
+				{Type: "greaterThan", From: "1"},
+				{Type: "greaterThan", From: "x"},
+			}}},
+			invalid: true,
+		},
+	})
+}
+
+func TestCompileLeavesOutEncryptedFields(t *testing.T) {
+	db := newTestDb(t)
+
+	s, err := Parse(db, &models.Gst{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Username", "username", "Password", "password"} {
+		if _, ok := s.Field(name); ok {
+			t.Errorf("encrypted field %s can be filtered on", name)
+		}
+	}
+
+	columns := s.Order(&[]dto.Sort{{ColId: "password", Sort: "asc"}, {ColId: "Name", Sort: "desc"}, {ColId: "Id", Sort: "up"}})
+	if len(columns) != 1 || columns[0].Column.Name != "name" || !columns[0].Desc {
+		t.Errorf("order = %+v, want name desc", columns)
+	}
+}
+
+func TestCompileMatchesRows(t *testing.T) {
+	db := newTestDb(t)
+	if err := db.AutoMigrate(&models.Gst{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []models.Gst{
+		{Gstin: "1", Name: "50% Traders", LastUpdateDate: day(1).Add(23*time.Hour + 59*time.Minute)},
+		{Gstin: "2", Name: "500 Traders", LastUpdateDate: day(2)},
+		{Gstin: "3", Name: "A_B Stores", LastUpdateDate: day(2).Add(10*time.Hour + 30*time.Minute)},
+		{Gstin: "4", Name: "AXB Stores", LastUpdateDate: day(3)},
+		{Gstin: "5", Name: `C\D Stores`, LastUpdateDate: day(5).Add(23 * time.Hour)},
+		{Gstin: "6", Name: "CD Stores", LastUpdateDate: day(6)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Parse(db, &models.Gst{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]dto.Filter
+		gstins  []string
+	}{
+		{"percent is literal", map[string]dto.Filter{"Name": {Type: "contains", From: "50%"}}, []string{"1"}},
+		{"underscore is literal", map[string]dto.Filter{"Name": {Type: "startsWith", From: "a_b"}}, []string{"3"}},
+		{"backslash is literal", map[string]dto.Filter{"Name": {Type: "contains", From: `c\d`}}, []string{"5"}},
+		{"date equals", map[string]dto.Filter{"LastUpdateDate": {Type: "equals", From: "2024-01-02 00:00:00"}}, []string{"2", "3"}},
+		{"date notEqual", map[string]dto.Filter{"LastUpdateDate": {Type: "notEqual", From: "2024-01-02 00:00:00"}}, []string{"1", "4", "5", "6"}},
+		{"date inRange", map[string]dto.Filter{"LastUpdateDate": {Type: "inRange", From: "2024-01-02 00:00:00", To: "2024-01-05 00:00:00"}}, []string{"2", "3", "4", "5"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exprs, err := s.Compile(test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gstins []string
+			err = db.Model(&models.Gst{}).Where(clause.And(exprs...)).Order("gstin").Pluck("gstin", &gstins).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gstins, test.gstins) {
+				t.Errorf("gstins = %v, want %v", gstins, test.gstins)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package dynamic_filter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Keyset pages through the rows by the values of the sort columns of the last row of the previous
+// page, instead of skipping the rows before the page. NULLs sort before any value in either direction
+// of the sort on every database, so a row can be told to be after another
+type Keyset struct {
+	schema  *Schema
+	columns []clause.OrderByColumn
+}
+
+type cursor struct {
+	Columns []string      `json:"c"`
+	Values  []interface{} `json:"v"`
+}
+
+// Keyset sorts by the columns and then by the primary key, the rows with the same values in the
+// sort columns are kept in the same order from page to page
+func (s *Schema) Keyset(order []clause.OrderByColumn) Keyset {
+	columns := slices.Clone(order)
+
+	for _, field := range s.schema.PrimaryFields {
+		if !slices.ContainsFunc(columns, func(c clause.OrderByColumn) bool { return c.Column.Name == field.DBName }) {
+			columns = append(columns, clause.OrderByColumn{Column: clause.Column{Table: s.schema.Table, Name: field.DBName}})
+		}
+	}
+
+	return Keyset{schema: s, columns: columns}
+}
+
+// Order sorts the rows the way the cursors are read
+func (k Keyset) Order() clause.Expression {
+	sql := make([]string, 0, len(k.columns))
+	vars := make([]interface{}, 0, len(k.columns))
+
+	for _, c := range k.columns {
+		if c.Desc {
+			sql = append(sql, "? DESC NULLS LAST")
+		} else {
+			sql = append(sql, "? ASC NULLS FIRST")
+		}
+		vars = append(vars, c.Column)
+	}
+
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sql, ", "), Vars: vars}}
+}
+
+// After returns the condition of the rows after the one the cursor points to
+func (k Keyset) After(value string) (clause.Expression, error) {
+	c, err := k.decode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// (a > x) OR (a = x AND b > y) OR (a = x AND b = y AND id > z)
+	branches := []clause.Expression{}
+	for i, column := range k.columns {
+		after := k.after(column, c.Values[i])
+		if after == nil {
+			continue
+		}
+
+		exprs := make([]clause.Expression, 0, i+1)
+		for j := range i {
+			exprs = append(exprs, clause.Eq{Column: k.columns[j].Column, Value: c.Values[j]})
+		}
+		branches = append(branches, clause.And(append(exprs, after)...))
+	}
+
+	if len(branches) == 0 {
+		return clause.Expr{SQL: "1 = 0"}, nil
+	}
+
+	return clause.Or(branches...), nil
+}
+
+func (k Keyset) after(column clause.OrderByColumn, value interface{}) clause.Expression {
+	switch {
+	case value == nil && column.Desc:
+		// nothing sorts after a NULL when descending
+		return nil
+	case value == nil:
+		return clause.Neq{Column: column.Column, Value: nil}
+	case column.Desc:
+		return clause.Or(clause.Lt{Column: column.Column, Value: value}, clause.Eq{Column: column.Column, Value: nil})
+	}
+
+	return clause.Gt{Column: column.Column, Value: value}
+}
+
+// Cursor returns the cursor pointing to the row, a model of the schema. The values are read back from the
+// database, a model can not tell a NULL from a zero value
+func (k Keyset) Cursor(db *gorm.DB, row interface{}) (string, error) {
+	model := reflect.Indirect(reflect.ValueOf(row))
+	query := db.Session(&gorm.Session{NewDB: true}).Table(k.schema.Table()).Select(k.columnNames())
+
+	for _, field := range k.schema.schema.PrimaryFields {
+		value, _ := field.ValueOf(db.Statement.Context, model)
+		query = query.Where(clause.Eq{Column: clause.Column{Table: k.schema.Table(), Name: field.DBName}, Value: value})
+	}
+
+	values := map[string]interface{}{}
+	err := query.Take(&values).Error
+	if err != nil {
+		return "", err
+	}
+
+	c := cursor{Columns: k.names(), Values: make([]interface{}, 0, len(k.columns))}
+	for _, column := range k.columns {
+		value := values[column.Column.Name]
+
+		switch v := value.(type) {
+		case []byte:
+			value = string(v)
+		case time.Time:
+			value = v.Format(time.RFC3339Nano)
+		}
+
+		c.Values = append(c.Values, value)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decode reads the cursor, it has to be of the same sort
+func (k Keyset) decode(value string) (cursor, error) {
+	c := cursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, invalidCursor(err.Error())
+	}
+
+	if !slices.Equal(c.Columns, k.names()) || len(c.Values) != len(k.columns) {
+		return c, invalidCursor("the cursor is of another sort")
+	}
+
+	// a cursor is only ever made of the values of columns
+	for _, v := range c.Values {
+		switch v.(type) {
+		case nil, string, float64, bool:
+		default:
+			return c, invalidCursor(fmt.Sprintf("%v is not the value of a column", v))
+		}
+	}
+
+	// JSON has no dates, they are read back by the type of the column. A date the database did not
+	// store as one is compared as it was read
+	for i, column := range k.columns {
+		field, _ := k.schema.Field(column.Column.Name)
+		s, ok := c.Values[i].(string)
+		if field.Type != Date || !ok {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			c.Values[i] = t
+		}
+	}
+
+	return c, nil
+}
+
+func (k Keyset) columnNames() []string {
+	names := make([]string, 0, len(k.columns))
+	for _, c := range k.columns {
+		names = append(names, c.Column.Name)
+	}
+
+	return names
+}
+
+func (k Keyset) names() []string {
+	names := make([]string, 0, len(k.columns))
+	for _, c := range k.columns {
+		direction := "asc"
+		if c.Desc {
+			direction = "desc"
+		}
+		names = append(names, c.Column.Name+" "+direction)
+	}
+
+	return names
+}
+
+func invalidCursor(reason string) error {
+	return &service_errors.ServiceError{EndUserMessage: service_errors.InvalidCursor, TechnicalMessage: reason}
+}
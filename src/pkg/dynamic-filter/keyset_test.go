@@ -0,0 +1,236 @@
+package dynamic_filter
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/gorm"
+)
+
+// keysetRow has a nullable column of each type a grid sorts by, with many rows sharing a value
+type keysetRow struct {
+	Id      int `gorm:"primarykey"`
+	Name    string
+	Amount  *float64
+	DueDate *time.Time
+}
+
+func newKeysetDb(t *testing.T) (*gorm.DB, []keysetRow) {
+	db := newTestDb(t)
+	if err := db.AutoMigrate(&keysetRow{}); err != nil {
+		t.Fatal(err)
+	}
+
+	amounts := []*float64{nil, ptr(10.0), ptr(20.0), ptr(10.0), nil, ptr(30.5)}
+	dueDates := []*time.Time{ptr(day(1)), nil, ptr(day(2).Add(90 * time.Minute)), ptr(day(1)), nil}
+	names := []string{"b", "a", "c", "a"}
+
+	rows := []keysetRow{}
+	for i := range 23 {
+		rows = append(rows, keysetRow{
+			Name:    names[i%len(names)],
+			Amount:  amounts[i%len(amounts)],
+			DueDate: dueDates[i%len(dueDates)],
+		})
+	}
+
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return db, rows
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// compareNullable sorts NULLs first ascending and last descending, like the keyset does
+func compareNullable[T any](a, b *T, desc bool, compare func(a, b T) int) int {
+	c := 0
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		c = -1
+	case b == nil:
+		c = 1
+	default:
+		c = compare(*a, *b)
+	}
+
+	if desc {
+		return -c
+	}
+	return c
+}
+
+func TestKeysetRoundTrip(t *testing.T) {
+	db, rows := newKeysetDb(t)
+
+	s, err := Parse(db, &keysetRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byAmount := func(desc bool) func(a, b keysetRow) int {
+		return func(a, b keysetRow) int {
+			return compareNullable(a.Amount, b.Amount, desc, func(a, b float64) int { return int(a*10 - b*10) })
+		}
+	}
+	byDueDate := func(desc bool) func(a, b keysetRow) int {
+		return func(a, b keysetRow) int {
+			return compareNullable(a.DueDate, b.DueDate, desc, func(a, b time.Time) int { return a.Compare(b) })
+		}
+	}
+	byName := func(a, b keysetRow) int {
+		return strings.Compare(a.Name, b.Name)
+	}
+
+	tests := []struct {
+		name    string
+		sorts   []dto.Sort
+		compare []func(a, b keysetRow) int
+	}{
+		{"by id", nil, nil},
+		{"nulls first ascending", []dto.Sort{{ColId: "Amount", Sort: "asc"}}, []func(a, b keysetRow) int{byAmount(false)}},
+		{"nulls last descending", []dto.Sort{{ColId: "Amount", Sort: "desc"}}, []func(a, b keysetRow) int{byAmount(true)}},
+		{"dates ascending", []dto.Sort{{ColId: "DueDate", Sort: "asc"}}, []func(a, b keysetRow) int{byDueDate(false)}},
+		{"dates descending", []dto.Sort{{ColId: "due_date", Sort: "desc"}}, []func(a, b keysetRow) int{byDueDate(true)}},
+		{
+			"ties on many columns",
+			[]dto.Sort{{ColId: "Name", Sort: "asc"}, {ColId: "Amount", Sort: "desc"}, {ColId: "DueDate", Sort: "asc"}},
+			[]func(a, b keysetRow) int{byName, byAmount(true), byDueDate(false)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the rows with the same values in the sort columns are kept in the order of their id
+			want := slices.Clone(rows)
+			slices.SortStableFunc(want, func(a, b keysetRow) int {
+				for _, compare := range test.compare {
+					if c := compare(a, b); c != 0 {
+						return c
+					}
+				}
+				return a.Id - b.Id
+			})
+
+			keyset := s.Keyset(s.Order(&test.sorts))
+
+			for _, size := range []int{1, 4, 7, len(rows)} {
+				got := []keysetRow{}
+				next := ""
+
+				for page := 0; ; page++ {
+					if page > len(rows) {
+						t.Fatalf("page size %d does not end", size)
+					}
+
+					query := db.Clauses(keyset.Order())
+					if next != "" {
+						after, err := keyset.After(next)
+						if err != nil {
+							t.Fatal(err)
+						}
+						query = query.Where(after)
+					}
+
+					items := []keysetRow{}
+					if err := query.Limit(size).Find(&items).Error; err != nil {
+						t.Fatal(err)
+					}
+
+					got = append(got, items...)
+					if len(items) < size {
+						break
+					}
+
+					next, err = keyset.Cursor(db, &items[len(items)-1])
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if !slices.Equal(ids(got), ids(want)) {
+					t.Errorf("page size %d ids = %v\nwant %v", size, ids(got), ids(want))
+				}
+			}
+		})
+	}
+}
+
+func ids(rows []keysetRow) []int {
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.Id)
+	}
+	return ids
+}
+
+func TestKeysetInvalidCursor(t *testing.T) {
+	db, rows := newKeysetDb(t)
+
+	s, err := Parse(db, &keysetRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byAmount := s.Keyset(s.Order(&[]dto.Sort{{ColId: "Amount", Sort: "asc"}}))
+	byName := s.Keyset(s.Order(&[]dto.Sort{{ColId: "Name", Sort: "asc"}}))
+
+	valid, err := byAmount.Cursor(db, &rows[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		keyset Keyset
+	}{
+		{"not base64", "not a cursor!", byAmount},
+		{"not json", encode("{not json"), byAmount},
+		{"of another sort", valid, byName},
+		{"of another direction", encode(`{"c":["amount desc","id asc"],"v":[10,4]}`), byAmount},
+		{"values missing", encode(`{"c":["amount asc","id asc"],"v":[10]}`), byAmount},
+		{"value not of a column", encode(`{"c":["amount asc","id asc"],"v":[{"a":1},4]}`), byAmount},
+		{"values of a list", encode(`{"c":["amount asc","id asc"],"v":[[1,2],4]}`), byAmount},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.keyset.After(test.cursor)
+
+			var serviceErr *service_errors.ServiceError
+			if !errors.As(err, &serviceErr) || serviceErr.EndUserMessage != service_errors.InvalidCursor {
+				t.Errorf("error = %v, want %s", err, service_errors.InvalidCursor)
+			}
+		})
+	}
+
+	// a cursor edited to hold SQL is only ever bound as a value
+	after, err := byAmount.After(encode(`{"c":["amount asc","id asc"],"v":["1 OR 1=1",4]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	where, vars := whereOf(db, &keysetRow{}, after)
+	if want := "(`keyset_rows`.`amount` > ? OR (`keyset_rows`.`amount` = ? AND `keyset_rows`.`id` > ?))"; where != want {
+		t.Errorf("where = %s\nwant %s", where, want)
+	}
+	if !slices.Contains(vars, interface{}("1 OR 1=1")) {
+		t.Errorf("vars = %v, want the value bound", vars)
+	}
+}
@@ -0,0 +1,64 @@
+package dynamic_filter
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// relation returns the relation a dotted name starts with and the name of the field within it
+func (s *Schema) relation(name string) (*schema.Relationship, string, bool) {
+	prefix, path, ok := strings.Cut(name, ".")
+	if !ok || path == "" {
+		return nil, "", false
+	}
+
+	relation, ok := s.relationship(prefix)
+
+	return relation, path, ok
+}
+
+// relationship returns the relation of the model by its field name
+func (s *Schema) relationship(name string) (*schema.Relationship, bool) {
+	relation, ok := s.schema.Relationships.Relations[name]
+
+	// the rows of a many to many relation are reached through a join table, none of the models has one
+	return relation, ok && relation.JoinTable == nil && relation.FieldSchema != nil
+}
+
+// related is the schema of the model of the relation
+func (s *Schema) related(relation *schema.Relationship) *Schema {
+	return &Schema{db: s.db, schema: relation.FieldSchema}
+}
+
+// exists matches the rows having a related row that meets all the conditions. The filters on a relation
+// are compiled to a single subquery, GstStatuses.Rtntype and GstStatuses.Status filter the same return,
+// and a row with many related rows is matched once unlike with a join
+func (s *Schema) exists(relation *schema.Relationship, exprs []clause.Expression) clause.Expression {
+	related := s.related(relation)
+
+	conditions := make([]clause.Expression, 0, len(relation.References)+len(exprs)+1)
+	for _, ref := range relation.References {
+		foreignKey := clause.Column{Table: ref.ForeignKey.Schema.Table, Name: ref.ForeignKey.DBName}
+		if ref.PrimaryKey == nil {
+			conditions = append(conditions, clause.Eq{Column: foreignKey, Value: ref.PrimaryValue})
+			continue
+		}
+
+		primaryKey := clause.Column{Table: ref.PrimaryKey.Schema.Table, Name: ref.PrimaryKey.DBName}
+		conditions = append(conditions, clause.Expr{SQL: "? = ?", Vars: []interface{}{primaryKey, foreignKey}})
+	}
+
+	if _, ok := related.lookUp("deleted_by"); ok {
+		conditions = append(conditions, clause.Eq{Column: clause.Column{Table: related.Table(), Name: "deleted_by"}, Value: nil})
+	}
+
+	query := s.db.Session(&gorm.Session{NewDB: true}).
+		Table(related.Table()).
+		Select("1").
+		Where(clause.And(append(conditions, exprs...)...))
+
+	return clause.Expr{SQL: "EXISTS (?)", Vars: []interface{}{query}}
+}
@@ -0,0 +1,146 @@
+package dynamic_filter
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+	"gorm.io/gorm/clause"
+)
+
+const gstStatusesExists = "EXISTS (SELECT 1 FROM `gst_statuses` WHERE `gsts`.`gstin` = `gst_statuses`.`gstin` AND `gst_statuses`.`deleted_by` IS NULL AND "
+
+func TestCompileRelation(t *testing.T) {
+	runCompileTests(t, &models.Gst{}, []compileTest{
+		{
+			name:    "field of the related rows",
+			filters: map[string]dto.Filter{"GstStatuses.Status": {Type: "equals", From: "Filed"}},
+			where:   gstStatusesExists + "`gst_statuses`.`status` = ?)",
+			vars:    []interface{}{"Filed"},
+		},
+		{
+			name: "fields of the same related row",
+			filters: map[string]dto.Filter{
+				"GstStatuses.Status":  {Type: "notEqual", From: "Filed"},
+				"GstStatuses.Rtntype": {FilterType: "set", Values: []string{"GSTR1", "GSTR3B"}},
+			},
+			where: gstStatusesExists + "`gst_statuses`.`rtntype` IN (?,?) AND `gst_statuses`.`status` <> ?)",
+			vars:  []interface{}{"GSTR1", "GSTR3B", "Filed"},
+		},
+		{
+			name: "with a field of the model",
+			filters: map[string]dto.Filter{
+				"Name":                {Type: "startsWith", From: "a"},
+				"GstStatuses.DueDate": {Type: "equals", From: "2024-01-02"},
+			},
+			where: "LOWER(`gsts`.`name`) LIKE ? ESCAPE '\\' AND " + gstStatusesExists +
+				"(`gst_statuses`.`due_date` >= ? AND `gst_statuses`.`due_date` < ?))",
+			vars: []interface{}{"a%", day(2), day(3)},
+		},
+		{
+			name:    "count of pending returns",
+			filters: map[string]dto.Filter{"GstStatuses.PendingReturns.count": {Type: "greaterThan", From: "2"}},
+			where:   gstStatusesExists + "COALESCE(json_array_length(NULLIF(`gst_statuses`.`pending_returns`, '')), 0) > ?)",
+			vars:    []interface{}{2.0},
+		},
+		{
+			name:    "count of pending returns in range",
+			filters: map[string]dto.Filter{"GstStatuses.pending_returns.count": {Type: "inRange", From: "1", To: "3"}},
+			where: gstStatusesExists + "(COALESCE(json_array_length(NULLIF(`gst_statuses`.`pending_returns`, '')), 0) >= ? AND " +
+				"COALESCE(json_array_length(NULLIF(`gst_statuses`.`pending_returns`, '')), 0) <= ?))",
+			vars: []interface{}{1.0, 3.0},
+		},
+		{
+			name:    "has one relation",
+			filters: map[string]dto.Filter{"Pradr.City": {Type: "equals", From: "Tirupur"}},
+			where: "EXISTS (SELECT 1 FROM `permenant_addresses` WHERE `gsts`.`gstin` = `permenant_addresses`.`gstin` AND " +
+				"`permenant_addresses`.`deleted_by` IS NULL AND `permenant_addresses`.`city` = ?)",
+			vars: []interface{}{"Tirupur"},
+		},
+		{
+			name:    "invalid filter on the related rows",
+			filters: map[string]dto.Filter{"GstStatuses.PendingReturns.count": {Type: "greaterThan", From: "two"}},
+			invalid: true,
+		},
+		{
+			name: "unknown paths are left out",
+			filters: map[string]dto.Filter{
+				"Unknown.Status":                    {Type: "equals", From: "x"},
+				"GstStatuses.Unknown":               {Type: "equals", From: "x"},
+				"GstStatuses.":                      {Type: "equals", From: "x"},
+				".Status":                           {Type: "equals", From: "x"},
+				"GstStatuses.Status.count":          {Type: "equals", From: "1"},
+				"GstStatuses.PendingReturns.length": {Type: "equals", From: "1"},
+				"GstStatuses.Gst.Name":              {Type: "equals", From: "x"},
+				"Nature.count.count":                {Type: "equals", From: "1"},
+				"gst_statuses.status":               {Type: "equals", From: "x"},
+				"GstStatuses.status = '' OR 1=1 --": {Type: "equals", From: "x"},
+			},
+			where: "",
+		},
+	})
+}
+
+func TestCompileRelationMatchesRows(t *testing.T) {
+	db := newTestDb(t)
+	if err := db.AutoMigrate(&models.Gst{}, &models.GstStatus{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Create(&[]models.Gst{{Gstin: "1"}, {Gstin: "2"}, {Gstin: "3"}})
+	db.Create(&[]models.GstStatus{
+		{Gstin: "1", Rtntype: constants.GSTR1, Status: constants.Filed, PendingReturns: sqlite_custom_type.SqliteStrArray{}},
+		{Gstin: "1", Rtntype: constants.GSTR3B, Status: constants.TaxPayable, PendingReturns: sqlite_custom_type.SqliteStrArray{"012024", "022024", "032024"}},
+		{Gstin: "2", Rtntype: constants.GSTR1, Status: constants.InvoiceEntry, PendingReturns: sqlite_custom_type.SqliteStrArray{"012024"}},
+		{Gstin: "2", Rtntype: constants.GSTR3B, Status: constants.Filed},
+		// a deleted return is never matched
+		{Gstin: "3", Rtntype: constants.GSTR1, Status: constants.InvoiceEntry, PendingReturns: sqlite_custom_type.SqliteStrArray{"012024", "022024", "032024"},
+			BaseModel: models.BaseModel{DeletedBy: &sql.NullInt64{Int64: 1, Valid: true}}},
+	})
+
+	s, err := Parse(db, &models.Gst{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]dto.Filter
+		gstins  []string
+	}{
+		{"any related row", map[string]dto.Filter{"GstStatuses.Status": {Type: "equals", From: "Filed"}}, []string{"1", "2"}},
+		{
+			"the same related row",
+			map[string]dto.Filter{
+				"GstStatuses.Status":  {Type: "equals", From: "Filed"},
+				"GstStatuses.Rtntype": {Type: "equals", From: "GSTR3B"},
+			},
+			[]string{"2"},
+		},
+		{"count", map[string]dto.Filter{"GstStatuses.PendingReturns.count": {Type: "greaterThanOrEqual", From: "3"}}, []string{"1"}},
+		{"count of no pending returns", map[string]dto.Filter{"GstStatuses.PendingReturns.count": {Type: "equals", From: "0"}}, []string{"1", "2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exprs, err := s.Compile(test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gstins []string
+			err = db.Model(&models.Gst{}).Where(clause.And(exprs...)).Order("gstin").Pluck("gstin", &gstins).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gstins, test.gstins) {
+				t.Errorf("gstins = %v, want %v", gstins, test.gstins)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package dynamic_filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/gorm/clause"
+)
+
+// Bounds of a filter tree, a tree is sent by the client and compiled by recursion
+const (
+	MaxTreeDepth = 8
+	MaxTreeNodes = 200
+)
+
+// CompileTree returns the condition of a filter tree, nil when none of its filters is on a known field.
+// The filters on unknown fields are left out like the ones of Compile, a group without any is dropped
+func (s *Schema) CompileTree(node *dto.FilterNode) (clause.Expression, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	depth, nodes := measure(node, 1)
+	if depth > MaxTreeDepth {
+		return nil, invalidNode(fmt.Sprintf("the tree is %d levels deep, it can be %d at most", depth, MaxTreeDepth))
+	}
+	if nodes > MaxTreeNodes {
+		return nil, invalidNode(fmt.Sprintf("the tree has %d nodes, it can have %d at most", nodes, MaxTreeNodes))
+	}
+
+	return s.compileTree(node)
+}
+
+func (s *Schema) compileTree(node *dto.FilterNode) (clause.Expression, error) {
+	if node.Field != "" && len(node.Nodes) == 0 {
+		if node.Filter == nil {
+			return nil, invalidNode(fmt.Sprintf("the filter on %s is missing", node.Field))
+		}
+
+		exprs, err := s.Compile(map[string]dto.Filter{node.Field: *node.Filter})
+		if err != nil || len(exprs) == 0 {
+			return nil, err
+		}
+
+		return exprs[0], nil
+	}
+
+	operator := strings.ToLower(node.Operator)
+	if operator != "" && operator != "and" && operator != "or" && operator != "not" {
+		return nil, invalidNode(fmt.Sprintf("%s is not an operator of a group", node.Operator))
+	}
+
+	// the nodes of a group on a relation are filters on the related model
+	nodes := s
+	relation, isRelation := s.relationship(node.Field)
+	if node.Field != "" {
+		if !isRelation {
+			return nil, nil
+		}
+		nodes = s.related(relation)
+	}
+
+	exprs := make([]clause.Expression, 0, len(node.Nodes))
+	for i := range node.Nodes {
+		expr, err := nodes.compileTree(&node.Nodes[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	var expr clause.Expression
+	switch operator {
+	case "or":
+		expr = clause.Or(exprs...)
+	case "not":
+		// clause.Not negates each of the conditions of an AND instead of the AND
+		expr = clause.Expr{SQL: "NOT (?)", Vars: []interface{}{clause.And(exprs...)}}
+	default:
+		expr = clause.And(exprs...)
+	}
+
+	if isRelation {
+		return s.exists(relation, []clause.Expression{expr}), nil
+	}
+
+	return expr, nil
+}
+
+// measure returns the number of levels and of nodes of the tree below the level. It stops once past the
+// bounds, a tree too big is not walked through
+func measure(node *dto.FilterNode, level int) (int, int) {
+	depth, nodes := level, 1
+
+	for i := range node.Nodes {
+		if depth > MaxTreeDepth || nodes > MaxTreeNodes {
+			break
+		}
+
+		d, n := measure(&node.Nodes[i], level+1)
+		depth = max(depth, d)
+		nodes += n
+	}
+
+	return depth, nodes
+}
+
+func invalidNode(reason string) error {
+	return &service_errors.ServiceError{EndUserMessage: service_errors.InvalidFilter, TechnicalMessage: reason}
+}
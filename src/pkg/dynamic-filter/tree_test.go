@@ -0,0 +1,219 @@
+package dynamic_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"gorm.io/gorm/clause"
+)
+
+func leaf(field, filterType, from string) dto.FilterNode {
+	return dto.FilterNode{Field: field, Filter: &dto.Filter{Type: filterType, From: from}}
+}
+
+// chain nests groups of a single filter the levels deep
+func chain(levels int) dto.FilterNode {
+	node := leaf("Name", "equals", "x")
+	for range levels - 1 {
+		node = dto.FilterNode{Operator: "and", Nodes: []dto.FilterNode{node}}
+	}
+	return node
+}
+
+func TestCompileTree(t *testing.T) {
+	db := newTestDb(t)
+
+	s, err := Parse(db, &models.Gst{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wide := dto.FilterNode{Operator: "or"}
+	for range MaxTreeNodes {
+		wide.Nodes = append(wide.Nodes, leaf("Name", "equals", "x"))
+	}
+
+	tests := []struct {
+		name    string
+		node    *dto.FilterNode
+		where   string
+		vars    []interface{}
+		invalid bool
+	}{
+		{name: "no tree", node: nil},
+		{
+			name:  "filter",
+			node:  &dto.FilterNode{Field: "Name", Filter: &dto.Filter{Type: "equals", From: "a"}},
+			where: "`gsts`.`name` = ?",
+			vars:  []interface{}{"a"},
+		},
+		{
+			name: "not over an or group",
+			node: &dto.FilterNode{Operator: "NOT", Nodes: []dto.FilterNode{
+				{Operator: "OR", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+			}},
+			where: "NOT ((`gsts`.`name` = ? OR `gsts`.`status` = ?))",
+			vars:  []interface{}{"a", "Active"},
+		},
+		{
+			name:  "not over many nodes negates their and",
+			node:  &dto.FilterNode{Operator: "not", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+			where: "NOT ((`gsts`.`name` = ? AND `gsts`.`status` = ?))",
+			vars:  []interface{}{"a", "Active"},
+		},
+		{
+			name: "or of and groups",
+			node: &dto.FilterNode{Operator: "or", Nodes: []dto.FilterNode{
+				{Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+				{Operator: "and", Nodes: []dto.FilterNode{leaf("Name", "equals", "b"), leaf("Locked", "equals", "true")}},
+			}},
+			where: "((`gsts`.`name` = ? AND `gsts`.`status` = ?) OR (`gsts`.`name` = ? AND `gsts`.`locked` = ?))",
+			vars:  []interface{}{"a", "Active", "b", true},
+		},
+		{
+			name: "and of an or group",
+			node: &dto.FilterNode{Nodes: []dto.FilterNode{
+				leaf("Locked", "equals", "false"),
+				{Operator: "or", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Name", "equals", "b")}},
+			}},
+			where: "`gsts`.`locked` = ? AND (`gsts`.`name` = ? OR `gsts`.`name` = ?)",
+			vars:  []interface{}{false, "a", "b"},
+		},
+		{
+			name: "group on a relation filters the same row",
+			node: &dto.FilterNode{Field: "GstStatuses", Operator: "not", Nodes: []dto.FilterNode{
+				leaf("Rtntype", "equals", "GSTR1"), leaf("Status", "equals", "Filed"),
+			}},
+			where: gstStatusesExists + "NOT ((`gst_statuses`.`rtntype` = ? AND `gst_statuses`.`status` = ?)))",
+			vars:  []interface{}{"GSTR1", "Filed"},
+		},
+		{name: "empty group", node: &dto.FilterNode{Operator: "or"}},
+		{name: "empty not group", node: &dto.FilterNode{Operator: "not", Nodes: []dto.FilterNode{}}},
+		{
+			name: "empty groups are dropped",
+			node: &dto.FilterNode{Operator: "or", Nodes: []dto.FilterNode{
+				{Operator: "and"},
+				{Operator: "not", Nodes: []dto.FilterNode{{Operator: "or"}}},
+				leaf("Name", "equals", "a"),
+			}},
+			where: "`gsts`.`name` = ?",
+			vars:  []interface{}{"a"},
+		},
+		{
+			name: "groups of unknown fields are dropped",
+			node: &dto.FilterNode{Operator: "not", Nodes: []dto.FilterNode{
+				leaf("Unknown", "equals", "a"),
+				{Field: "Unknown", Nodes: []dto.FilterNode{leaf("Status", "equals", "Filed")}},
+			}},
+		},
+		{name: "unknown operator", node: &dto.FilterNode{Operator: "xor", Nodes: []dto.FilterNode{leaf("Name", "equals", "a")}}, invalid: true},
+		{name: "filter missing", node: &dto.FilterNode{Field: "Name"}, invalid: true},
+		{
+			name:    "invalid filter within a group",
+			node:    &dto.FilterNode{Operator: "or", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Id", "equals", "a")}},
+			invalid: true,
+		},
+		{name: "deepest tree", node: ptr(chain(MaxTreeDepth)), where: "`gsts`.`name` = ?", vars: []interface{}{"x"}},
+		{name: "tree too deep", node: ptr(chain(MaxTreeDepth + 1)), invalid: true},
+		{name: "tree far too deep", node: ptr(chain(10000)), invalid: true},
+		{name: "tree too big", node: &wide, invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := s.CompileTree(test.node)
+
+			if test.invalid {
+				if !isInvalidFilter(err) {
+					t.Fatalf("error = %v, want %s", err, "InvalidFilter")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.where == "" {
+				if expr != nil {
+					t.Errorf("condition = %#v, want none", expr)
+				}
+				return
+			}
+
+			where, vars := whereOf(db, &models.Gst{}, expr)
+			if where != test.where {
+				t.Errorf("where = %s\nwant %s", where, test.where)
+			}
+			if !reflect.DeepEqual(vars, test.vars) {
+				t.Errorf("vars = %#v, want %#v", vars, test.vars)
+			}
+		})
+	}
+}
+
+func TestCompileTreeMatchesRows(t *testing.T) {
+	db := newTestDb(t)
+	if err := db.AutoMigrate(&models.Gst{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Create(&[]models.Gst{
+		{Gstin: "1", Name: "a", Status: "Active"},
+		{Gstin: "2", Name: "a", Status: "Cancelled"},
+		{Gstin: "3", Name: "b", Status: "Active"},
+		{Gstin: "4", Name: "b", Status: "Cancelled"},
+	})
+
+	s, err := Parse(db, &models.Gst{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		node   dto.FilterNode
+		gstins []string
+	}{
+		{
+			"not over an or group",
+			dto.FilterNode{Operator: "not", Nodes: []dto.FilterNode{
+				{Operator: "or", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+			}},
+			[]string{"4"},
+		},
+		{
+			"not over an and group",
+			dto.FilterNode{Operator: "not", Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+			[]string{"2", "3", "4"},
+		},
+		{
+			"or of and groups",
+			dto.FilterNode{Operator: "or", Nodes: []dto.FilterNode{
+				{Nodes: []dto.FilterNode{leaf("Name", "equals", "a"), leaf("Status", "equals", "Active")}},
+				{Nodes: []dto.FilterNode{leaf("Name", "equals", "b"), leaf("Status", "equals", "Cancelled")}},
+			}},
+			[]string{"1", "4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := s.CompileTree(&test.node)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gstins []string
+			err = db.Model(&models.Gst{}).Where(clause.And(expr)).Order("gstin").Pluck("gstin", &gstins).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gstins, test.gstins) {
+				t.Errorf("gstins = %v, want %v", gstins, test.gstins)
+			}
+		})
+	}
+}
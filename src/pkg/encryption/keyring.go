@@ -0,0 +1,283 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaganathanb/dapps-api/config"
+)
+
+const (
+	keySize = 32
+	prefix  = "enc:v1:"
+)
+
+var (
+	ErrNoMasterKey   = errors.New("encryption master key is not configured, set encryption.masterKey or encryption.keyFile")
+	ErrUnknownKey    = errors.New("value is encrypted with an unknown master key")
+	ErrMalformed     = errors.New("encrypted value is malformed")
+	ErrInvalidKeyLen = fmt.Errorf("master key must be %d bytes encoded in base64", keySize)
+)
+
+// Keyring holds the master key new values are encrypted with and the previous
+// master keys, which are only used to decrypt values not yet rotated.
+//
+// Values are envelope encrypted: each value gets its own random data key, which
+// encrypts the value and is stored next to it wrapped by the master key.
+// The stored form is enc:v1:<key id>:<wrapped data key>:<cipher text>
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring reads the master key from encryption.masterKey, or from encryption.keyFile
+// when no key is given. A key file that does not exist yet is created with a new key
+func NewKeyring(cfg *config.Config) (*Keyring, error) {
+	key, err := readMasterKey(cfg.Encryption.MasterKey, cfg.Encryption.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{keys: map[string][]byte{}}
+	k.active = k.add(key)
+
+	for _, prev := range cfg.Encryption.PreviousKeys {
+		key, err := decodeKey(prev)
+		if err != nil {
+			return nil, err
+		}
+		k.add(key)
+	}
+
+	for _, file := range cfg.Encryption.PreviousKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := decodeKey(string(data))
+		if err != nil {
+			return nil, err
+		}
+		k.add(key)
+	}
+
+	return k, nil
+}
+
+// GenerateKey returns a new base64 encoded master key
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ActiveKeyId is the id of the master key new values are encrypted with
+func (k *Keyring) ActiveKeyId() string {
+	return k.active
+}
+
+// IsEncrypted tells whether the value is in the encrypted form
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt encrypts the value with a new data key wrapped by the active master key.
+// Empty values are kept empty
+func (k *Keyring) Encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.keys[k.active], dek)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := seal(dek, []byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawStdEncoding
+	return prefix + k.active + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(data), nil
+}
+
+// Decrypt returns the plain value. Values which are not encrypted yet are returned as is
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+
+	key, ok := k.keys[parts[0]]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	enc := base64.RawStdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	data, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	dek, err := open(key, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := open(dek, data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// Rewrap encrypts the value with the active master key when it is in plain text or
+// encrypted with a previous master key. It reports whether the value was changed
+func (k *Keyring) Rewrap(value string) (string, bool, error) {
+	if value == "" || strings.HasPrefix(value, prefix+k.active+":") {
+		return value, false, nil
+	}
+
+	plain, err := k.Decrypt(value)
+	if err != nil {
+		return value, false, err
+	}
+
+	value, err = k.Encrypt(plain)
+	if err != nil {
+		return value, false, err
+	}
+
+	return value, true, nil
+}
+
+// Hash returns a keyed hash of the value to look an encrypted value up by, the encrypted form differs every
+// time. The hash is keyed by the active master key, the stored hashes have to be computed again after a rotation
+func (k *Keyring) Hash(value string) string {
+	lookup := hmac.New(sha256.New, k.keys[k.active])
+	lookup.Write([]byte("lookup"))
+
+	mac := hmac.New(sha256.New, lookup.Sum(nil))
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *Keyring) add(key []byte) string {
+	sum := sha256.Sum256(key)
+	id := hex.EncodeToString(sum[:4])
+	k.keys[id] = key
+
+	return id
+}
+
+func readMasterKey(masterKey, keyFile string) ([]byte, error) {
+	if masterKey != "" {
+		return decodeKey(masterKey)
+	}
+
+	if keyFile == "" {
+		return nil, ErrNoMasterKey
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.MkdirAll(filepath.Dir(keyFile), 0700)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.WriteFile(keyFile, []byte(key), 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		return decodeKey(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeKey(string(data))
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidKeyLen
+	}
+
+	return key, nil
+}
+
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,99 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used on model fields as gorm:"serializer:encrypted"
+const SerializerName = "encrypted"
+
+// Mask replaces the secrets in API responses
+const Mask = "********"
+
+var (
+	keyring   *Keyring
+	keyringMu sync.RWMutex
+)
+
+var errNoKeyring = errors.New("encryption keyring is not initialized")
+
+// Register sets the keyring used by the encrypted serializer and registers it with gorm
+func Register(k *Keyring) {
+	keyringMu.Lock()
+	keyring = k
+	keyringMu.Unlock()
+
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// GetKeyring returns the registered keyring
+func GetKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	return keyring
+}
+
+// MaskValue hides a secret, keeping empty values empty so a missing secret is still visible
+func MaskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return Mask
+}
+
+// IsMasked tells whether the value is a masked secret sent back by a client
+func IsMasked(value string) bool {
+	return value == Mask
+}
+
+// Serializer transparently encrypts string fields when written and decrypts them when read
+type Serializer struct{}
+
+// Scan implements serializer interface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("failed to decrypt value: %#v", dbValue)
+	}
+
+	k := GetKeyring()
+	if k == nil {
+		return errNoKeyring
+	}
+
+	plain, err := k.Decrypt(value)
+	if err != nil {
+		return err
+	}
+
+	return field.Set(ctx, dst, plain)
+}
+
+// Value implements serializer interface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to encrypt value: %#v", fieldValue)
+	}
+
+	k := GetKeyring()
+	if k == nil {
+		return nil, errNoKeyring
+	}
+
+	return k.Encrypt(value)
+}
@@ -0,0 +1,306 @@
+package fake_gst_portal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+)
+
+// Scenarios decide what the portal shows after a successful login of an account.
+const (
+	Dashboard            = "dashboard"
+	AadhaarReminder      = "aadhaarReminder"
+	BankAccountNotLinked = "bankAccountNotLinked"
+	ChangePassword       = "changePassword"
+	UnknownDialog        = "unknownDialog"
+)
+
+const (
+	sessionCookie        = "AuthToken"
+	invalidCredentialMsg = "Invalid Username or Password. Please try again."
+	invalidCaptchaMsg    = "Enter valid Letters shown."
+)
+
+// FakeGstPortal mimics the pages and XHR endpoints of the GST portal that the rod scrapper drives,
+// so the login and return status flows can be exercised without touching the real portal.
+type FakeGstPortal struct {
+	logger   logging.Logger
+	cfg      *config.Config
+	captcha  string
+	accounts map[string]config.FakeGstAccount
+	sessions map[string]string
+	mutex    sync.Mutex
+}
+
+func NewFakeGstPortal(cfg *config.Config) *FakeGstPortal {
+	portal := &FakeGstPortal{
+		logger:   logging.NewLogger(cfg),
+		cfg:      cfg,
+		captcha:  cfg.FakeGstPortal.Captcha,
+		accounts: map[string]config.FakeGstAccount{},
+		sessions: map[string]string{},
+	}
+
+	for _, account := range cfg.FakeGstPortal.Accounts {
+		portal.AddAccount(account)
+	}
+
+	return portal
+}
+
+// AddAccount registers (or replaces) a portal account.
+func (p *FakeGstPortal) AddAccount(account config.FakeGstAccount) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if account.Scenario == "" {
+		account.Scenario = Dashboard
+	}
+
+	p.accounts[account.Username] = account
+}
+
+// Captcha returns the captcha code the portal accepts.
+func (p *FakeGstPortal) Captcha() string {
+	return p.captcha
+}
+
+func (p *FakeGstPortal) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/services/login", p.login)
+	mux.HandleFunc("/services/captcha", p.imageCaptcha)
+	mux.HandleFunc("/services/audiocaptcha", p.audioCaptcha)
+	mux.HandleFunc("/services/auth/fowelcome", p.dashboard)
+	mux.HandleFunc("/services/auth/changepassword", p.changePassword)
+	mux.HandleFunc("/services/api/auth/profile/detail", p.profile)
+	mux.HandleFunc("/returns/auth/api/returnstatus", p.returnStatus)
+
+	return mux
+}
+
+// Start blocks serving the portal on `fakeGstPortal.port`.
+func (p *FakeGstPortal) Start() error {
+	p.logger.Infof("Fake GST portal listening on :%s", p.cfg.FakeGstPortal.Port)
+
+	return http.ListenAndServe(fmt.Sprintf(":%s", p.cfg.FakeGstPortal.Port), p.Handler())
+}
+
+func (p *FakeGstPortal) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render(w, loginTemplate, loginPage{})
+		return
+	}
+
+	username := r.FormValue("username")
+
+	if r.FormValue("captcha") != p.captcha {
+		render(w, loginTemplate, loginPage{Username: username, CaptchaError: invalidCaptchaMsg})
+		return
+	}
+
+	p.mutex.Lock()
+	account, found := p.accounts[username]
+	p.mutex.Unlock()
+
+	if !found || account.Password != r.FormValue("password") {
+		render(w, loginTemplate, loginPage{Username: username, Error: invalidCredentialMsg})
+		return
+	}
+
+	token := newToken()
+
+	p.mutex.Lock()
+	p.sessions[token] = username
+	p.mutex.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: token, Path: "/", HttpOnly: true})
+
+	if account.Scenario == ChangePassword {
+		http.Redirect(w, r, "/services/auth/changepassword", http.StatusSeeOther)
+	} else {
+		http.Redirect(w, r, "/services/auth/fowelcome", http.StatusSeeOther)
+	}
+}
+
+func (p *FakeGstPortal) dashboard(w http.ResponseWriter, r *http.Request) {
+	account, ok := p.account(r)
+	if !ok {
+		http.Redirect(w, r, "/services/login", http.StatusSeeOther)
+		return
+	}
+
+	page := dashboardPage{}
+	switch account.Scenario {
+	case AadhaarReminder:
+		page.Modal = "aadhaar"
+	case BankAccountNotLinked:
+		page.Modal = "amendment"
+	case UnknownDialog:
+		page.Modal = "unknown"
+	}
+
+	render(w, dashboardTemplate, page)
+}
+
+func (p *FakeGstPortal) changePassword(w http.ResponseWriter, r *http.Request) {
+	if _, ok := p.account(r); !ok {
+		http.Redirect(w, r, "/services/login", http.StatusSeeOther)
+		return
+	}
+
+	render(w, changePasswordTemplate, nil)
+}
+
+func (p *FakeGstPortal) profile(w http.ResponseWriter, r *http.Request) {
+	p.fixture(w, r, "gsts.json")
+}
+
+func (p *FakeGstPortal) returnStatus(w http.ResponseWriter, r *http.Request) {
+	p.fixture(w, r, "returns.json")
+}
+
+// fixture answers with the recorded response of the logged in account's GSTIN.
+func (p *FakeGstPortal) fixture(w http.ResponseWriter, r *http.Request, fileName string) {
+	account, ok := p.account(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.cfg.Server.Gst.FixturePath, fileName))
+	if err != nil {
+		p.logger.Errorf("Could not read fixture %s. %s", fileName, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var recorded map[string]json.RawMessage
+	err = json.Unmarshal(data, &recorded)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body, found := recorded[account.Gstin]
+	if !found {
+		http.Error(w, fmt.Sprintf("No data for GSTIN %s", account.Gstin), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (p *FakeGstPortal) audioCaptcha(w http.ResponseWriter, r *http.Request) {
+	// Not a playable mp3; use the stub captcha solver against this portal
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Write(append([]byte("ID3"), []byte(p.captcha)...))
+}
+
+func (p *FakeGstPortal) imageCaptcha(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, drawCaptcha(p.captcha))
+}
+
+func (p *FakeGstPortal) account(r *http.Request) (config.FakeGstAccount, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return config.FakeGstAccount{}, false
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	username, found := p.sessions[cookie.Value]
+	if !found {
+		return config.FakeGstAccount{}, false
+	}
+
+	account, found := p.accounts[username]
+
+	return account, found
+}
+
+func render(w http.ResponseWriter, page *template.Template, data any) {
+	var buf bytes.Buffer
+	err := page.Execute(&buf, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// segments lists the lit segments (a-g) of a seven segment display for each digit
+var segments = map[rune]string{
+	'0': "abcdef", '1': "bc", '2': "abdeg", '3': "abcdg", '4': "bcfg",
+	'5': "acdfg", '6': "acdefg", '7': "abc", '8': "abcdefg", '9': "abcdfg",
+}
+
+// drawCaptcha renders the numeric captcha as seven segment digits, legible enough for an OCR service
+func drawCaptcha(code string) image.Image {
+	const width, height, digitWidth = 150, 40, 20
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	fill := func(x0, y0, x1, y1 int) {
+		for x := x0; x < x1; x++ {
+			for y := y0; y < y1; y++ {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	for i, r := range code {
+		left := 8 + i*(digitWidth+4)
+		right := left + digitWidth - 8
+		for _, seg := range segments[r] {
+			switch seg {
+			case 'a':
+				fill(left, 5, right, 8)
+			case 'b':
+				fill(right-3, 5, right, 20)
+			case 'c':
+				fill(right-3, 20, right, 35)
+			case 'd':
+				fill(left, 32, right, 35)
+			case 'e':
+				fill(left, 20, left+3, 35)
+			case 'f':
+				fill(left, 5, left+3, 20)
+			case 'g':
+				fill(left, 19, right, 22)
+			}
+		}
+	}
+
+	return img
+}
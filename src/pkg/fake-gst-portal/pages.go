@@ -0,0 +1,99 @@
+package fake_gst_portal
+
+import "html/template"
+
+type loginPage struct {
+	Username     string
+	Error        string
+	CaptchaError string
+}
+
+type dashboardPage struct {
+	Modal string
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Goods and Services Tax - Login</title></head>
+<body>
+<form method="post" action="/services/login">
+  {{if .Error}}<div class="alert alert-danger">{{.Error}}</div>{{end}}
+  <input id="username" name="username" type="text" value="{{.Username}}">
+  <input id="user_pass" name="password" type="password">
+  <img id="imgCaptcha" src="/services/captcha" width="150" height="40" alt="captcha">
+  <a href="#" onclick="playAudio(event)"><i class="fa fa-volume-up">Play</i></a>
+  <input id="captcha" name="captcha" type="text">
+  {{if .CaptchaError}}<span class="err">{{.CaptchaError}}</span>{{end}}
+  <button type="submit">LOGIN</button>
+</form>
+<script>
+function playAudio(e) {
+  e.preventDefault();
+  fetch('/services/audiocaptcha?rnd=' + Date.now()).then(function (r) { return r.blob(); });
+}
+</script>
+</body>
+</html>`))
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Goods and Services Tax - Dashboard</title></head>
+<body{{if .Modal}} class="modal-open"{{end}}>
+<ul class="nav">
+  <li class="menuList">
+    <a class="dropdown-toggle" href="#">Services</a>
+    <ul class="smenu">
+      <li class="has-sub">
+        <a href="#">Returns</a>
+        <ul class="isubmenu ret post">
+          <li><a href="#" onclick="track(event, '/returns/auth/api/returnstatus')">Track Return Status</a></li>
+        </ul>
+      </li>
+    </ul>
+  </li>
+</ul>
+<div class="dp-widgt">
+  <a class="tp-pfl-lnk" href="#" onclick="track(event, '/services/api/auth/profile/detail')">View Profile <i class="fa fa-angle-double-right">&raquo;</i></a>
+</div>
+{{if eq .Modal "aadhaar"}}
+<div id="adhrtableV" class="modal">
+  <div class="modal-body">Authenticate your Aadhaar to continue.</div>
+  <div class="modal-footer"><a href="#" onclick="dismiss(event, 'adhrtableV')">Remind me later</a></div>
+</div>
+{{else if eq .Modal "amendment"}}
+<div id="confirmDlg" class="modal">
+  <div class="modal-body">Bank account details are not available for your registration.</div>
+  <div class="modal-footer"><a href="#">FILE AMENDMENT</a></div>
+</div>
+{{else if eq .Modal "unknown"}}
+<div id="noticeDlg" class="modal">
+  <div class="modal-body">A new notice has been issued for your registration.</div>
+  <div class="modal-footer"><a href="#">VIEW NOTICE</a></div>
+</div>
+{{end}}
+<script>
+function track(e, url) {
+  e.preventDefault();
+  fetch(url, { credentials: 'same-origin' }).then(function (r) { return r.json(); });
+}
+function dismiss(e, id) {
+  e.preventDefault();
+  document.getElementById(id).remove();
+  document.body.classList.remove('modal-open');
+}
+</script>
+</body>
+</html>`))
+
+var changePasswordTemplate = template.Must(template.New("changePassword").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Goods and Services Tax - Change Password</title></head>
+<body>
+<form method="post" action="/services/auth/changepassword">
+  <div class="alert alert-info">Your password has expired. Please change your password to continue.</div>
+  <input id="newpassword" name="newpassword" type="password">
+  <input id="confirmpassword" name="confirmpassword" type="password">
+  <button id="submitpwd" type="submit">SUBMIT</button>
+</form>
+</body>
+</html>`))
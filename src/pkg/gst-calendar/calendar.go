@@ -0,0 +1,216 @@
+package gst_calendar
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/pkg/utils"
+)
+
+// A return period is identified by the first day of its last month, the way the
+// portal reports ret_prd. E.g. the quarter Apr-Jun 2024 is 01/06/2024 and the
+// financial year 2023-24 is 01/03/2024
+
+// Extension moves the due date of a return period, as notified by the government
+type Extension struct {
+	ReturnType constants.GstReturnType
+	Period     time.Time
+	// States the extension applies to, by GST state code. Empty means all the states
+	StateCodes   []string
+	DueDate      time.Time
+	Notification string
+}
+
+// DueDate is the date a return period has to be filed by
+type DueDate struct {
+	ReturnType constants.GstReturnType
+	Period     time.Time
+	DueDate    time.Time
+	// Extension is the notification the due date was extended by, if any
+	Extension *Extension
+}
+
+// Calendar knows the statutory due dates of the GST returns and the extensions notified on them
+type Calendar struct {
+	extensions []Extension
+}
+
+func NewCalendar(extensions []Extension) *Calendar {
+	return &Calendar{extensions: extensions}
+}
+
+// PeriodMonths is the number of months a return of the type is filed for
+func PeriodMonths(returnType constants.GstReturnType, frequency constants.FilingFrequency) int {
+	switch returnType {
+	case constants.GSTR9, constants.GSTR4:
+		return 12
+	case constants.CMP08:
+		return 3
+	case constants.GSTR1, constants.GSTR3B:
+		if frequency == constants.QuarterlyFiling {
+			return 3
+		}
+	}
+
+	return 1
+}
+
+// PeriodOf returns the return period the date falls in
+func PeriodOf(returnType constants.GstReturnType, frequency constants.FilingFrequency, date time.Time) time.Time {
+	month := utils.StartOfMonth(date)
+
+	switch PeriodMonths(returnType, frequency) {
+	case 12:
+		// financial year ends in March
+		year := month.Year()
+		if month.Month() > time.March {
+			year++
+		}
+		return time.Date(year, time.March, 1, 0, 0, 0, 0, date.Location())
+	case 3:
+		return month.AddDate(0, 2-(int(month.Month())-1)%3, 0)
+	default:
+		return month
+	}
+}
+
+// NextPeriod returns the return period following the period
+func NextPeriod(returnType constants.GstReturnType, frequency constants.FilingFrequency, period time.Time) time.Time {
+	return period.AddDate(0, PeriodMonths(returnType, frequency), 0)
+}
+
+// DueDate returns the date the return period of the GSTIN has to be filed by,
+// taking the extensions into account
+func (c *Calendar) DueDate(returnType constants.GstReturnType, frequency constants.FilingFrequency, gstin string, period time.Time) DueDate {
+	due := DueDate{ReturnType: returnType, Period: period, DueDate: statutoryDueDate(returnType, frequency, gstin, period)}
+
+	for i, ext := range c.extensions {
+		if ext.ReturnType == returnType && ext.Period.Equal(period) && appliesTo(ext, gstin) && ext.DueDate.After(due.DueDate) {
+			due.DueDate = ext.DueDate
+			due.Extension = &c.extensions[i]
+		}
+	}
+
+	return due
+}
+
+// PendingPeriods returns the periods after the last filed period which have ended by now
+func (c *Calendar) PendingPeriods(returnType constants.GstReturnType, frequency constants.FilingFrequency, lastFiled time.Time, now time.Time) []time.Time {
+	pendings := []time.Time{}
+
+	for period := NextPeriod(returnType, frequency, lastFiled); !period.AddDate(0, 1, 0).After(now); period = NextPeriod(returnType, frequency, period) {
+		pendings = append(pendings, period)
+	}
+
+	return pendings
+}
+
+// IsOverdue tells whether the due date of the return period has passed. The due date itself is not overdue
+func (c *Calendar) IsOverdue(returnType constants.GstReturnType, frequency constants.FilingFrequency, gstin string, period time.Time, now time.Time) bool {
+	due := c.DueDate(returnType, frequency, gstin, period)
+
+	return !now.Before(due.DueDate.AddDate(0, 0, 1))
+}
+
+// IsQuarterEnd tells whether the month closes a quarter of the financial year
+func IsQuarterEnd(period time.Time) bool {
+	return period.Month()%3 == 0
+}
+
+// QrmpObligations returns the IFF and PMT-06 due for a month of a QRMP filer. The last
+// month of a quarter has none, it is covered by the quarterly GSTR-1 and GSTR-3B
+func (c *Calendar) QrmpObligations(gstin string, month time.Time) []DueDate {
+	if IsQuarterEnd(month) {
+		return []DueDate{}
+	}
+
+	return []DueDate{
+		c.DueDate(constants.IFF, constants.QuarterlyFiling, gstin, month),
+		c.DueDate(constants.PMT06, constants.QuarterlyFiling, gstin, month),
+	}
+}
+
+// DueDates returns the due dates of all the returns a GSTIN of the frequency files for the financial year
+// ending in March of fyEndYear
+func (c *Calendar) DueDates(gstin string, frequency constants.FilingFrequency, composition bool, fyEndYear int) []DueDate {
+	returnTypes := []constants.GstReturnType{constants.GSTR1, constants.GSTR3B, constants.GSTR9}
+	if composition {
+		returnTypes = []constants.GstReturnType{constants.CMP08, constants.GSTR4}
+	}
+
+	start := time.Date(fyEndYear-1, time.April, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(fyEndYear, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	dues := []DueDate{}
+	for _, returnType := range returnTypes {
+		for period := PeriodOf(returnType, frequency, start); !period.After(end); period = NextPeriod(returnType, frequency, period) {
+			dues = append(dues, c.DueDate(returnType, frequency, gstin, period))
+		}
+	}
+
+	if frequency == constants.QuarterlyFiling && !composition {
+		for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+			dues = append(dues, c.QrmpObligations(gstin, month)...)
+		}
+	}
+
+	slices.SortStableFunc(dues, func(a, b DueDate) int { return a.DueDate.Compare(b.DueDate) })
+
+	return dues
+}
+
+func statutoryDueDate(returnType constants.GstReturnType, frequency constants.FilingFrequency, gstin string, period time.Time) time.Time {
+	next := period.AddDate(0, 1, 0)
+	quarterly := frequency == constants.QuarterlyFiling
+
+	day := 20
+	switch returnType {
+	case constants.GSTR1:
+		day = 11
+		if quarterly {
+			day = 13
+		}
+	case constants.GSTR3B:
+		if quarterly {
+			day = 22
+			if slices.Contains(laterQuarterlyStates, stateCode(gstin)) {
+				day = 24
+			}
+		}
+	case constants.IFF:
+		day = 13
+	case constants.PMT06:
+		day = 25
+	case constants.CMP08:
+		day = 18
+	case constants.GSTR9:
+		return time.Date(period.Year(), time.December, 31, 0, 0, 0, 0, period.Location())
+	case constants.GSTR4:
+		// moved from 30th April to 30th June from the financial year 2024-25
+		if period.Year() >= 2025 {
+			return time.Date(period.Year(), time.June, 30, 0, 0, 0, 0, period.Location())
+		}
+		return time.Date(period.Year(), time.April, 30, 0, 0, 0, 0, period.Location())
+	}
+
+	return next.AddDate(0, 0, day-1)
+}
+
+// laterQuarterlyStates file quarterly GSTR-3B by the 24th, the rest of the states by the 22nd
+var laterQuarterlyStates = []string{
+	"01", "02", "03", "04", "05", "06", "07", "08", "09", "10", "11",
+	"12", "13", "14", "15", "16", "17", "18", "19", "20", "21", "38",
+}
+
+func stateCode(gstin string) string {
+	if len(gstin) < 2 {
+		return ""
+	}
+
+	return gstin[:2]
+}
+
+func appliesTo(ext Extension, gstin string) bool {
+	return len(ext.StateCodes) == 0 || slices.Contains(ext.StateCodes, stateCode(gstin))
+}
@@ -0,0 +1,142 @@
+package gst_liability
+
+import (
+	"math"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// InterestRate is the yearly interest on tax paid after the due date
+const InterestRate = 0.18
+
+// Return is a return filed late, or not yet filed, to estimate the liability of
+type Return struct {
+	ReturnType constants.GstReturnType
+	Period     time.Time
+	DueDate    time.Time
+	// Nil returns have a lower late fee
+	Nil bool
+	// TaxAmount paid in cash with the return, interest is charged on it
+	TaxAmount float64
+}
+
+// Liability is the estimated late fee and interest of a return till a date
+type Liability struct {
+	Return
+	DaysLate int
+	LateFee  float64
+	Interest float64
+	// Capped tells the late fee reached its statutory maximum
+	Capped bool
+	// Uncapped tells the maximum of the late fee is not known without the turnover, the fee is an estimate
+	Uncapped bool
+}
+
+func (l Liability) Total() float64 {
+	return l.LateFee + l.Interest
+}
+
+// feeRule is the late fee per day (CGST and SGST together) of a return type and its maximum
+type feeRule struct {
+	perDay    float64
+	nilPerDay float64
+	cap       func(turnover float64, nilReturn bool) float64
+}
+
+// UnknownTurnover estimates the caps for the highest turnover slab, when the turnover is not known
+const UnknownTurnover = -1
+
+var feeRules = map[constants.GstReturnType]feeRule{
+	constants.GSTR1:  {perDay: 50, nilPerDay: 20, cap: monthlyReturnCap},
+	constants.GSTR3B: {perDay: 50, nilPerDay: 20, cap: monthlyReturnCap},
+	constants.GSTR4: {perDay: 50, nilPerDay: 20, cap: func(turnover float64, nilReturn bool) float64 {
+		if nilReturn {
+			return 500
+		}
+		return 2000
+	}},
+	constants.GSTR9: {perDay: 200, nilPerDay: 200, cap: annualReturnCap},
+}
+
+// monthlyReturnCap is the maximum late fee of GSTR-1 and GSTR-3B by the turnover of the previous year
+func monthlyReturnCap(turnover float64, nilReturn bool) float64 {
+	switch {
+	case nilReturn:
+		return 500
+	case turnover < 0:
+		return 10000
+	case turnover <= 1.5e7:
+		return 2000
+	case turnover <= 5e7:
+		return 5000
+	default:
+		return 10000
+	}
+}
+
+// annualReturnCap is the maximum late fee of GSTR-9, a share of the turnover in the state
+func annualReturnCap(turnover float64, nilReturn bool) float64 {
+	switch {
+	case turnover < 0:
+		return math.Inf(1)
+	case turnover <= 2e8:
+		return turnover * 0.0002 * 2
+	default:
+		return turnover * 0.0025 * 2
+	}
+}
+
+// annualReturnPerDay is the late fee per day of GSTR-9 by the turnover
+func annualReturnPerDay(turnover float64) float64 {
+	switch {
+	case turnover < 0:
+		return 200
+	case turnover <= 5e7:
+		return 50
+	case turnover <= 2e8:
+		return 100
+	default:
+		return 200
+	}
+}
+
+// Calculate estimates the late fee and interest of the return till the date. The turnover of
+// the GSTIN decides the caps, pass UnknownTurnover to estimate them for the highest slab
+func Calculate(ret Return, turnover float64, asOf time.Time) Liability {
+	liability := Liability{Return: ret}
+
+	days := int(asOf.Sub(ret.DueDate).Hours() / 24)
+	if days <= 0 {
+		return liability
+	}
+	liability.DaysLate = days
+
+	rule, ok := feeRules[ret.ReturnType]
+	if ok {
+		perDay := rule.perDay
+		if ret.Nil {
+			perDay = rule.nilPerDay
+		}
+		if ret.ReturnType == constants.GSTR9 {
+			perDay = annualReturnPerDay(turnover)
+		}
+
+		fee := perDay * float64(days)
+		limit := rule.cap(turnover, ret.Nil)
+		if fee >= limit {
+			fee = limit
+			liability.Capped = true
+		}
+		liability.Uncapped = math.IsInf(limit, 1)
+		liability.LateFee = fee
+	}
+
+	// tax is paid with GSTR-3B, CMP-08 and PMT-06
+	switch ret.ReturnType {
+	case constants.GSTR3B, constants.CMP08, constants.PMT06:
+		liability.Interest = math.Round(ret.TaxAmount*InterestRate*float64(days)/365*100) / 100
+	}
+
+	return liability
+}
@@ -0,0 +1,95 @@
+package gst_liability
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+func TestCalculateLateFee(t *testing.T) {
+	due := time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		returnType constants.GstReturnType
+		nilReturn  bool
+		turnover   float64
+		days       int
+		fee        float64
+		capped     bool
+	}{
+		{"GSTR-3B nil return", constants.GSTR3B, true, 1e7, 10, 200, false},
+		{"GSTR-3B nil return capped", constants.GSTR3B, true, 1e7, 30, 500, true},
+		{"GSTR-3B up to 1.5 cr", constants.GSTR3B, false, 1.5e7, 10, 500, false},
+		{"GSTR-3B up to 1.5 cr capped", constants.GSTR3B, false, 1.5e7, 60, 2000, true},
+		{"GSTR-3B up to 5 cr capped", constants.GSTR3B, false, 5e7, 150, 5000, true},
+		{"GSTR-3B above 5 cr capped", constants.GSTR3B, false, 6e7, 250, 10000, true},
+		{"GSTR-1 unknown turnover capped", constants.GSTR1, false, UnknownTurnover, 250, 10000, true},
+		{"GSTR-4 nil return capped", constants.GSTR4, true, 1e7, 30, 500, true},
+		{"GSTR-4 capped", constants.GSTR4, false, 1e7, 60, 2000, true},
+		{"GSTR-9 up to 5 cr", constants.GSTR9, false, 4e7, 10, 500, false},
+		{"GSTR-9 up to 5 cr capped", constants.GSTR9, false, 4e7, 400, 16000, true},
+		{"GSTR-9 up to 20 cr", constants.GSTR9, false, 2e8, 10, 1000, false},
+		{"GSTR-9 up to 20 cr capped", constants.GSTR9, false, 2e8, 1000, 80000, true},
+		{"GSTR-9 above 20 cr", constants.GSTR9, false, 3e8, 10, 2000, false},
+		{"GSTR-9 above 20 cr capped", constants.GSTR9, false, 3e8, 8000, 1.5e6, true},
+		{"GSTR-9 unknown turnover", constants.GSTR9, false, UnknownTurnover, 1000, 200000, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ret := Return{ReturnType: test.returnType, DueDate: due, Nil: test.nilReturn}
+			liability := Calculate(ret, test.turnover, due.AddDate(0, 0, test.days))
+
+			if math.Abs(liability.LateFee-test.fee) > 0.005 {
+				t.Errorf("late fee = %v, want %v", liability.LateFee, test.fee)
+			}
+			if liability.Capped != test.capped {
+				t.Errorf("capped = %v, want %v", liability.Capped, test.capped)
+			}
+			if uncapped := test.returnType == constants.GSTR9 && test.turnover == UnknownTurnover; liability.Uncapped != uncapped {
+				t.Errorf("uncapped = %v, want %v", liability.Uncapped, uncapped)
+			}
+			if liability.DaysLate != test.days {
+				t.Errorf("days late = %v, want %v", liability.DaysLate, test.days)
+			}
+		})
+	}
+}
+
+func TestCalculateInterest(t *testing.T) {
+	due := time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		returnType constants.GstReturnType
+		interest   float64
+	}{
+		{"GSTR-3B", constants.GSTR3B, 1800},
+		{"CMP-08", constants.CMP08, 1800},
+		{"GSTR-1", constants.GSTR1, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ret := Return{ReturnType: test.returnType, DueDate: due, TaxAmount: 10000}
+			liability := Calculate(ret, 1e7, due.AddDate(0, 0, 365))
+
+			if liability.Interest != test.interest {
+				t.Errorf("interest = %v, want %v", liability.Interest, test.interest)
+			}
+		})
+	}
+}
+
+func TestCalculateOnTime(t *testing.T) {
+	due := time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC)
+	ret := Return{ReturnType: constants.GSTR3B, DueDate: due, TaxAmount: 10000}
+
+	liability := Calculate(ret, 1e7, due)
+	if liability.DaysLate != 0 || liability.Total() != 0 {
+		t.Errorf("liability = %+v, want none", liability)
+	}
+}
@@ -0,0 +1,118 @@
+package gst_scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/common"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+)
+
+const (
+	profileFixture = "gsts.json"
+	returnsFixture = "returns.json"
+)
+
+// FixtureScrapper replays recorded `auth/profile/detail` and `returnstatus` responses keyed by GSTIN
+// instead of driving the GST portal, so the scrapping flow can run on machines without Chrome.
+type FixtureScrapper struct {
+	logger logging.Logger
+	cfg    *config.Config
+}
+
+var fixtureScrapper *FixtureScrapper
+var fixtureScrapperOnce sync.Once
+
+func NewFixtureScrapper(cfg *config.Config) *FixtureScrapper {
+	fixtureScrapperOnce.Do(func() {
+		fixtureScrapper = &FixtureScrapper{
+			logger: logging.NewLogger(cfg),
+			cfg:    cfg,
+		}
+	})
+
+	return fixtureScrapper
+}
+
+func (s *FixtureScrapper) ScrapGstReturnsDetail(ctx context.Context, gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[GstDetail], error) {
+	profiles, err := readFixture[map[string]json.RawMessage](s.cfg.Server.Gst.FixturePath, profileFixture)
+	if err != nil {
+		return nil, err
+	}
+
+	returns, err := readFixture[map[string]json.RawMessage](s.cfg.Server.Gst.FixturePath, returnsFixture)
+	if err != nil {
+		return nil, err
+	}
+
+	quit := common.NewSafeChannel[GstDetail]()
+
+	go func() {
+		defer quit.SafeClose()
+
+		for _, gst := range gsts {
+			startedAt := time.Now()
+
+			if common.WaitIfPaused(ctx) != nil {
+				quit.C <- cancelledDetail(gst.Gstin, startedAt)
+				continue
+			}
+
+			detail := s.replay(gst.Gstin, profiles, returns)
+			detail.Gstin = gst.Gstin
+			detail.Attempts = 1
+			detail.StartedAt = startedAt
+			detail.Duration = time.Since(startedAt)
+
+			quit.C <- detail
+		}
+	}()
+
+	return quit, nil
+}
+
+func (s *FixtureScrapper) replay(gstin string, profiles map[string]json.RawMessage, returns map[string]json.RawMessage) GstDetail {
+	profile, hasProfile := profiles[gstin]
+	statuses, hasReturns := returns[gstin]
+
+	if !hasProfile || !hasReturns {
+		return GstDetail{Error: NewScrapeError(constants.NoRecordedResponse, gstin, "")}
+	}
+
+	var gst models.Gst
+	err := json.Unmarshal(profile, &gst)
+	if err != nil {
+		return GstDetail{Error: NewScrapeError(constants.NoRecordedResponse, gstin, fmt.Sprintf("Not able to read recorded profile. %s", err.Error()))}
+	}
+
+	var rtns []models.GstStatus
+	err = json.Unmarshal(statuses, &rtns)
+	if err != nil {
+		return GstDetail{Error: NewScrapeError(constants.NoRecordedResponse, gstin, fmt.Sprintf("Not able to read recorded returns. %s", err.Error()))}
+	}
+
+	s.logger.Infof("Replayed %d returns for GSTIN %s", len(rtns), gstin)
+
+	return GstDetail{Gst: gst, Returns: rtns}
+}
+
+func readFixture[T any](dir string, fileName string) (T, error) {
+	var result T
+
+	data, err := os.ReadFile(filepath.Join(dir, fileName))
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(data, &result)
+
+	return result, err
+}
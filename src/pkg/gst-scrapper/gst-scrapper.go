@@ -6,8 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"regexp"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -16,53 +15,98 @@ import (
 	"github.com/go-rod/rod/lib/input"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/google/uuid"
 	"github.com/jaganathanb/dapps-api/common"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/models"
+	captcha_solver "github.com/jaganathanb/dapps-api/pkg/captcha-solver"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
-	"github.com/jaganathanb/dapps-api/pkg/s2t"
+	"golang.org/x/time/rate"
+)
+
+const (
+	ScreenshotArtifact = "screenshot"
+	HtmlArtifact       = "html"
+	HarArtifact        = "har"
+)
+
+const (
+	defaultConcurrency     = 3
+	defaultLoginsPerMinute = 6
 )
 
 type GstDetail struct {
-	Gst          models.Gst
-	Returns      []models.GstStatus
-	ErrorMessage string
+	Gstin     string
+	Gst       models.Gst
+	Returns   []models.GstStatus
+	Error     *ScrapeError
+	Cancelled bool
+	Attempts  int
+	StartedAt time.Time
+	Duration  time.Duration
+	Artifacts []Artifact
+}
+
+// Artifact is an evidence of how the portal looked like when scrapping a GSTIN failed
+type Artifact struct {
+	Kind        string
+	FileName    string
+	ContentType string
+	Data        []byte
 }
 
 type DashboardDetail struct {
-	Landed       bool
-	ErrorMessage string
-	ShouldRetry  bool
+	Landed   bool
+	Error    *ScrapeError
+	Attempts int
 }
 
 type GstScrapper struct {
-	logger        logging.Logger
-	cfg           *config.Config
-	speechService *s2t.DAppsSpeechToText
+	logger   logging.Logger
+	cfg      *config.Config
+	solver   captcha_solver.CaptchaSolver
+	workers  chan struct{}
+	logins   *rate.Limiter
+	sessions SessionStore
 }
 
 var gstScrapper *GstScrapper
 var gstScrapperOnce sync.Once
 
-func NewGstScrapper(cfg *config.Config) *GstScrapper {
+func NewGstScrapper(cfg *config.Config, sessions SessionStore) *GstScrapper {
 	gstScrapperOnce.Do(func() {
+		concurrency := cfg.Server.Gst.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultConcurrency
+		}
+
+		loginsPerMinute := cfg.Server.Gst.LoginsPerMinute
+		if loginsPerMinute <= 0 {
+			loginsPerMinute = defaultLoginsPerMinute
+		}
+
+		// Shared by all the runs, so overlapping runs together stay within the limits
 		gstScrapper = &GstScrapper{
-			logger:        logging.NewLogger(cfg),
-			cfg:           cfg,
-			speechService: s2t.NewDAppsSpeechToText(cfg),
+			logger:   logging.NewLogger(cfg),
+			cfg:      cfg,
+			solver:   captcha_solver.NewCaptchaSolver(cfg),
+			workers:  make(chan struct{}, concurrency),
+			logins:   rate.NewLimiter(rate.Every(time.Minute/time.Duration(loginsPerMinute)), 1),
+			sessions: sessions,
 		}
 	})
 
 	return gstScrapper
 }
 
-func (s *GstScrapper) ScrapGstReturnsDetail(gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[GstDetail], error) {
+func (s *GstScrapper) ScrapGstReturnsDetail(ctx context.Context, gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[GstDetail], error) {
 	quit := common.NewSafeChannel[GstDetail]()
 
 	l := launcher.New().Headless(true).Devtools(false).Leakless(false)
 	browser := rod.New().ControlURL(l.MustLaunch()).MustConnect().SlowMotion(time.Second * 1).Trace(s.cfg.Server.RunMode == "debug")
 
-	go s.runGstProcesses(gsts, browser, l, useCredentialFromSettings, quit)
+	go s.runGstProcesses(ctx, gsts, browser, l, useCredentialFromSettings, quit)
 
 	defer common.RecoverFromPanic(func(err any) {
 		s.logger.Errorf("Recovered from panic: ", err)
@@ -76,44 +120,71 @@ func (s *GstScrapper) ScrapGstReturnsDetail(gsts []models.Gst, useCredentialFrom
 	return quit, nil
 }
 
-func (s *GstScrapper) runGstProcesses(gsts []models.Gst, browser *rod.Browser, launcher *launcher.Launcher, useCredentialFromSettings bool, quit *common.SafeChannel[GstDetail]) {
-	pool := rod.NewBrowserPool(len(gsts))
+func (s *GstScrapper) runGstProcesses(ctx context.Context, gsts []models.Gst, browser *rod.Browser, launcher *launcher.Launcher, useCredentialFromSettings bool, quit *common.SafeChannel[GstDetail]) {
+	pool := rod.NewBrowserPool(min(cap(s.workers), len(gsts)))
 
 	create := func() *rod.Browser {
 		return browser.MustIncognito()
 	}
 
 	scrapJob := func(gst models.Gst) GstDetail {
-		browser := pool.Get(create)
-		defer pool.Put(browser)
+		startedAt := time.Now()
+		attempts := 0
+
+		var gstDetail GstDetail
+		for try := 1; ; try++ {
+			if common.WaitIfPaused(ctx) != nil {
+				return cancelledDetail(gst.Gstin, startedAt)
+			}
 
-		page := browser.MustPage(s.cfg.Server.Gst.BaseUrl).MustWaitLoad()
+			gstDetail = s.scrapGst(ctx, pool, create, gst, useCredentialFromSettings)
+			attempts += gstDetail.Attempts
 
-		page.MustWindowMaximize()
+			if gstDetail.Cancelled {
+				return cancelledDetail(gst.Gstin, startedAt)
+			}
 
-		dashboard := s.login(page, gst, useCredentialFromSettings)
+			if gstDetail.Error == nil || !gstDetail.Error.Retryable {
+				break
+			}
 
-		var gstDetail GstDetail
-		if dashboard.Landed {
-			gstDetail = s.getGstReturnsDetail(page, gst.Gstin)
-		} else {
-			gstDetail.ErrorMessage = dashboard.ErrorMessage
+			// Captcha is retried within the same login
+			policy := s.retryPolicy(gstDetail.Error.Class)
+			if gstDetail.Error.Class == constants.CaptchaScrapeError || try >= policy.MaxAttempts {
+				break
+			}
+
+			delay := backoff(policy, try)
+			s.logger.Infof("Retrying GSTIN %s in %s after %s error. %s", gst.Gstin, delay, gstDetail.Error.Class, gstDetail.Error.Error())
+
+			if sleep(ctx, delay) != nil {
+				return cancelledDetail(gst.Gstin, startedAt)
+			}
 		}
 
-		page.Close()
+		gstDetail.Gstin = gst.Gstin
+		gstDetail.Attempts = attempts
+		gstDetail.StartedAt = startedAt
+		gstDetail.Duration = time.Since(startedAt)
 
 		return gstDetail
 	}
 
-	wg := sync.WaitGroup{}
+	jobs := make(chan models.Gst, len(gsts))
 	for _, gst := range gsts {
+		jobs <- gst
+	}
+	close(jobs)
+
+	wg := sync.WaitGroup{}
+	for range min(cap(s.workers), len(gsts)) {
 		wg.Add(1)
-		go func(g models.Gst) {
+		go func() {
 			defer wg.Done()
-			detail := scrapJob(g)
-
-			quit.C <- detail
-		}(gst)
+			for g := range jobs {
+				quit.C <- scrapJob(g)
+			}
+		}()
 	}
 	wg.Wait()
 
@@ -124,6 +195,168 @@ func (s *GstScrapper) runGstProcesses(gsts []models.Gst, browser *rod.Browser, l
 	quit.SafeClose()
 }
 
+// scrapGst makes one attempt at scrapping the GSTIN. Attempts of the result is the number of logins tried
+func (s *GstScrapper) scrapGst(ctx context.Context, pool rod.BrowserPool, create func() *rod.Browser, gst models.Gst, useCredentialFromSettings bool) GstDetail {
+	username, _ := s.credential(gst, useCredentialFromSettings)
+	cookies, hasSession := s.loadSession(username)
+
+	for {
+		select {
+		case s.workers <- struct{}{}:
+		case <-ctx.Done():
+			return GstDetail{Cancelled: true}
+		}
+
+		// A reused session does not log in, so it is not held by the login rate
+		if !hasSession && s.throttle(ctx) != nil {
+			<-s.workers
+			return GstDetail{Cancelled: true}
+		}
+
+		// The run may have been paused while the GSTIN waited for a worker or the login rate. The worker is
+		// given back until the run is resumed
+		if !common.IsPaused(ctx) {
+			break
+		}
+
+		<-s.workers
+		if common.WaitIfPaused(ctx) != nil {
+			return GstDetail{Cancelled: true}
+		}
+	}
+	defer func() { <-s.workers }()
+
+	if ctx.Err() != nil {
+		return GstDetail{Cancelled: true}
+	}
+
+	browser := pool.Get(create)
+	defer pool.Put(browser)
+
+	var gstDetail GstDetail
+	var dashboard DashboardDetail
+	var page *rod.Page
+	var recorder *harRecorder
+
+	err := rod.Try(func() {
+		// Pooled browsers are shared between GSTINs, never carry the cookies of the previous one
+		browser.MustSetCookies()
+
+		page = browser.MustPage("")
+		recorder = newHarRecorder(page)
+		defer recorder.Close()
+
+		// Every page operation fails as soon as the run is cancelled
+		p := page.Context(ctx)
+
+		p.MustWindowMaximize()
+
+		if hasSession {
+			dashboard = s.resumeSession(p, gst.Gstin, cookies)
+
+			if !dashboard.Landed {
+				s.removeSession(username)
+				browser.MustSetCookies()
+
+				if s.throttle(ctx) != nil {
+					panic(ctx.Err())
+				}
+			}
+		}
+
+		if !dashboard.Landed {
+			p.MustNavigate(s.cfg.Server.Gst.BaseUrl).MustWaitLoad()
+
+			dashboard = s.login(p, gst, useCredentialFromSettings)
+
+			if dashboard.Landed {
+				s.saveSession(username, p)
+			}
+		}
+
+		if dashboard.Landed {
+			gstDetail = s.getGstReturnsDetail(p, gst.Gstin)
+		} else {
+			gstDetail.Error = dashboard.Error
+		}
+	})
+
+	if page != nil {
+		if ctx.Err() == nil && (err != nil || gstDetail.Error != nil) {
+			gstDetail.Artifacts = s.captureArtifacts(page, recorder)
+		}
+
+		page.Close()
+	}
+
+	if ctx.Err() != nil {
+		return GstDetail{Cancelled: true}
+	}
+
+	if err != nil {
+		gstDetail.Error = NewScrapeError(constants.PortalError, gst.Gstin, err.Error())
+	}
+
+	gstDetail.Attempts = max(dashboard.Attempts, 1)
+
+	return gstDetail
+}
+
+// captureArtifacts takes a full page screenshot, the page HTML and the HAR of the page. Whatever could not be captured is skipped
+func (s *GstScrapper) captureArtifacts(page *rod.Page, recorder *harRecorder) []Artifact {
+	artifacts := []Artifact{}
+
+	page = page.Timeout(30 * time.Second)
+	defer page.CancelTimeout()
+
+	screenshot, err := page.Screenshot(true, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err == nil {
+		artifacts = append(artifacts, Artifact{Kind: ScreenshotArtifact, FileName: "screenshot.png", ContentType: "image/png", Data: screenshot})
+	} else {
+		s.logger.Errorf("Could not take screenshot of the page. %s", err.Error())
+	}
+
+	html, err := page.HTML()
+	if err == nil {
+		artifacts = append(artifacts, Artifact{Kind: HtmlArtifact, FileName: "page.html", ContentType: "text/html", Data: []byte(html)})
+	} else {
+		s.logger.Errorf("Could not read HTML of the page. %s", err.Error())
+	}
+
+	if recorder == nil {
+		return artifacts
+	}
+
+	data, err := recorder.Marshal()
+	if err == nil {
+		artifacts = append(artifacts, Artifact{Kind: HarArtifact, FileName: "network.har", ContentType: "application/json", Data: data})
+	} else {
+		s.logger.Errorf("Could not prepare HAR of the page. %s", err.Error())
+	}
+
+	return artifacts
+}
+
+// throttle holds a login until the per minute login rate allows it, followed by a jittered delay
+func (s *GstScrapper) throttle(ctx context.Context) error {
+	err := s.logins.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	delay := s.cfg.Server.Gst.LoginDelay * time.Second
+	if jitter := s.cfg.Server.Gst.LoginJitter * time.Second; jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (s *GstScrapper) getGstReturnsDetail(page *rod.Page, gstin string) GstDetail {
 	gstData, _, err := s.extractResponseFromHttpRequest(page, "auth/profile/detail", ".dp-widgt > a.tp-pfl-lnk", "/View Profile /")
 
@@ -131,6 +364,10 @@ func (s *GstScrapper) getGstReturnsDetail(page *rod.Page, gstin string) GstDetai
 		var gst models.Gst
 		json.Unmarshal([]byte(gstData.Body), &gst)
 
+		if gst.Gstin != "" && gst.Gstin != gstin {
+			return GstDetail{Error: NewScrapeError(constants.GstinMismatch, gstin, fmt.Sprintf("Portal profile is of GSTIN %s", gst.Gstin))}
+		}
+
 		page.MustElementR(".nav > .menuList > a.dropdown-toggle", "/Services/").MustClick() // Click Services
 		page.MustElementR(".smenu > .has-sub > a", "/Returns/").MustHover()                 // Hover Returns
 
@@ -144,10 +381,10 @@ func (s *GstScrapper) getGstReturnsDetail(page *rod.Page, gstin string) GstDetai
 
 			return GstDetail{Gst: gst, Returns: statuses}
 		} else {
-			return GstDetail{ErrorMessage: fmt.Sprintf("Not able to extract response from Gst API calls for GSTIN %s", gstin)}
+			return GstDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Not able to extract response of return status. %s", err.Error()))}
 		}
 	} else {
-		return GstDetail{ErrorMessage: fmt.Sprintf("Not able to extract response from Gst API calls for GSTIN %s", gstin)}
+		return GstDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Not able to extract response of profile detail. %s", err.Error()))}
 	}
 }
 
@@ -197,97 +434,131 @@ func (s *GstScrapper) extractResponseFromHttpRequest(page *rod.Page, url string,
 
 	wait()
 
+	if response == nil {
+		return nil, string(requestId), fmt.Errorf("request to %s failed", url)
+	}
+
 	return response, string(requestId), nil
 }
 
 func (s *GstScrapper) login(page *rod.Page, gst models.Gst, useCredentialFromSettings bool) DashboardDetail {
 	var dashboard DashboardDetail
-	for v := range 3 {
+	attempts := 0
+
+	policy := s.retryPolicy(constants.CaptchaScrapeError)
+
+	for v := range policy.MaxAttempts {
+		if v > 0 {
+			err := sleep(page.GetContext(), backoff(policy, v))
+			if err != nil {
+				dashboard = DashboardDetail{Error: NewScrapeError(constants.ScrapeCancelled, gst.Gstin, err.Error())}
+				break
+			}
+		}
+
 		s.logger.Infof("Try logging in as %d time", v)
+		attempts = v + 1
 
 		err := s.setUsernamePassword(page, gst, useCredentialFromSettings)
 
 		if err != nil {
-			dashboard.ErrorMessage = fmt.Sprintf("Something went wrong while setting username password for GSTIN %s login page. The error is: %s", gst.Gstin, err.Error())
+			dashboard = DashboardDetail{Error: NewScrapeError(constants.PortalError, gst.Gstin, fmt.Sprintf("Something went wrong while setting username password in login page. %s", err.Error()))}
 			break
 		}
 
-		data, id, err := s.extractResponseFromHttpRequest(page, "/audiocaptcha", "i.fa.fa-volume-up", "/.*/")
+		captcha, err := s.readCaptcha(page, gst.Gstin)
 
-		if err == nil && data != nil {
-			dashboard = s.setCaptchaAndLogin(page, data, id, gst.Gstin)
+		if err == nil {
+			dashboard = s.setCaptchaAndLogin(page, captcha, gst.Gstin)
 
-			if dashboard.Landed {
+			if dashboard.Landed || dashboard.Error.Class != constants.CaptchaScrapeError {
 				break
-			} else {
-				if !dashboard.ShouldRetry {
-					break
-				}
 			}
 		} else {
 			s.logger.Errorf("Something went wrong while processing captcha code for GSTIN %s. Trying again %v time", gst.Gstin, v)
+
+			dashboard = DashboardDetail{Error: NewScrapeError(constants.CaptchaNotSolved, gst.Gstin, fmt.Sprintf("Could not read captcha. %s", err.Error()))}
 		}
 	}
 
+	dashboard.Attempts = attempts
+
 	return dashboard
 }
 
-func (s *GstScrapper) setCaptchaAndLogin(page *rod.Page, audioData *proto.NetworkGetResponseBodyResult, id string, gstin string) DashboardDetail {
-	fileName := fmt.Sprintf("%s.mp3", id)
-	defer func(fn string) {
-		os.Remove(fn)
-	}(fileName)
+// readCaptcha captures the captcha in the medium the solver works with.
+func (s *GstScrapper) readCaptcha(page *rod.Page, gstin string) (captcha_solver.Captcha, error) {
+	captcha := captcha_solver.Captcha{Id: uuid.NewString(), Gstin: gstin, Medium: s.solver.Medium()}
 
-	err := createAudioFile(audioData.Body, id)
+	switch captcha.Medium {
+	case captcha_solver.Audio:
+		data, _, err := s.extractResponseFromHttpRequest(page, "/audiocaptcha", "i.fa.fa-volume-up", "/.*/")
+		if err != nil {
+			return captcha, err
+		}
 
-	if err == nil {
-		code, err := s.speechService.SpeechToText(fileName)
-		if err == nil {
-			var numericRegex = regexp.MustCompile(`[^\p{N} ]+`)
+		if data == nil {
+			return captcha, fmt.Errorf("audio captcha could not be downloaded for GSTIN %s", gstin)
+		}
 
-			err = rod.Try(func() {
-				captcha := page.MustElement("#captcha")
+		if data.Base64Encoded {
+			captcha.Data, err = base64.StdEncoding.DecodeString(data.Body)
+		} else {
+			captcha.Data = []byte(data.Body)
+		}
 
-				captcha.MustSelectAllText().MustType(input.Backspace).MustInput(numericRegex.ReplaceAllString(code, ""))
+		return captcha, err
+	case captcha_solver.Image:
+		err := rod.Try(func() {
+			captcha.Data = page.Timeout(time.Duration(5 * time.Second)).MustElement("#imgCaptcha").CancelTimeout().MustScreenshot()
+		})
 
-				page.MustElement("[type=submit]").MustClick()
-			})
+		return captcha, err
+	}
 
-			if err != nil {
-				return DashboardDetail{ErrorMessage: fmt.Sprintf("Something went wrong while setting captcha code and clicking login button for GSTIN %s. The error is: %s", gstin, err.Error())}
-			}
+	return captcha, nil
+}
 
-			err := rod.Try(func() {
-				page.Timeout(time.Duration(10 * time.Second)).MustElement("body.modal-open")
-			})
+func (s *GstScrapper) setCaptchaAndLogin(page *rod.Page, captcha captcha_solver.Captcha, gstin string) DashboardDetail {
+	code, err := s.solver.Solve(page.GetContext(), captcha)
+	if err != nil {
+		s.logger.Errorf("Error while solving the captcha for GSTIN %s. Error - %s", gstin, err.Error())
+		return DashboardDetail{Error: NewScrapeError(constants.CaptchaNotSolved, gstin, fmt.Sprintf("Could not solve captcha. %s", err.Error()))}
+	}
 
-			if err != nil {
-				return s.checkDashboardPage(page, gstin)
-			} else {
-				found, ele, _ := page.HasR("#adhrtableV div.modal-footer > a", "/Remind me later/")
-				if found {
-					ele.MustClick()
-
-					return s.checkDashboardPage(page, gstin)
-				} else {
-					found, _, _ := page.HasR("#confirmDlg div.modal-footer > a", "/FILE AMENDMENT/")
-					if found {
-						return DashboardDetail{ErrorMessage: fmt.Sprintf("Bank account is not linked with GSTIN %s", gstin)}
-					}
-				}
+	err = rod.Try(func() {
+		captchaEl := page.MustElement("#captcha")
 
-				return DashboardDetail{ErrorMessage: fmt.Sprintf("There is unknow dialog preventing the process to get gst details for GSTIN %s", gstin)}
-			}
-		} else {
-			s.logger.Errorf("Error while processing the Speech to text for GSTIN %s. The code is - %s", gstin, code)
-			return DashboardDetail{ShouldRetry: true}
-		}
+		captchaEl.MustSelectAllText().MustType(input.Backspace).MustInput(code)
+
+		page.MustElement("[type=submit]").MustClick()
+	})
+
+	if err != nil {
+		return DashboardDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Something went wrong while setting captcha code and clicking login button. %s", err.Error()))}
 	}
 
-	s.logger.Errorf("Error while processing the Speech to text for GSTIN %s. Error - %s", gstin, err.Error())
-	return DashboardDetail{
-		ShouldRetry: true,
+	err = rod.Try(func() {
+		page.Timeout(time.Duration(10 * time.Second)).MustElement("body.modal-open")
+	})
+
+	if err != nil {
+		return s.checkDashboardPage(page, gstin)
 	}
+
+	found, ele, _ := page.HasR("#adhrtableV div.modal-footer > a", "/Remind me later/")
+	if found {
+		ele.MustClick()
+
+		return s.checkDashboardPage(page, gstin)
+	} else {
+		found, _, _ := page.HasR("#confirmDlg div.modal-footer > a", "/FILE AMENDMENT/")
+		if found {
+			return DashboardDetail{Error: NewScrapeError(constants.BankAccountNotLinked, gstin, "")}
+		}
+	}
+
+	return DashboardDetail{Error: NewScrapeError(constants.UnknownDialog, gstin, "")}
 }
 
 func (s *GstScrapper) checkDashboardPage(page *rod.Page, gstin string) DashboardDetail {
@@ -303,9 +574,7 @@ func (s *GstScrapper) checkDashboardPage(page *rod.Page, gstin string) Dashboard
 		})
 
 		if err == nil {
-			return DashboardDetail{
-				ErrorMessage: fmt.Sprintf("NOTIFICATION|GST credential needs to be changed for the GSTIN %s", gstin),
-			}
+			return DashboardDetail{Error: NewScrapeError(constants.PasswordExpired, gstin, "")}
 		}
 
 		var errMsg *rod.Element
@@ -317,7 +586,7 @@ func (s *GstScrapper) checkDashboardPage(page *rod.Page, gstin string) Dashboard
 			msg, _ := errMsg.Text()
 
 			if strings.Contains(msg, "Enter valid Letters shown") {
-				return DashboardDetail{ShouldRetry: true}
+				return DashboardDetail{Error: NewScrapeError(constants.CaptchaNotSolved, gstin, "Captcha was not accepted")}
 			}
 		}
 
@@ -329,15 +598,13 @@ func (s *GstScrapper) checkDashboardPage(page *rod.Page, gstin string) Dashboard
 			msg, _ := errMsg.Text()
 
 			if strings.Contains(msg, "Invalid Username or Password. Please try again.") {
-				return DashboardDetail{
-					ErrorMessage: fmt.Sprintf("NOTIFICATION|GST username or password is invalid. Please update GST credential for %s and try again.", gstin),
-				}
+				return DashboardDetail{Error: NewScrapeError(constants.InvalidCredential, gstin, "")}
 			}
 		} else {
-			return DashboardDetail{ErrorMessage: fmt.Sprintf("Something went wrong while looking for dashboard widget for GSTIN %s - Reason: %s", gstin, err.Error())}
+			return DashboardDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Something went wrong while looking for dashboard widget. %s", err.Error()))}
 		}
 	} else if err != nil {
-		return DashboardDetail{ErrorMessage: fmt.Sprintf("Something went wrong while looking for dashboard widget for GSTIN %s - Reason: %s", gstin, err.Error())}
+		return DashboardDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Something went wrong while looking for dashboard widget. %s", err.Error()))}
 	} else {
 		s.logger.Infof("Successfully landed into dashboard widget")
 		return DashboardDetail{
@@ -345,7 +612,15 @@ func (s *GstScrapper) checkDashboardPage(page *rod.Page, gstin string) Dashboard
 		}
 	}
 
-	return DashboardDetail{ErrorMessage: fmt.Sprintf("Something went wrong while landing into dashboard for GSTIN %s. It is neither timeout nor element not found.", gstin)}
+	return DashboardDetail{Error: NewScrapeError(constants.PortalError, gstin, "Something went wrong while landing into dashboard. It is neither timeout nor element not found.")}
+}
+
+func (s *GstScrapper) credential(gst models.Gst, useCredentialFromSettings bool) (string, string) {
+	if useCredentialFromSettings {
+		return s.cfg.Server.Gst.Username, s.cfg.Server.Gst.Password
+	}
+
+	return gst.Username, gst.Password
 }
 
 func (s *GstScrapper) setUsernamePassword(page *rod.Page, gst models.Gst, useCredentialFromSettings bool) error {
@@ -361,13 +636,7 @@ func (s *GstScrapper) setUsernamePassword(page *rod.Page, gst models.Gst, useCre
 		return err
 	}
 
-	username := gst.Username
-	password := gst.Password
-
-	if useCredentialFromSettings {
-		username = s.cfg.Server.Gst.Username
-		password = s.cfg.Server.Gst.Password
-	}
+	username, password := s.credential(gst, useCredentialFromSettings)
 
 	usernameEl.MustSelectAllText().MustType(input.Backspace).MustInput(username)
 
@@ -383,25 +652,3 @@ func (s *GstScrapper) setUsernamePassword(page *rod.Page, gst models.Gst, useCre
 
 	return nil
 }
-
-func createAudioFile(fileContent string, id string) error {
-	dec, err := base64.StdEncoding.DecodeString(fileContent)
-	if err != nil {
-		return err
-	}
-
-	f, err := os.Create(fmt.Sprintf("%s.mp3", id))
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	if _, err := f.Write(dec); err != nil {
-		return err
-	}
-	if err := f.Sync(); err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,235 @@
+package gst_scrapper
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/jaganathanb/dapps-api/common"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	captcha_solver "github.com/jaganathanb/dapps-api/pkg/captcha-solver"
+	fake_gst_portal "github.com/jaganathanb/dapps-api/pkg/fake-gst-portal"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	"golang.org/x/time/rate"
+)
+
+const (
+	stubCode     = "123456"
+	testGstin    = "33AOSPA7307Q1ZI"
+	testPassword = "secret"
+)
+
+// portalTest drives the fake GST portal with a headless browser and the stub captcha solver
+type portalTest struct {
+	cfg      *config.Config
+	scrapper *GstScrapper
+	browser  *rod.Browser
+}
+
+func newPortalTest(t *testing.T) *portalTest {
+	if testing.Short() {
+		t.Skip("drives a browser")
+	}
+
+	bin, found := os.Getenv("ROD_BROWSER_BIN"), true
+	if bin == "" {
+		bin, found = launcher.LookPath()
+	}
+	if !found {
+		// CI installs a browser, the portal tests must not be skipped there
+		if os.Getenv("CI") != "" {
+			t.Fatal("no browser to drive the fake GST portal with")
+		}
+		t.Skip("no browser to drive the fake GST portal with")
+	}
+
+	l := launcher.New().Bin(bin).Headless(true).Leakless(false)
+	browser := rod.New().ControlURL(l.MustLaunch()).MustConnect()
+	t.Cleanup(func() {
+		browser.MustClose()
+		l.Cleanup()
+	})
+
+	cfg := &config.Config{
+		Logger:  config.LoggerConfig{Logger: "zap", FilePath: t.TempDir(), Level: "error"},
+		Captcha: config.CaptchaConfig{Solver: captcha_solver.StubSolverName, StubCode: stubCode},
+		Server: config.ServerConfig{Gst: config.GstServer{
+			FixturePath: "../../data/db/mocks",
+			Retry:       config.GstRetryConfig{Captcha: config.RetryPolicy{MaxAttempts: 2}},
+		}},
+	}
+
+	scrapper := &GstScrapper{
+		logger: logging.NewLogger(cfg),
+		cfg:    cfg,
+		solver: captcha_solver.NewStubSolver(cfg),
+	}
+
+	return &portalTest{cfg: cfg, scrapper: scrapper, browser: browser}
+}
+
+// open starts a fake portal on a random port with the account and the captcha it accepts, and opens its
+// login page in a new incognito window
+func (p *portalTest) open(t *testing.T, captcha string, account config.FakeGstAccount) *rod.Page {
+	cfg := *p.cfg
+	cfg.FakeGstPortal = config.FakeGstPortalConfig{Captcha: captcha, Accounts: []config.FakeGstAccount{account}}
+
+	server := httptest.NewServer(fake_gst_portal.NewFakeGstPortal(&cfg).Handler())
+	t.Cleanup(server.Close)
+
+	page := p.browser.MustIncognito().MustPage(server.URL + "/services/login").MustWaitLoad()
+	t.Cleanup(func() { page.MustClose() })
+
+	return page
+}
+
+func TestLogin(t *testing.T) {
+	p := newPortalTest(t)
+
+	tests := []struct {
+		name     string
+		scenario string
+		password string
+		captcha  string
+		landed   bool
+		code     constants.ScrapeErrorCode
+		attempts int
+	}{
+		{name: "dashboard", scenario: fake_gst_portal.Dashboard, password: testPassword, captcha: stubCode, landed: true, attempts: 1},
+		{name: "aadhaar reminder", scenario: fake_gst_portal.AadhaarReminder, password: testPassword, captcha: stubCode, landed: true, attempts: 1},
+		{name: "invalid password", scenario: fake_gst_portal.Dashboard, password: "wrong", captcha: stubCode, code: constants.InvalidCredential, attempts: 1},
+		{name: "forced password change", scenario: fake_gst_portal.ChangePassword, password: testPassword, captcha: stubCode, code: constants.PasswordExpired, attempts: 1},
+		{name: "captcha mismatch", scenario: fake_gst_portal.Dashboard, password: testPassword, captcha: "654321", code: constants.CaptchaNotSolved, attempts: 2},
+		{name: "bank account not linked", scenario: fake_gst_portal.BankAccountNotLinked, password: testPassword, captcha: stubCode, code: constants.BankAccountNotLinked, attempts: 1},
+		{name: "unknown dialog", scenario: fake_gst_portal.UnknownDialog, password: testPassword, captcha: stubCode, code: constants.UnknownDialog, attempts: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := p.open(t, test.captcha, config.FakeGstAccount{
+				Username: "user", Password: testPassword, Gstin: testGstin, Scenario: test.scenario,
+			})
+
+			dashboard := p.scrapper.login(page, models.Gst{Gstin: testGstin, Username: "user", Password: test.password}, false)
+
+			if dashboard.Landed != test.landed {
+				t.Fatalf("landed = %v, want %v. %v", dashboard.Landed, test.landed, dashboard.Error)
+			}
+			if !test.landed && dashboard.Error.Code != test.code {
+				t.Errorf("code = %s, want %s. %v", dashboard.Error.Code, test.code, dashboard.Error)
+			}
+			if dashboard.Attempts != test.attempts {
+				t.Errorf("attempts = %d, want %d", dashboard.Attempts, test.attempts)
+			}
+		})
+	}
+}
+
+func TestCheckDashboardPage(t *testing.T) {
+	p := newPortalTest(t)
+
+	page := p.open(t, stubCode, config.FakeGstAccount{Username: "user", Password: testPassword, Gstin: testGstin})
+
+	dashboard := p.scrapper.checkDashboardPage(page, testGstin)
+	if dashboard.Landed || dashboard.Error == nil || dashboard.Error.Code != constants.PortalError {
+		t.Errorf("login page = %+v, want %s", dashboard, constants.PortalError)
+	}
+}
+
+func TestGetGstReturnsDetail(t *testing.T) {
+	p := newPortalTest(t)
+
+	tests := []struct {
+		name    string
+		gstin   string
+		code    constants.ScrapeErrorCode
+		returns int
+	}{
+		{name: "returns", gstin: testGstin, returns: 21},
+		{name: "gstin mismatch", gstin: "33AKBPA6032B1Z6", code: constants.GstinMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := p.open(t, stubCode, config.FakeGstAccount{Username: "user", Password: testPassword, Gstin: testGstin})
+
+			dashboard := p.scrapper.login(page, models.Gst{Gstin: test.gstin, Username: "user", Password: testPassword}, false)
+			if !dashboard.Landed {
+				t.Fatalf("not landed. %v", dashboard.Error)
+			}
+
+			detail := p.scrapper.getGstReturnsDetail(page, test.gstin)
+
+			if test.code != "" {
+				if detail.Error == nil || detail.Error.Code != test.code {
+					t.Errorf("error = %v, want %s", detail.Error, test.code)
+				}
+				return
+			}
+
+			if detail.Error != nil {
+				t.Fatalf("error = %v", detail.Error)
+			}
+			if detail.Gst.Gstin != test.gstin {
+				t.Errorf("gstin = %s, want %s", detail.Gst.Gstin, test.gstin)
+			}
+			if len(detail.Returns) != test.returns {
+				t.Errorf("returns = %d, want %d", len(detail.Returns), test.returns)
+			}
+		})
+	}
+}
+
+func TestScrapGstHoldsWhenPausedWhileWaitingForAWorker(t *testing.T) {
+	cfg := &config.Config{Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir(), Level: "error"}}
+	scrapper := &GstScrapper{
+		logger:  logging.NewLogger(cfg),
+		cfg:     cfg,
+		workers: make(chan struct{}, 1),
+		logins:  rate.NewLimiter(rate.Inf, 1),
+	}
+
+	gate := common.NewPauseGate()
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = common.WithPauseGate(ctx, gate)
+
+	var started atomic.Bool
+	create := func() *rod.Browser {
+		started.Store(true)
+		return nil
+	}
+
+	// another GSTIN holds the only worker
+	scrapper.workers <- struct{}{}
+
+	done := make(chan GstDetail)
+	go func() {
+		done <- scrapper.scrapGst(ctx, rod.NewBrowserPool(1), create, models.Gst{Gstin: testGstin}, false)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	gate.Pause()
+	<-scrapper.workers
+
+	time.Sleep(100 * time.Millisecond)
+	if started.Load() {
+		t.Fatalf("GSTIN started scrapping while the run is paused")
+	}
+
+	cancel()
+
+	detail := <-done
+	if !detail.Cancelled {
+		t.Errorf("detail = %+v, want cancelled", detail)
+	}
+	if len(scrapper.workers) != 0 {
+		t.Errorf("worker is not given back")
+	}
+}
@@ -0,0 +1,183 @@
+package gst_scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"github.com/samber/lo"
+)
+
+// Bare HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/) with only what the network events tell us
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string      `json:"method"`
+	Url         string      `json:"url"`
+	HttpVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	QueryString []harHeader `json:"queryString"`
+	Cookies     []harHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HttpVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Cookies     []harHeader `json:"cookies"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder keeps the network events of a page so they can be saved as a HAR when scrapping fails
+type harRecorder struct {
+	entries map[proto.NetworkRequestID]*harEntry
+	order   []proto.NetworkRequestID
+	mutex   sync.Mutex
+	stop    context.CancelFunc
+}
+
+func newHarRecorder(page *rod.Page) *harRecorder {
+	ctx, stop := context.WithCancel(context.Background())
+	recorder := &harRecorder{entries: map[proto.NetworkRequestID]*harEntry{}, stop: stop}
+
+	wait := page.Context(ctx).EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		recorder.mutex.Lock()
+		defer recorder.mutex.Unlock()
+
+		if _, found := recorder.entries[e.RequestID]; !found {
+			recorder.order = append(recorder.order, e.RequestID)
+		}
+
+		recorder.entries[e.RequestID] = &harEntry{
+			StartedDateTime: e.WallTime.Time(),
+			Request: harRequest{
+				Method:      e.Request.Method,
+				Url:         e.Request.URL,
+				HttpVersion: "HTTP/1.1",
+				Headers:     toHarHeaders(e.Request.Headers),
+				QueryString: []harHeader{},
+				Cookies:     []harHeader{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: harResponse{Headers: []harHeader{}, Cookies: []harHeader{}, HeadersSize: -1, BodySize: -1},
+		}
+	}, func(e *proto.NetworkResponseReceived) {
+		recorder.update(e.RequestID, func(entry *harEntry) {
+			entry.Response.Status = e.Response.Status
+			entry.Response.StatusText = e.Response.StatusText
+			entry.Response.HttpVersion = e.Response.Protocol
+			entry.Response.Headers = toHarHeaders(e.Response.Headers)
+			entry.Response.Content.MimeType = e.Response.MIMEType
+		})
+	}, func(e *proto.NetworkLoadingFinished) {
+		recorder.update(e.RequestID, func(entry *harEntry) {
+			entry.Response.Content.Size = int(e.EncodedDataLength)
+			entry.Response.BodySize = int(e.EncodedDataLength)
+			entry.Time = float64(time.Since(entry.StartedDateTime).Milliseconds())
+			entry.Timings.Wait = entry.Time
+		})
+	}, func(e *proto.NetworkLoadingFailed) {
+		recorder.update(e.RequestID, func(entry *harEntry) {
+			entry.Comment = e.ErrorText
+			entry.Time = float64(time.Since(entry.StartedDateTime).Milliseconds())
+			entry.Timings.Wait = entry.Time
+		})
+	})
+
+	go wait()
+
+	return recorder
+}
+
+func (r *harRecorder) update(requestId proto.NetworkRequestID, apply func(entry *harEntry)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry, found := r.entries[requestId]; found {
+		apply(entry)
+	}
+}
+
+func (r *harRecorder) Close() {
+	r.stop()
+}
+
+func (r *harRecorder) Marshal() ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := make([]harEntry, 0, len(r.order))
+	for _, id := range r.order {
+		entries = append(entries, *r.entries[id])
+	}
+
+	return json.MarshalIndent(har{Log: harLog{Version: "1.2", Creator: harCreator{Name: "dapps-api", Version: "1.0"}, Entries: entries}}, "", "  ")
+}
+
+// secretHeaders carry the portal session and credentials, the HAR is saved in plain text
+var secretHeaders = []string{"authorization", "cookie", "set-cookie", "proxy-authorization"}
+
+func toHarHeaders(headers proto.NetworkHeaders) []harHeader {
+	result := []harHeader{}
+	for name, value := range headers {
+		header := harHeader{Name: name, Value: value.String()}
+		if lo.Contains(secretHeaders, strings.ToLower(name)) {
+			header.Value = encryption.MaskValue(header.Value)
+		}
+
+		result = append(result, header)
+	}
+
+	return result
+}
@@ -0,0 +1,70 @@
+package gst_scrapper
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// defaultRetryPolicies are used for the error classes without a policy in `server.gst.retry`. Backoffs are in seconds
+var defaultRetryPolicies = map[constants.ScrapeErrorClass]config.RetryPolicy{
+	constants.TransientScrapeError:    {MaxAttempts: 3, Backoff: 5, MaxBackoff: 60, Multiplier: 2},
+	constants.CredentialScrapeError:   {MaxAttempts: 1},
+	constants.CaptchaScrapeError:      {MaxAttempts: 3, Backoff: 1, MaxBackoff: 5, Multiplier: 1},
+	constants.PortalDialogScrapeError: {MaxAttempts: 2, Backoff: 30, MaxBackoff: 30, Multiplier: 1},
+}
+
+func (s *GstScrapper) retryPolicy(class constants.ScrapeErrorClass) config.RetryPolicy {
+	var policy config.RetryPolicy
+
+	switch class {
+	case constants.TransientScrapeError:
+		policy = s.cfg.Server.Gst.Retry.Transient
+	case constants.CredentialScrapeError:
+		policy = s.cfg.Server.Gst.Retry.Credential
+	case constants.CaptchaScrapeError:
+		policy = s.cfg.Server.Gst.Retry.Captcha
+	case constants.PortalDialogScrapeError:
+		policy = s.cfg.Server.Gst.Retry.PortalDialog
+	default:
+		return config.RetryPolicy{MaxAttempts: 1}
+	}
+
+	if policy.MaxAttempts <= 0 {
+		return defaultRetryPolicies[class]
+	}
+
+	return policy
+}
+
+// backoff is the delay before retrying after the given (1 based) attempt failed
+func backoff(policy config.RetryPolicy, attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(policy.Backoff*time.Second) * math.Pow(multiplier, float64(attempt-1))
+
+	if policy.MaxBackoff > 0 {
+		delay = math.Min(delay, float64(policy.MaxBackoff*time.Second))
+	}
+
+	return time.Duration(delay)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
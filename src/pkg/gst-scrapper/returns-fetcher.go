@@ -0,0 +1,45 @@
+package gst_scrapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/common"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+)
+
+const (
+	RodFetcher     = "rod"
+	FixtureFetcher = "fixture"
+)
+
+// ReturnsFetcher fetches the GST profile and return status details of the given GSTs.
+// Every GST yields exactly one GstDetail on the returned channel, which is closed once all of them are done.
+// Cancelling the context stops the pending GSTs, which are reported back as cancelled. A common.PauseGate
+// carried by the context holds the GSTs not yet started until it is resumed.
+type ReturnsFetcher interface {
+	ScrapGstReturnsDetail(ctx context.Context, gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[GstDetail], error)
+}
+
+// NewReturnsFetcher returns the backend configured in `server.gst.fetcher`. Defaults to the rod (headless Chrome) scrapper,
+// which reuses the portal sessions kept in the store.
+func NewReturnsFetcher(cfg *config.Config, sessions SessionStore) ReturnsFetcher {
+	switch cfg.Server.Gst.Fetcher {
+	case FixtureFetcher:
+		return NewFixtureScrapper(cfg)
+	default:
+		return NewGstScrapper(cfg, sessions)
+	}
+}
+
+func cancelledDetail(gstin string, startedAt time.Time) GstDetail {
+	return GstDetail{
+		Gstin:     gstin,
+		Error:     NewScrapeError(constants.ScrapeCancelled, gstin, ""),
+		Cancelled: true,
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+	}
+}
@@ -0,0 +1,62 @@
+package gst_scrapper
+
+import (
+	"fmt"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// ScrapeError is why a GSTIN could not be scrapped
+type ScrapeError struct {
+	Code  constants.ScrapeErrorCode  `json:"code"`
+	Class constants.ScrapeErrorClass `json:"class"`
+	Gstin string                     `json:"gstin"`
+	// Message is meant for the end user
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+	// Reason is the technical detail of the failure, kept for the logs and the run history
+	Reason string `json:"-"`
+}
+
+type scrapeErrorKind struct {
+	class   constants.ScrapeErrorClass
+	message string
+}
+
+var scrapeErrorKinds = map[constants.ScrapeErrorCode]scrapeErrorKind{
+	constants.InvalidCredential:    {constants.CredentialScrapeError, "GST username or password is invalid. Please update GST credential for %s and try again."},
+	constants.PasswordExpired:      {constants.CredentialScrapeError, "GST credential needs to be changed for the GSTIN %s"},
+	constants.BankAccountNotLinked: {constants.PortalDialogScrapeError, "Bank account is not linked with GSTIN %s"},
+	constants.UnknownDialog:        {constants.PortalDialogScrapeError, "There is unknow dialog preventing the process to get gst details for GSTIN %s"},
+	constants.CaptchaNotSolved:     {constants.CaptchaScrapeError, "Could not get past the captcha of GST portal for GSTIN %s"},
+	constants.PortalError:          {constants.TransientScrapeError, "Something went wrong while getting gst details of GSTIN %s from GST portal"},
+	constants.GstinMismatch:        {constants.PermanentScrapeError, "GST credential of GSTIN %s belongs to another GSTIN"},
+	constants.NoRecordedResponse:   {constants.PermanentScrapeError, "No recorded response found for GSTIN %s"},
+	constants.ScrapeCancelled:      {"", "Scrapping cancelled for GSTIN %s"},
+}
+
+func NewScrapeError(code constants.ScrapeErrorCode, gstin string, reason string) *ScrapeError {
+	kind := scrapeErrorKinds[code]
+
+	return &ScrapeError{
+		Code:      code,
+		Class:     kind.class,
+		Gstin:     gstin,
+		Message:   fmt.Sprintf(kind.message, gstin),
+		Retryable: kind.class == constants.TransientScrapeError || kind.class == constants.CaptchaScrapeError || kind.class == constants.PortalDialogScrapeError,
+		Reason:    reason,
+	}
+}
+
+func (e *ScrapeError) Error() string {
+	if e.Reason == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s. %s", e.Message, e.Reason)
+}
+
+// NeedsAttention tells whether someone has to act on the GSTIN for it to be scrapped again
+func (e *ScrapeError) NeedsAttention() bool {
+	return e.Class == constants.CredentialScrapeError
+}
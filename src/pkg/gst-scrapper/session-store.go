@@ -0,0 +1,98 @@
+package gst_scrapper
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// SessionStore keeps the portal cookies of a logged in username, so the next scrape can skip the login
+type SessionStore interface {
+	// Load returns the cookies of the username when they are not yet expired
+	Load(username string) ([]*proto.NetworkCookie, bool)
+	Save(username string, cookies []*proto.NetworkCookie, expiresAt time.Time) error
+	Remove(username string) error
+}
+
+const defaultSessionTtl = 30
+
+// dashboardPath is where the portal lands after login
+const dashboardPath = "/services/auth/fowelcome"
+
+func (s *GstScrapper) loadSession(username string) ([]*proto.NetworkCookie, bool) {
+	if s.sessions == nil || username == "" {
+		return nil, false
+	}
+
+	return s.sessions.Load(username)
+}
+
+// resumeSession opens the dashboard with the cookies of an earlier login
+func (s *GstScrapper) resumeSession(page *rod.Page, gstin string, cookies []*proto.NetworkCookie) DashboardDetail {
+	base, err := url.Parse(s.cfg.Server.Gst.BaseUrl)
+
+	if err == nil {
+		err = rod.Try(func() {
+			page.MustSetCookies(proto.CookiesToParams(cookies)...).
+				MustNavigate(base.ResolveReference(&url.URL{Path: dashboardPath}).String()).
+				MustWaitLoad()
+		})
+	}
+
+	if err != nil {
+		return DashboardDetail{Error: NewScrapeError(constants.PortalError, gstin, fmt.Sprintf("Could not resume portal session. %s", err.Error()))}
+	}
+
+	dashboard := s.checkDashboardPage(page, gstin)
+	if dashboard.Landed {
+		s.logger.Infof("Reused portal session for GSTIN %s", gstin)
+	}
+
+	return dashboard
+}
+
+// saveSession keeps the cookies of the logged in page till the earliest of them expires, at most `server.gst.sessionTtl` minutes
+func (s *GstScrapper) saveSession(username string, page *rod.Page) {
+	if s.sessions == nil || username == "" {
+		return
+	}
+
+	cookies, err := page.Browser().GetCookies()
+	if err != nil {
+		s.logger.Errorf("Could not read portal cookies. %s", err.Error())
+		return
+	}
+
+	ttl := s.cfg.Server.Gst.SessionTtl
+	if ttl <= 0 {
+		ttl = defaultSessionTtl
+	}
+
+	expiresAt := time.Now().Add(ttl * time.Minute)
+	for _, cookie := range cookies {
+		// Session cookies have no expiry
+		if expires := cookie.Expires.Time(); cookie.Expires > 0 && expires.Before(expiresAt) {
+			expiresAt = expires
+		}
+	}
+
+	err = s.sessions.Save(username, cookies, expiresAt)
+	if err != nil {
+		s.logger.Errorf("Could not save portal session. %s", err.Error())
+	}
+}
+
+func (s *GstScrapper) removeSession(username string) {
+	if s.sessions == nil {
+		return
+	}
+
+	err := s.sessions.Remove(username)
+	if err != nil {
+		s.logger.Errorf("Could not remove portal session. %s", err.Error())
+	}
+}
@@ -0,0 +1,225 @@
+package gst_search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Table is the full text index of the GSTs, kept in sync with the GSTs and their addresses by triggers
+const Table = "gsts_search"
+
+// maxTerms caps the words of a query, each of them is a condition of the search
+const maxTerms = 8
+
+var term = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// gstColumns are the columns of a GST searched by, from the best ranked to the least
+var gstColumns = []string{"gstin", "name", "tradename", "email", "mobile_number", "sno", "fno"}
+
+// sqliteWeights rank a match in each of the columns of the SQLite index, the address is the last of them
+var sqliteWeights = []string{"10.0", "5.0", "5.0", "2.0", "2.0", "2.0", "2.0", "1.0"}
+
+// addressColumns make up the address of the principal place of business of a GST
+var addressColumns = []string{"bno", "flno", "bnm", "st", "loc", "locality", "land_mark", "city", "district", "stcd", "pncd"}
+
+// Terms returns the words of a query in lower case. Punctuation splits words the way the index does, so a
+// part of an email or a GSTIN can be looked up
+func Terms(q string) []string {
+	terms := term.FindAllString(strings.ToLower(q), -1)
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+
+	return terms
+}
+
+// Available tells whether the index has been created. SQLite has the index only when built with FTS5
+func Available(db *gorm.DB) bool {
+	return db.Migrator().HasTable(Table)
+}
+
+// Match returns a query of the ids of the GSTs matching all the terms as the start of a word, with the score
+// of the match. The higher the score the better the match. Without the index the columns of the GSTs are
+// matched with LIKE and every match scores the same
+func Match(db *gorm.DB, terms []string) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true})
+
+	if !Available(db) {
+		return like(query, terms)
+	}
+
+	if db.Dialector.Name() == "postgres" {
+		tsquery := strings.Join(terms, ":* & ") + ":*"
+		return query.Table(Table).
+			Select("id, ts_rank(document, to_tsquery('simple', ?)) AS score", tsquery).
+			Where("document @@ to_tsquery('simple', ?)", tsquery)
+	}
+
+	match := make([]string, 0, len(terms))
+	for _, t := range terms {
+		match = append(match, `"`+t+`"*`)
+	}
+
+	// bm25 is the lower the better
+	return query.Table(Table).
+		Select(fmt.Sprintf("rowid AS id, -bm25(%s, %s) AS score", Table, strings.Join(sqliteWeights, ", "))).
+		Where(Table+" MATCH ?", strings.Join(match, " "))
+}
+
+// like matches each of the terms in any of the columns of the GST or of its address, the way the index does
+func like(query *gorm.DB, terms []string) *gorm.DB {
+	query = query.Table("gsts").Select("id, 0 AS score")
+
+	for _, t := range terms {
+		pattern := "%" + t + "%"
+
+		conditions := make([]string, 0, len(gstColumns)+1)
+		values := make([]interface{}, 0, len(gstColumns)+len(addressColumns))
+		for _, column := range gstColumns {
+			conditions = append(conditions, "LOWER(gsts."+column+") LIKE ?")
+			values = append(values, pattern)
+		}
+
+		address := make([]string, 0, len(addressColumns))
+		for _, column := range addressColumns {
+			address = append(address, "LOWER(a."+column+") LIKE ?")
+			values = append(values, pattern)
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM permenant_addresses a WHERE a.gstin = gsts.gstin AND a.deleted_by IS NULL AND (%s))",
+			strings.Join(address, " OR ")))
+
+		query = query.Where(strings.Join(conditions, " OR "), values...)
+	}
+
+	return query
+}
+
+// Migrate creates the index of the database and its triggers, and indexes all the GSTs again. The triggers
+// are dropped first, a SQLite without FTS5 can not write a GST with triggers on an index it can not load
+func Migrate(db *gorm.DB) error {
+	if db.Dialector.Name() == "postgres" {
+		return exec(db, postgresStatements())
+	}
+
+	err := exec(db, sqliteDropTriggers())
+	if err != nil {
+		return err
+	}
+
+	return exec(db, sqliteStatements())
+}
+
+func exec(db *gorm.DB, statements []string) error {
+	for _, statement := range statements {
+		err := db.Exec(statement).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// documents selects the text indexed of the GSTs matching the condition on gsts g
+func documents(where string) string {
+	columns := make([]string, 0, len(gstColumns)+2)
+	columns = append(columns, "g.id")
+	for _, column := range gstColumns {
+		columns = append(columns, fmt.Sprintf("COALESCE(g.%s, '')", column))
+	}
+
+	address := make([]string, 0, len(addressColumns))
+	for _, column := range addressColumns {
+		address = append(address, fmt.Sprintf("COALESCE(a.%s, '')", column))
+	}
+	columns = append(columns, fmt.Sprintf(
+		"COALESCE((SELECT %s FROM permenant_addresses a WHERE a.gstin = g.gstin AND a.deleted_by IS NULL ORDER BY a.id DESC LIMIT 1), '')",
+		strings.Join(address, " || ' ' || ")))
+
+	return fmt.Sprintf("SELECT %s FROM gsts g WHERE %s", strings.Join(columns, ", "), where)
+}
+
+var sqliteTriggers = []string{
+	"gsts_search_insert", "gsts_search_update", "gsts_search_delete",
+	"gsts_search_address_insert", "gsts_search_address_update", "gsts_search_address_delete",
+}
+
+func sqliteDropTriggers() []string {
+	statements := make([]string, 0, len(sqliteTriggers))
+	for _, trigger := range sqliteTriggers {
+		statements = append(statements, "DROP TRIGGER IF EXISTS "+trigger)
+	}
+
+	return statements
+}
+
+func sqliteStatements() []string {
+	columns := strings.Join(append(append([]string{}, gstColumns...), "address"), ", ")
+	insert := func(where string) string {
+		return fmt.Sprintf("INSERT INTO %s (rowid, %s) %s;", Table, columns, documents(where))
+	}
+	refresh := func(gstins string) string {
+		return fmt.Sprintf("DELETE FROM %s WHERE rowid IN (SELECT id FROM gsts WHERE gstin IN (%s)); %s",
+			Table, gstins, insert("g.gstin IN ("+gstins+")"))
+	}
+
+	return []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3')", Table, columns),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_insert AFTER INSERT ON gsts BEGIN %s END", insert("g.id = new.id")),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_update AFTER UPDATE OF %s ON gsts BEGIN DELETE FROM %s WHERE rowid = old.id; %s END",
+			strings.Join(gstColumns, ", "), Table, insert("g.id = new.id")),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_delete AFTER DELETE ON gsts BEGIN DELETE FROM %s WHERE rowid = old.id; END", Table),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_address_insert AFTER INSERT ON permenant_addresses BEGIN %s END", refresh("new.gstin")),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_address_update AFTER UPDATE ON permenant_addresses BEGIN %s END", refresh("old.gstin, new.gstin")),
+		fmt.Sprintf("CREATE TRIGGER gsts_search_address_delete AFTER DELETE ON permenant_addresses BEGIN %s END", refresh("old.gstin")),
+		"DELETE FROM " + Table,
+		insert("1 = 1"),
+	}
+}
+
+func postgresStatements() []string {
+	// punctuation is replaced the way Terms splits the query, the parser keeps an email as a single word
+	text := func(columns ...string) string {
+		parts := make([]string, 0, len(columns))
+		for _, column := range columns {
+			parts = append(parts, "d."+column)
+		}
+		return fmt.Sprintf("to_tsvector('simple', regexp_replace(%s, '[^[:alnum:]]+', ' ', 'g'))", strings.Join(parts, " || ' ' || "))
+	}
+	document := fmt.Sprintf("setweight(%s, 'A') || setweight(%s, 'B') || setweight(%s, 'C')",
+		text("gstin", "name", "tradename"), text("email", "mobile_number", "sno", "fno"), text("address"))
+	columns := strings.Join(append(append([]string{"id"}, gstColumns...), "address"), ", ")
+
+	return []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id bigint PRIMARY KEY, gstin text NOT NULL, document tsvector NOT NULL)", Table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_document ON %s USING GIN (document)", Table, Table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_gstin ON %s (gstin)", Table, Table),
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION gsts_search_refresh(gstins text[]) RETURNS void LANGUAGE sql AS $$
+			DELETE FROM %s WHERE gstin = ANY(gstins);
+			INSERT INTO %s (id, gstin, document) SELECT d.id, d.gstin, %s FROM (%s) AS d (%s);
+		$$`, Table, Table, document, documents("g.gstin = ANY(gstins)"), columns),
+		`CREATE OR REPLACE FUNCTION gsts_search_sync() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				PERFORM gsts_search_refresh(ARRAY[NEW.gstin]);
+			ELSIF TG_OP = 'UPDATE' THEN
+				PERFORM gsts_search_refresh(ARRAY[OLD.gstin, NEW.gstin]);
+			ELSE
+				PERFORM gsts_search_refresh(ARRAY[OLD.gstin]);
+			END IF;
+			RETURN NULL;
+		END
+		$$`,
+		"DROP TRIGGER IF EXISTS gsts_search_sync ON gsts",
+		fmt.Sprintf("CREATE TRIGGER gsts_search_sync AFTER INSERT OR DELETE OR UPDATE OF %s ON gsts FOR EACH ROW EXECUTE FUNCTION gsts_search_sync()",
+			strings.Join(gstColumns, ", ")),
+		"DROP TRIGGER IF EXISTS gsts_search_sync ON permenant_addresses",
+		"CREATE TRIGGER gsts_search_sync AFTER INSERT OR UPDATE OR DELETE ON permenant_addresses FOR EACH ROW EXECUTE FUNCTION gsts_search_sync()",
+		"TRUNCATE " + Table,
+		"SELECT gsts_search_refresh(ARRAY(SELECT gstin FROM gsts))",
+	}
+}
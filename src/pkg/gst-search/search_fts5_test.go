@@ -0,0 +1,89 @@
+//go:build sqlite_fts5
+
+package gst_search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/data/models"
+)
+
+func TestMatchWithIndex(t *testing.T) {
+	db := newTestDb(t)
+
+	// the GSTs there before the index are indexed by the migration, the ones after by the triggers
+	err := db.Create(&models.Gst{Gstin: "33AKBPA6032B1Z6", Name: "Balaji Stores", Tradename: "Tirupati Mart"}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !Available(db) {
+		t.Fatal("index is not available")
+	}
+
+	err = db.Create(&models.Gst{Gstin: "33AOSPA7307Q1ZI", Name: "Arun Textiles", Email: "arun@traders.in"}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Create(&models.PermenantAddress{Gstin: "33AOSPA7307Q1ZI", St: "Kamaraj Road", City: "Tirupur", Pncd: "641604"}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(q string, want ...string) {
+		t.Helper()
+		if gstins := search(t, db, q); !slices.Equal(gstins, want) {
+			t.Errorf("search of %q = %v, want %v", q, gstins, want)
+		}
+	}
+
+	check("balaji", "33AKBPA6032B1Z6")
+	check("arun", "33AOSPA7307Q1ZI")
+	check("traders in", "33AOSPA7307Q1ZI")
+	check("kamaraj", "33AOSPA7307Q1ZI")
+	check("tirupur arun", "33AOSPA7307Q1ZI")
+	// a match in the name ranks above one in the address
+	check("tirup", "33AKBPA6032B1Z6", "33AOSPA7307Q1ZI")
+
+	// the index follows the changes of a GST
+	err = db.Model(&models.Gst{}).Where("gstin = ?", "33AOSPA7307Q1ZI").Update("name", "Kumar Agencies").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("textiles")
+	check("kumar", "33AOSPA7307Q1ZI")
+
+	// and of its address
+	err = db.Model(&models.PermenantAddress{}).Where("gstin = ?", "33AOSPA7307Q1ZI").Update("city", "Erode").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("tirupur")
+	check("erode", "33AOSPA7307Q1ZI")
+
+	err = db.Where("gstin = ?", "33AOSPA7307Q1ZI").Delete(&models.PermenantAddress{}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("erode")
+	check("kumar", "33AOSPA7307Q1ZI")
+
+	err = db.Where("gstin = ?", "33AOSPA7307Q1ZI").Delete(&models.Gst{}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("kumar")
+
+	// the migration can be run again
+	err = Migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("balaji", "33AKBPA6032B1Z6")
+}
@@ -0,0 +1,127 @@
+package gst_search
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestDb opens an in-memory database with a few GSTs and their addresses
+func newTestDb(t *testing.T) *gorm.DB {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := encryption.NewKeyring(&config.Config{Encryption: config.EncryptionConfig{MasterKey: key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.Register(keyring)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDb, _ := db.DB()
+		sqlDb.Close()
+	})
+
+	err = db.AutoMigrate(&models.Gst{}, &models.PermenantAddress{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func seed(t *testing.T, db *gorm.DB) {
+	err := db.Create(&[]models.Gst{
+		{Gstin: "33AOSPA7307Q1ZI", Name: "Arun Traders", Email: "arun@traders.in"},
+		{Gstin: "33AKBPA6032B1Z6", Name: "Balaji Stores", Tradename: "Tirupati Mart"},
+		{Gstin: "29AAACB1234C1Z5", Name: "Chennai Silks"},
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Create(&[]models.PermenantAddress{
+		{Gstin: "33AOSPA7307Q1ZI", St: "Kamaraj Road", City: "Tirupur", Pncd: "641604"},
+		{Gstin: "29AAACB1234C1Z5", St: "Gandhi Street", City: "Tirupur", Pncd: "641601",
+			BaseModel: models.BaseModel{DeletedBy: &sql.NullInt64{Int64: 1, Valid: true}}},
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// search returns the GSTINs matching the query, best match first
+func search(t *testing.T, db *gorm.DB, q string) []string {
+	var gstins []string
+	err := db.Table("gsts").
+		Joins("JOIN (?) AS matches ON matches.id = gsts.id", Match(db, Terms(q))).
+		Order("matches.score DESC, gsts.id").
+		Pluck("gsts.gstin", &gstins).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gstins
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		q     string
+		terms []string
+	}{
+		{"Arun Traders", []string{"arun", "traders"}},
+		{"arun@traders.in", []string{"arun", "traders", "in"}},
+		{"  %_' OR 1=1 -- ", []string{"or", "1", "1"}},
+		{"", nil},
+		{"a b c d e f g h i j", []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+	}
+
+	for _, test := range tests {
+		if terms := Terms(test.q); !slices.Equal(terms, test.terms) {
+			t.Errorf("terms of %q = %v, want %v", test.q, terms, test.terms)
+		}
+	}
+}
+
+// The index is not created, the GSTs are searched with LIKE as on a SQLite without FTS5
+func TestMatchWithoutIndex(t *testing.T) {
+	db := newTestDb(t)
+	seed(t, db)
+
+	if Available(db) {
+		t.Fatal("index is available")
+	}
+
+	tests := []struct {
+		q      string
+		gstins []string
+	}{
+		{"arun", []string{"33AOSPA7307Q1ZI"}},
+		{"33akbp", []string{"33AKBPA6032B1Z6"}},
+		{"traders.in", []string{"33AOSPA7307Q1ZI"}},
+		{"tirup", []string{"33AOSPA7307Q1ZI", "33AKBPA6032B1Z6"}},
+		{"kamaraj", []string{"33AOSPA7307Q1ZI"}},
+		{"641604", []string{"33AOSPA7307Q1ZI"}},
+		{"tirupur arun", []string{"33AOSPA7307Q1ZI"}},
+		{"gandhi", nil},
+		{"tirupur chennai", nil},
+	}
+
+	for _, test := range tests {
+		if gstins := search(t, db, test.q); !slices.Equal(gstins, test.gstins) {
+			t.Errorf("search of %q = %v, want %v", test.q, gstins, test.gstins)
+		}
+	}
+}
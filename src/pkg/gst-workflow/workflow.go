@@ -0,0 +1,70 @@
+package gst_workflow
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jaganathanb/dapps-api/constants"
+)
+
+// Steps are the statuses a return moves through, in order, till it is filed
+var steps = map[constants.GstReturnType][]constants.GstReturnStatus{
+	constants.GSTR1:  {constants.CallForInvoice, constants.InvoiceReceived, constants.InvoiceEntry, constants.Filed},
+	constants.GSTR3B: {constants.TaxPayable, constants.CustomerIntimated, constants.TaxAmountReceived, constants.Filed},
+}
+
+// Steps returns the statuses of the workflow of the return type. The return types without a workflow
+// of their own follow the one of GSTR-1
+func Steps(returnType constants.GstReturnType) []constants.GstReturnStatus {
+	if s, ok := steps[returnType]; ok {
+		return s
+	}
+
+	return steps[constants.GSTR1]
+}
+
+// InitialStatus is the status a return period starts in
+func InitialStatus(returnType constants.GstReturnType) constants.GstReturnStatus {
+	return Steps(returnType)[0]
+}
+
+// NextStatus returns the status following the current one, false when the return is already filed
+func NextStatus(returnType constants.GstReturnType, current constants.GstReturnStatus) (constants.GstReturnStatus, bool) {
+	s := Steps(returnType)
+
+	i := slices.Index(s, current)
+	if i < 0 || i == len(s)-1 {
+		return "", false
+	}
+
+	return s[i+1], true
+}
+
+// ValidateTransition tells whether a return of the type can move from the status to the other.
+// A return only moves to the step next to its current one
+func ValidateTransition(returnType constants.GstReturnType, from, to constants.GstReturnStatus) error {
+	s := Steps(returnType)
+
+	if !slices.Contains(s, to) {
+		return fmt.Errorf("%s is not a status of %s, it goes %v", to, returnType, s)
+	}
+
+	// a return without a status of the workflow yet starts it
+	if !slices.Contains(s, from) {
+		from = s[0]
+		if to == from {
+			return nil
+		}
+	}
+
+	next, ok := NextStatus(returnType, from)
+	if !ok {
+		return fmt.Errorf("%s is %s already, it can not move to %s", returnType, from, to)
+	}
+
+	if to != next {
+		return fmt.Errorf("%s can only move from %s to %s, not to %s", returnType, from, next, to)
+	}
+
+	return nil
+}
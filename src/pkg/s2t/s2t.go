@@ -2,7 +2,8 @@ package s2t
 
 import (
 	"context"
-	"os"
+	"errors"
+	"io"
 	"sync"
 
 	"github.com/AssemblyAI/assemblyai-go-sdk"
@@ -29,28 +30,18 @@ func NewDAppsSpeechToText(cfg *config.Config) *DAppsSpeechToText {
 	return speechService
 }
 
-func (s *DAppsSpeechToText) SpeechToText(filePath string) (string, error) {
-	apiKey := s.cfg.AssemblyAI.ApiKey
+func (s *DAppsSpeechToText) SpeechToText(ctx context.Context, audio io.Reader) (string, error) {
+	client := assemblyai.NewClient(s.cfg.AssemblyAI.ApiKey)
 
-	ctx := context.Background()
-
-	s.logger.Debugf("The assembly AI token is - %s", apiKey)
-
-	client := assemblyai.NewClient(apiKey)
-
-	f, err := os.Open(filePath)
+	transcript, err := client.Transcripts.TranscribeFromReader(ctx, audio, nil)
 	if err != nil {
-		s.logger.Errorf("Couldn't open audio file:", err)
+		s.logger.Errorf("Something bad happened:", err)
 
 		return "", err
 	}
-	defer f.Close()
 
-	transcript, err := client.Transcripts.TranscribeFromReader(ctx, f, nil)
-	if err != nil {
-		s.logger.Errorf("Something bad happened:", err)
-
-		return "", err
+	if transcript.Text == nil {
+		return "", errors.New("no text transcribed from the audio")
 	}
 
 	return *transcript.Text, nil
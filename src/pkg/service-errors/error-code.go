@@ -25,4 +25,33 @@ const (
 	GstNotFound = "GST %s does not exists in the system"
 	GstExists   = "GST %s already exists in the system"
 	GstsExists  = "GSTs %s already exists in the system"
+
+	// Captcha
+	CaptchaNotManual = "Captcha is not solved manually"
+	CaptchaNotFound  = "Captcha is not waiting for an answer"
+
+	// Scrape runs
+	ScrapeRunNotActive = "Scrape run is not in progress"
+
+	// Calendar
+	InvalidFinancialYear = "Financial year is not valid"
+	InvalidReturnPeriod  = "Return period is not valid"
+
+	// Workflow
+	InvalidStatusTransition = "Return can not move to the status from its current one"
+	StatusChanged           = "Return status was changed meanwhile, reload and try again"
+
+	// Filter
+	InvalidFilter = "Filter is not valid"
+	InvalidCursor = "Cursor is not valid"
+
+	// Import
+	UnsupportedImportFile = "Only CSV and XLSX files can be imported"
+	InvalidImportMapping  = "Column mapping is not valid"
+	EmptyImportFile       = "File has no rows to import"
+	InvalidImportRows     = "Some of the rows are not valid, nothing is imported"
+
+	// Saved views
+	SavedViewExists = "A view with the name already exists"
+	RoleNotFound    = "Role does not exist"
 )
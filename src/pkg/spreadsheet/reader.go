@@ -0,0 +1,108 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format of a spreadsheet file
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	// PDF is only written, it can not be read
+	PDF Format = "pdf"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// FormatOf tells the format of the file by its extension
+func FormatOf(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	case ".pdf":
+		return PDF, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// ReadRows reads all the rows of the file with the cells trimmed. The first sheet of a workbook is read
+// unless another one is named
+func ReadRows(r io.Reader, format Format, sheet string) ([][]string, error) {
+	var rows [][]string
+	var err error
+
+	switch format {
+	case CSV:
+		rows, err = readCsv(r)
+	case XLSX:
+		rows, err = readXlsx(r, sheet)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+
+	return rows, nil
+}
+
+func readCsv(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	// rows of a sheet saved as CSV do not always have all the columns
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// Excel saves CSV as UTF-8 with a byte order mark
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+
+	return rows, nil
+}
+
+func readXlsx(r io.Reader, sheet string) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sheet == "" {
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return [][]string{}, nil
+		}
+		sheet = sheets[0]
+	}
+
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, fmt.Errorf("sheet %s does not exist in the workbook", sheet)
+	}
+
+	// raw values keep the long numbers like mobile numbers from being formatted
+	return f.GetRows(sheet, excelize.Options{RawCellValue: true})
+}
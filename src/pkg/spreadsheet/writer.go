@@ -0,0 +1,270 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Table is a sheet to write, every row has a cell for each header
+type Table struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+// ContentType is the media type of a file of the format
+func ContentType(format Format) string {
+	switch format {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/pdf"
+	}
+
+	return "text/csv"
+}
+
+// Write writes the table to w as a file of the format
+func Write(w io.Writer, format Format, table Table) error {
+	switch format {
+	case CSV:
+		return writeCsv(w, table)
+	case XLSX:
+		return writeXlsx(w, table)
+	case PDF:
+		return writePdf(w, table)
+	}
+
+	return ErrUnsupportedFormat
+}
+
+func writeCsv(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(table.Headers)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range table.Rows {
+		err = writer.Write(escapeFormulas(row))
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func writeXlsx(w io.Writer, table Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Sheet1"
+	if table.Title != "" {
+		sheet = table.Title
+		err := f.SetSheetName("Sheet1", sheet)
+		if err != nil {
+			return err
+		}
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"4472C4"}},
+		Alignment: &excelize.Alignment{Vertical: "center", WrapText: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	cellStyle, err := f.NewStyle(&excelize.Style{Alignment: &excelize.Alignment{Vertical: "top", WrapText: true}})
+	if err != nil {
+		return err
+	}
+
+	err = f.SetSheetRow(sheet, "A1", &table.Headers)
+	if err != nil {
+		return err
+	}
+
+	// the cells are written as strings, a spreadsheet never runs them as a formula
+	for i, row := range table.Rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		err = f.SetSheetRow(sheet, cell, &row)
+		if err != nil {
+			return err
+		}
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(table.Headers))
+	lastCell, _ := excelize.CoordinatesToCellName(len(table.Headers), len(table.Rows)+1)
+
+	err = f.SetCellStyle(sheet, "A1", lastCol+"1", headerStyle)
+	if err != nil {
+		return err
+	}
+
+	if len(table.Rows) > 0 {
+		err = f.SetCellStyle(sheet, "A2", lastCell, cellStyle)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, width := range columnWidths(table, 60) {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		err = f.SetColWidth(sheet, col, col, width+2)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the header stays in sight while scrolling and filters the rows like the grid
+	err = f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	if err != nil {
+		return err
+	}
+
+	err = f.AutoFilter(sheet, "A1:"+lastCell, nil)
+	if err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
+
+const (
+	pdfFontSize   = 7
+	pdfLineHeight = 3.5
+	pdfCellPad    = 1
+)
+
+func writePdf(w io.Writer, table Table) error {
+	pdf := fpdf.New("L", "mm", "A3", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+
+	// the columns share the width of the page by the length of their content
+	weights := columnWidths(table, 40)
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	widths := make([]float64, len(weights))
+	for i, weight := range weights {
+		widths[i] = (pageWidth - left - right) * weight / total
+	}
+
+	row := func(cells []string, fill bool) {
+		lines := make([][]string, len(cells))
+		height := 0.0
+		for i, cell := range cells {
+			lines[i] = pdf.SplitText(tr(cell), widths[i]-2*pdfCellPad)
+			height = max(height, float64(max(len(lines[i]), 1))*pdfLineHeight+2*pdfCellPad)
+		}
+
+		if pdf.GetY()+height > pageHeight-bottom {
+			pdf.AddPage()
+		}
+
+		x, y := left, pdf.GetY()
+		for i := range cells {
+			style := "D"
+			if fill {
+				style = "FD"
+			}
+			pdf.Rect(x, y, widths[i], height, style)
+
+			for j, line := range lines[i] {
+				pdf.SetXY(x+pdfCellPad, y+pdfCellPad+float64(j)*pdfLineHeight)
+				pdf.CellFormat(widths[i]-2*pdfCellPad, pdfLineHeight, line, "", 0, "L", false, 0, "")
+			}
+
+			x += widths[i]
+		}
+
+		pdf.SetXY(left, y+height)
+	}
+
+	// every page starts with the title and the header
+	pdf.SetHeaderFunc(func() {
+		pdf.SetY(top)
+		if table.Title != "" {
+			pdf.SetFont("Helvetica", "B", 12)
+			pdf.CellFormat(0, 8, tr(table.Title), "", 1, "L", false, 0, "")
+		}
+
+		pdf.SetFont("Helvetica", "B", pdfFontSize)
+		pdf.SetFillColor(230, 230, 230)
+		row(table.Headers, true)
+		pdf.SetFont("Helvetica", "", pdfFontSize)
+	})
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetXY(left, pageHeight-bottom+2)
+		pdf.SetFont("Helvetica", "", pdfFontSize)
+		pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	for _, cells := range table.Rows {
+		row(cells, false)
+	}
+
+	return pdf.Output(w)
+}
+
+// formulaPrefixes start a formula when a spreadsheet opens the file
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormulas quotes the cells of a CSV a spreadsheet would run as a formula, the names and emails come
+// from the portal and from imported files. Numbers are kept as they are
+func escapeFormulas(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		escaped[i] = cell
+		if cell == "" || !strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+			continue
+		}
+
+		if _, err := strconv.ParseFloat(cell, 64); err != nil {
+			escaped[i] = "'" + cell
+		}
+	}
+
+	return escaped
+}
+
+// columnWidths returns the width of each column in characters, the longest cell capped to limit
+func columnWidths(table Table, limit int) []float64 {
+	widths := make([]float64, len(table.Headers))
+
+	for i, header := range table.Headers {
+		widths[i] = float64(min(max(utf8.RuneCountInString(header), 6), limit))
+	}
+
+	for _, row := range table.Rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], float64(min(utf8.RuneCountInString(cell), limit)))
+			}
+		}
+	}
+
+	return widths
+}
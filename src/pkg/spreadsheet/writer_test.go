@@ -0,0 +1,90 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var formulaTable = Table{
+	Title:   "GSTs",
+	Headers: []string{"Name", "Email", "Amount"},
+	Rows: [][]string{
+		{`=HYPERLINK("http://x","y")`, "@SUM(1,2)", "-500"},
+		{"+91 98765", "-cmd|' /C calc'!A0", "1e3"},
+		{"Arun Traders", "", "12.5"},
+	},
+}
+
+func TestWriteCsvEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, formulaTable); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Name", "Email", "Amount"},
+		{`'=HYPERLINK("http://x","y")`, "'@SUM(1,2)", "-500"},
+		{"'+91 98765", "'-cmd|' /C calc'!A0", "1e3"},
+		{"Arun Traders", "", "12.5"},
+	}
+	for i := range want {
+		if !slices.Equal(records[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestWriteXlsxKeepsValues(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, XLSX, formulaTable); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for i, row := range formulaTable.Rows {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+
+			got, err := f.GetCellValue("GSTs", cell)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != value {
+				t.Errorf("%s = %q, want %q", cell, got, value)
+			}
+
+			formula, err := f.GetCellFormula("GSTs", cell)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formula != "" {
+				t.Errorf("%s is the formula %q", cell, formula)
+			}
+		}
+	}
+}
+
+func TestWritePdf(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, PDF, formulaTable); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("file starts with %q, want a PDF", buf.Bytes()[:min(buf.Len(), 8)])
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+type AuditService struct {
+	logger   logging.Logger
+	cfg      *config.Config
+	database *gorm.DB
+}
+
+var auditService *AuditService
+var auditServiceOnce sync.Once
+
+func NewAuditService(cfg *config.Config) *AuditService {
+	auditServiceOnce.Do(func() {
+		auditService = &AuditService{
+			logger:   logging.NewLogger(cfg),
+			cfg:      cfg,
+			database: db.GetDb(),
+		}
+	})
+
+	return auditService
+}
+
+// auditLogRow is an audit log entry with the name of the user who made the change
+type auditLogRow struct {
+	models.AuditLog
+	Username string
+}
+
+// GetAuditLogs lists the audit log entries matching the filters, latest first
+func (s *AuditService) GetAuditLogs(req *dto.GetAuditLogsRequest) (*dto.PagedList[dto.AuditLog], error) {
+	if req.PageNumber == 0 {
+		req.PageNumber = 1
+	}
+
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	query := s.database.Model(&models.AuditLog{})
+
+	if req.Entity != "" {
+		query = query.Where("audit_logs.entity = ?", req.Entity)
+	}
+
+	if req.EntityId != "" {
+		query = query.Where("audit_logs.entity_id = ?", req.EntityId)
+	}
+
+	if req.Gstin != "" {
+		query = query.Where("audit_logs.gstin = ?", req.Gstin)
+	}
+
+	if req.Action != "" {
+		query = query.Where("audit_logs.action = ?", req.Action)
+	}
+
+	if req.ActorId != 0 {
+		query = query.Where("audit_logs.created_by = ?", req.ActorId)
+	}
+
+	if req.RequestId != "" {
+		query = query.Where("audit_logs.request_id = ?", req.RequestId)
+	}
+
+	if !req.From.IsZero() {
+		query = query.Where("audit_logs.created_at >= ?", req.From)
+	}
+
+	if !req.To.IsZero() {
+		// the whole day is included
+		query = query.Where("audit_logs.created_at < ?", req.To.AddDate(0, 0, 1))
+	}
+
+	var totalRows int64
+	err := query.Count(&totalRows).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	var rows []auditLogRow
+	err = query.
+		Select("audit_logs.*, users.username").
+		Joins("LEFT JOIN users ON users.id = audit_logs.created_by").
+		Order("audit_logs.id desc").
+		Offset((req.PageNumber - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&rows).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	items := lo.Map(rows, func(row auditLogRow, i int) dto.AuditLog { return prepareAuditLogDTO(row) })
+
+	return NewPagedList(&items, totalRows, req.PageNumber, int64(req.PageSize)), nil
+}
+
+func prepareAuditLogDTO(row auditLogRow) dto.AuditLog {
+	changes := map[string]dto.AuditChange{}
+	_ = json.Unmarshal([]byte(row.Changes), &changes)
+
+	return dto.AuditLog{
+		Id:        row.Id,
+		Entity:    row.Entity,
+		EntityId:  row.EntityId,
+		Gstin:     row.Gstin,
+		Action:    row.Action,
+		ActorId:   row.CreatedBy,
+		ActorName: row.Username,
+		RequestId: row.RequestId,
+		Changes:   changes,
+		CreatedAt: row.CreatedAt,
+	}
+}
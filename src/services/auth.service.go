@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"sync"
 
 	"github.com/jaganathanb/dapps-api/api/dto"
@@ -111,7 +112,7 @@ func (s *AuthService) LogoutByUsername(req *dto.LogoutByUsernameRequest) (bool,
 }
 
 // Register by username
-func (s *AuthService) RegisterByUsername(req *dto.RegisterUserByUsernameRequest) error {
+func (s *AuthService) RegisterByUsername(ctx context.Context, req *dto.RegisterUserByUsernameRequest) error {
 	u := models.User{Username: req.Username, FirstName: req.FirstName, LastName: req.LastName, Email: req.Email}
 
 	exists, err := s.existsByEmail(req.Email)
@@ -142,7 +143,7 @@ func (s *AuthService) RegisterByUsername(req *dto.RegisterUserByUsernameRequest)
 		return err
 	}
 
-	tx := s.database.Begin()
+	tx := s.database.WithContext(ctx).Begin()
 	err = tx.Create(&u).Error
 	if err != nil {
 		tx.Rollback()
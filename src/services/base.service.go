@@ -3,10 +3,8 @@ package services
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"math"
 	"reflect"
-	"strings"
 	"sync"
 	"time"
 
@@ -16,10 +14,13 @@ import (
 	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	dynamic_filter "github.com/jaganathanb/dapps-api/pkg/dynamic-filter"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 	"github.com/jaganathanb/dapps-api/pkg/metrics"
 	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type preload struct {
@@ -135,7 +136,7 @@ func (s *BaseService[T, Tc, Tu, Tr]) GetById(ctx context.Context, id int) (*Tr,
 }
 
 func (s *BaseService[T, Tc, Tu, Tr]) GetByFilter(ctx context.Context, req *dto.PaginationInputWithFilter) (*dto.PagedList[Tr], error) {
-	res, err := Paginate[T, Tr](req, s.Preloads, s.Database)
+	res, err := Paginate[T, Tr](req, s.Preloads, s.Database, s.Logger)
 	if err != nil {
 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(T)).String(), "GetByFilter", "Failed").Inc()
 		return nil, err
@@ -158,26 +159,30 @@ func NewPagedList[T any](items *[]T, count int64, pageNumber int, pageSize int64
 }
 
 // Paginate
-func Paginate[T any, Tr any](pagination *dto.PaginationInputWithFilter, preloads []preload, db *gorm.DB) (*dto.PagedList[Tr], error) {
+func Paginate[T any, Tr any](pagination *dto.PaginationInputWithFilter, preloads []preload, db *gorm.DB, logger logging.Logger) (*dto.PagedList[Tr], error) {
+	if pagination.IsKeyset() {
+		return paginateByKeyset[T, Tr](pagination, preloads, db, logger)
+	}
+
 	model := new(T)
 	var items *[]T
-	var rItems *[]Tr
-	db = Preload(db, preloads)
-	query := getQuery[T](&pagination.DynamicFilter)
-	sort := getSort[T](&pagination.DynamicFilter)
 
 	var totalRows int64 = 0
 
-	db.
+	err := db.
 		Model(model).
-		Where(query).
-		Count(&totalRows)
+		Scopes(filterScope[T](&pagination.DynamicFilter)).
+		Count(&totalRows).
+		Error
 
-	err := db.
-		Where(query).
+	if err != nil {
+		return nil, err
+	}
+
+	err = Preload(db, preloads).
+		Scopes(filterScope[T](&pagination.DynamicFilter), sortScope[T](&pagination.DynamicFilter)).
 		Offset(pagination.GetOffset()).
 		Limit(pagination.GetPageSize()).
-		Order(sort).
 		Find(&items).
 		Error
 
@@ -185,8 +190,83 @@ func Paginate[T any, Tr any](pagination *dto.PaginationInputWithFilter, preloads
 		return nil, err
 	}
 
+	rItems, err := toDTOs[T, Tr](items, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPagedList(rItems, totalRows, pagination.PageNumber, int64(pagination.PageSize)), err
+}
+
+// paginateByKeyset returns the page of items after the cursor, sorted by the sort of the filter and then by id.
+// The items are counted only when asked for
+func paginateByKeyset[T any, Tr any](pagination *dto.PaginationInputWithFilter, preloads []preload, db *gorm.DB, logger logging.Logger) (*dto.PagedList[Tr], error) {
+	model := new(T)
+	items := []T{}
+	pageSize := pagination.GetKeysetPageSize()
+
+	s, err := dynamic_filter.Parse(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := s.Keyset(s.Order(pagination.Sort))
+
+	query := Preload(db, preloads).
+		Scopes(filterScope[T](&pagination.DynamicFilter)).
+		Clauses(keyset.Order())
+
+	if pagination.Cursor != "" {
+		after, err := keyset.After(pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(after)
+	}
+
+	// one more item tells there is a page after this one
+	err = query.Limit(pageSize + 1).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := &dto.PagedList[Tr]{PageNumber: pagination.GetPageNumber(), HasPreviousPage: pagination.Cursor != ""}
+
+	if len(items) > pageSize {
+		items = items[:pageSize]
+		list.HasNextPage = true
+
+		list.NextCursor, err = keyset.Cursor(db, &items[pageSize-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pagination.WithCount {
+		err = db.
+			Model(model).
+			Scopes(filterScope[T](&pagination.DynamicFilter)).
+			Count(&list.TotalRows).
+			Error
+
+		if err != nil {
+			return nil, err
+		}
+
+		list.TotalPages = int(math.Ceil(float64(list.TotalRows) / float64(pageSize)))
+	}
+
+	list.Items, err = toDTOs[T, Tr](&items, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func toDTOs[T any, Tr any](items *[]T, logger logging.Logger) (*[]Tr, error) {
 	// special case for GST
-	switch any(*model).(type) {
+	switch any(*new(T)).(type) {
 	case models.Gst:
 		gsts := make([]Tr, 0)
 		for _, gst := range *items {
@@ -194,35 +274,44 @@ func Paginate[T any, Tr any](pagination *dto.PaginationInputWithFilter, preloads
 			gsts = append(gsts, nGst.(Tr))
 		}
 
-		rItems = &gsts
-		break
+		return &gsts, nil
 	default:
-		fmt.Println("Falling back to Type conversion")
-		rItems, err = common.TypeConverter[[]Tr](items)
+		logger.Debugf("Falling back to type conversion of %T", *new(T))
+		return common.TypeConverter[[]Tr](items)
 	}
+}
 
-	if err != nil {
-		return nil, err
-	}
+// FindByFilter returns all the items matching the filter in its sort order, filtered the way Paginate does
+func FindByFilter[T any](filter *dto.DynamicFilter, preloads []preload, db *gorm.DB) ([]T, error) {
+	items := []T{}
 
-	return NewPagedList(rItems, totalRows, pagination.PageNumber, int64(pagination.PageSize)), err
+	err := Preload(db, preloads).
+		Scopes(filterScope[T](filter), sortScope[T](filter)).
+		Find(&items).
+		Error
+
+	return items, err
 }
 
 func prepareGstDTO(data models.Gst) dto.GetGstResponse {
 	return dto.GetGstResponse{
-		Fno:              data.Fno,
-		Sno:              data.Sno,
-		Gstin:            data.Gstin,
-		Name:             data.Name,
-		TradeName:        data.Tradename,
-		RegistrationDate: data.RegistrationDate,
-		Type:             data.Type,
-		LastUpdateDate:   data.LastUpdateDate,
-		Locked:           data.Locked,
-		MobileNumber:     data.MobileNumber,
-		Username:         data.Username,
-		Password:         data.Password,
-		GstStatuses:      prepareGstStatusDTO(data.GstStatuses),
+		Fno:                   data.Fno,
+		Sno:                   data.Sno,
+		Gstin:                 data.Gstin,
+		Name:                  data.Name,
+		TradeName:             data.Tradename,
+		RegistrationDate:      data.RegistrationDate,
+		Type:                  data.Type,
+		LastUpdateDate:        data.LastUpdateDate,
+		Locked:                data.Locked,
+		LockReason:            data.LockReason,
+		MobileNumber:          data.MobileNumber,
+		FilingFrequency:       frequencyOf(data),
+		FilingFrequencyManual: data.FilingFrequencyManual,
+		Turnover:              data.Turnover,
+		Username:              data.Username,
+		Password:              encryption.MaskValue(data.Password),
+		GstStatuses:           prepareGstStatusDTO(data.GstStatuses),
 		PermenantAddress: dto.PermenantAddress{
 			Street:   data.Pradr.St,
 			Locality: data.Pradr.Loc,
@@ -250,79 +339,70 @@ func prepareGstStatusDTO(gstStatuses []models.GstStatus) []dto.GstStatus {
 			Status:         status.Status,
 			Notes:          status.Notes,
 			PendingReturns: status.PendingReturns,
+			DueDate:        status.DueDate,
 		})
 	}
 
 	return returns
 }
 
-// getQuery
-func getQuery[T any](filter *dto.DynamicFilter) string {
-	t := new(T)
-	typeT := reflect.TypeOf(*t)
-	query := make([]string, 0)
-	query = append(query, "deleted_by is null AND status = 'Active'")
-	if filter.Filter != nil {
-		for name, filter := range filter.Filter {
-			fld, ok := typeT.FieldByName(name)
-			if ok {
-				fld.Name = common.ToSnakeCase(fld.Name)
-				switch filter.Type {
-				case "contains":
-					query = append(query, fmt.Sprintf("%s Like '%%%s%%'", fld.Name, filter.From))
-				case "notContains":
-					query = append(query, fmt.Sprintf("%s NOT Like '%%%s%%'", fld.Name, filter.From))
-				case "startsWith":
-					query = append(query, fmt.Sprintf("%s Like '%s%%'", fld.Name, filter.From))
-				case "endsWith":
-					query = append(query, fmt.Sprintf("%s Like '%%%s'", fld.Name, filter.From))
-				case "equals":
-					query = append(query, fmt.Sprintf("%s = '%s'", fld.Name, filter.From))
-				case "notEqual":
-					query = append(query, fmt.Sprintf("%s != '%s'", fld.Name, filter.From))
-				case "lessThan":
-					query = append(query, fmt.Sprintf("%s < %s", fld.Name, filter.From))
-				case "lessThanOrEqual":
-					query = append(query, fmt.Sprintf("%s <= %s", fld.Name, filter.From))
-				case "greaterThan":
-					query = append(query, fmt.Sprintf("%s > %s", fld.Name, filter.From))
-				case "greaterThanOrEqual":
-					query = append(query, fmt.Sprintf("%s >= %s", fld.Name, filter.From))
-				case "inRange":
-					query = appendInRange(fld, query, filter)
-				}
-			}
+// filterScope filters T by the dynamic filter within the default scope of T, the values are bound as
+// parameters. The rows deleted are never listed
+func filterScope[T any](filter *dto.DynamicFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		model := new(T)
+
+		s, err := dynamic_filter.Parse(db, model)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+
+		exprs, err := s.Compile(filter.Filter)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+
+		where, err := s.CompileTree(filter.Where)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		if where != nil {
+			exprs = append(exprs, where)
 		}
-	}
-	return strings.Join(query, " AND ")
-}
 
-func appendInRange(fld reflect.StructField, query []string, filter dto.Filter) []string {
-	if fld.Type.Kind() == reflect.String {
-		query = append(query, fmt.Sprintf("%s >= '%s'", fld.Name, filter.From))
-		query = append(query, fmt.Sprintf("%s <= '%s'", fld.Name, filter.To))
-	} else {
-		query = append(query, fmt.Sprintf("%s >= %s", fld.Name, filter.From))
-		query = append(query, fmt.Sprintf("%s <= %s", fld.Name, filter.To))
+		db = db.Where(clause.Eq{Column: clause.Column{Table: s.Table(), Name: "deleted_by"}, Value: nil})
+
+		if scope, ok := any(model).(models.DefaultScope); ok {
+			db = scope.DefaultScope(db)
+		}
+
+		if len(exprs) > 0 {
+			db = db.Where(clause.And(exprs...))
+		}
+
+		return db
 	}
-	return query
 }
 
-// getSort
-func getSort[T any](filter *dto.DynamicFilter) string {
-	t := new(T)
-	typeT := reflect.TypeOf(*t)
-	sort := make([]string, 0)
-	if filter.Sort != nil {
-		for _, tp := range *filter.Sort {
-			fld, ok := typeT.FieldByName(tp.ColId)
-			if ok && (tp.Sort == "asc" || tp.Sort == "desc") {
-				fld.Name = common.ToSnakeCase(fld.Name)
-				sort = append(sort, fmt.Sprintf("%s %s", fld.Name, tp.Sort))
-			}
+// sortScope sorts T by the columns of the dynamic filter
+func sortScope[T any](filter *dto.DynamicFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		s, err := dynamic_filter.Parse(db, new(T))
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+
+		columns := s.Order(filter.Sort)
+		if len(columns) == 0 {
+			return db
 		}
+
+		return db.Clauses(clause.OrderBy{Columns: columns})
 	}
-	return strings.Join(sort, ", ")
 }
 
 // Preload
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"gorm.io/gorm"
+)
+
+func TestFilterScopeDefaultScope(t *testing.T) {
+	_, database := newFixtureGstService(t)
+
+	database.Create(&[]models.Gst{
+		{Gstin: "1", Name: "Active Traders", Status: "Active"},
+		{Gstin: "2", Name: "Cancelled Traders", Status: "Cancelled"},
+		{Gstin: "3", Name: "Deleted Traders", Status: "Active", BaseModel: models.BaseModel{DeletedBy: &sql.NullInt64{Int64: 1, Valid: true}}},
+		{Gstin: "4", Name: "Active Stores", Status: "Active"},
+	})
+	database.Create(&[]models.GstStatus{
+		{Gstin: "1", Rtntype: constants.GSTR1, Status: constants.Filed},
+		{Gstin: "1", Rtntype: constants.GSTR3B, Status: constants.TaxPayable},
+	})
+
+	tests := []struct {
+		name   string
+		filter dto.DynamicFilter
+		gstins []string
+	}{
+		{"active and not deleted", dto.DynamicFilter{}, []string{"1", "4"}},
+		{"filter within the scope", dto.DynamicFilter{Filter: map[string]dto.Filter{"Name": {Type: "contains", From: "traders"}}}, []string{"1"}},
+		{"scope is not overridden by a filter", dto.DynamicFilter{Filter: map[string]dto.Filter{"Status": {Type: "equals", From: "Cancelled"}}}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gsts := []models.Gst{}
+			err := database.Scopes(filterScope[models.Gst](&test.filter)).Order("gstin").Find(&gsts).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gstins := []string{}
+			for _, gst := range gsts {
+				gstins = append(gstins, gst.Gstin)
+			}
+
+			if !slices.Equal(gstins, test.gstins) {
+				t.Errorf("gstins = %v, want %v", gstins, test.gstins)
+			}
+		})
+	}
+
+	// the statuses of the returns are not the status of the GSTIN on the portal, they have no default scope
+	statuses := []models.GstStatus{}
+	err := database.Scopes(filterScope[models.GstStatus](&dto.DynamicFilter{})).Find(&statuses).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 {
+		t.Errorf("return statuses = %d, want 2", len(statuses))
+	}
+
+	stmt := database.Session(&gorm.Session{DryRun: true}).Scopes(filterScope[models.Gst](&dto.DynamicFilter{})).Find(&[]models.Gst{}).Statement
+	if !slices.Contains(stmt.Vars, interface{}("Active")) {
+		t.Errorf("vars = %v, want the status bound", stmt.Vars)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	gst_calendar "github.com/jaganathanb/dapps-api/pkg/gst-calendar"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+type CalendarService struct {
+	logger   logging.Logger
+	cfg      *config.Config
+	database *gorm.DB
+	calendar *gst_calendar.Calendar
+	mutex    sync.RWMutex
+}
+
+var calendarService *CalendarService
+var calendarServiceOnce sync.Once
+
+func NewCalendarService(cfg *config.Config) *CalendarService {
+	calendarServiceOnce.Do(func() {
+		calendarService = &CalendarService{
+			logger:   logging.NewLogger(cfg),
+			cfg:      cfg,
+			database: db.GetDb(),
+			calendar: gst_calendar.NewCalendar(nil),
+		}
+
+		err := calendarService.reload()
+		if err != nil {
+			calendarService.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		}
+	})
+
+	return calendarService
+}
+
+// Calendar returns the due date calendar with the extensions notified so far
+func (s *CalendarService) Calendar() *gst_calendar.Calendar {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.calendar
+}
+
+// GetDueDates lists the due dates of the returns of a GSTIN for a financial year
+func (s *CalendarService) GetDueDates(req *dto.GetDueDatesRequest) ([]dto.DueDate, error) {
+	fyEndYear, err := parseFinancialYear(req.Fy)
+	if err != nil {
+		return nil, err
+	}
+
+	// the frequency of the GSTIN unless asked for another
+	frequency := req.Frequency
+	if frequency == "" {
+		var gst models.Gst
+		err = s.database.Model(&models.Gst{}).Select("filing_frequency").Where("gstin = ?", req.Gstin).Limit(1).Find(&gst).Error
+		if err != nil {
+			s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+			return nil, err
+		}
+
+		frequency = frequencyOf(gst)
+	}
+
+	dues := s.Calendar().DueDates(req.Gstin, frequency, req.Composition, fyEndYear)
+
+	return lo.Map(dues, func(due gst_calendar.DueDate, i int) dto.DueDate {
+		d := dto.DueDate{
+			ReturnType:   due.ReturnType,
+			ReturnPeriod: due.Period.Format(constants.TAXPRD),
+			DueDate:      due.DueDate,
+		}
+
+		if due.Extension != nil {
+			d.Extended = true
+			d.Notification = due.Extension.Notification
+		}
+
+		return d
+	}), nil
+}
+
+// GetExtensions lists the due date extensions, latest period first
+func (s *CalendarService) GetExtensions() ([]dto.DueDateExtension, error) {
+	var extensions []models.DueDateExtension
+
+	err := s.database.Model(&models.DueDateExtension{}).Order("due_date desc").Find(&extensions).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return lo.Map(extensions, func(ext models.DueDateExtension, i int) dto.DueDateExtension {
+		return prepareDueDateExtensionDTO(ext)
+	}), nil
+}
+
+// CreateExtension records a due date extension notified by the government
+func (s *CalendarService) CreateExtension(req *dto.CreateDueDateExtensionRequest) (*dto.DueDateExtension, error) {
+	_, err := time.Parse(constants.TAXPRD, req.ReturnPeriod)
+	if err != nil {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidReturnPeriod, Err: err}
+	}
+
+	extension := &models.DueDateExtension{
+		ReturnType:   req.ReturnType,
+		RetPrd:       req.ReturnPeriod,
+		StateCodes:   req.StateCodes,
+		DueDate:      req.DueDate,
+		Notification: req.Notification,
+		BaseModel:    models.BaseModel{CreatedBy: req.CreatedBy},
+	}
+
+	err = s.database.Create(extension).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+		return nil, err
+	}
+
+	err = s.reload()
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+	}
+
+	ext := prepareDueDateExtensionDTO(*extension)
+
+	return &ext, nil
+}
+
+// DeleteExtension removes a due date extension, the statutory due date applies again
+func (s *CalendarService) DeleteExtension(id int) (bool, error) {
+	result := s.database.Delete(&models.DueDateExtension{}, id)
+	if result.Error != nil {
+		s.logger.Error(logging.Sqlite3, logging.Delete, result.Error.Error(), nil)
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+
+	err := s.reload()
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+	}
+
+	return true, nil
+}
+
+func (s *CalendarService) reload() error {
+	var extensions []models.DueDateExtension
+
+	err := s.database.Model(&models.DueDateExtension{}).Find(&extensions).Error
+	if err != nil {
+		return err
+	}
+
+	calendar := gst_calendar.NewCalendar(lo.FilterMap(extensions, func(ext models.DueDateExtension, i int) (gst_calendar.Extension, bool) {
+		period, err := time.Parse(constants.TAXPRD, ext.RetPrd)
+
+		return gst_calendar.Extension{
+			ReturnType:   ext.ReturnType,
+			Period:       period,
+			StateCodes:   ext.StateCodes,
+			DueDate:      ext.DueDate,
+			Notification: ext.Notification,
+		}, err == nil
+	}))
+
+	s.mutex.Lock()
+	s.calendar = calendar
+	s.mutex.Unlock()
+
+	return nil
+}
+
+func prepareDueDateExtensionDTO(ext models.DueDateExtension) dto.DueDateExtension {
+	return dto.DueDateExtension{
+		Id:           ext.Id,
+		ReturnType:   ext.ReturnType,
+		ReturnPeriod: ext.RetPrd,
+		StateCodes:   ext.StateCodes,
+		DueDate:      ext.DueDate,
+		Notification: ext.Notification,
+	}
+}
+
+// parseFinancialYear returns the year the financial year given as 2024-25 or 2024-2025 ends in,
+// the current financial year when it is empty
+func parseFinancialYear(fy string) (int, error) {
+	if fy == "" {
+		return gst_calendar.PeriodOf(constants.GSTR9, constants.MonthlyFiling, time.Now()).Year(), nil
+	}
+
+	years := strings.Split(fy, "-")
+	if len(years) != 2 {
+		return 0, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidFinancialYear}
+	}
+
+	start, err := strconv.Atoi(years[0])
+	if err != nil {
+		return 0, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidFinancialYear, Err: err}
+	}
+
+	// the portal names it 2024-2025
+	if years[1] != fmt.Sprintf("%02d", (start+1)%100) && years[1] != strconv.Itoa(start+1) {
+		return 0, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidFinancialYear}
+	}
+
+	return start + 1, nil
+}
@@ -2,25 +2,36 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"math"
+	"regexp"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/common"
 	"github.com/jaganathanb/dapps-api/config"
 	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/audit"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	gst_calendar "github.com/jaganathanb/dapps-api/pkg/gst-calendar"
 	gst_scrapper "github.com/jaganathanb/dapps-api/pkg/gst-scrapper"
+	gst_search "github.com/jaganathanb/dapps-api/pkg/gst-search"
+	gst_workflow "github.com/jaganathanb/dapps-api/pkg/gst-workflow"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"github.com/jaganathanb/dapps-api/pkg/spreadsheet"
 	"github.com/jaganathanb/dapps-api/pkg/utils"
 	"github.com/samber/lo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GstService struct {
@@ -28,7 +39,10 @@ type GstService struct {
 	scrapperService      *ScrapperService
 	streamerService      *StreamerService
 	notificationsService *NotificationsService
+	scrapeRunService     *ScrapeRunService
+	calendarService      *CalendarService
 	scrapperRunning      []string
+	scrapperMutex        sync.Mutex
 }
 
 var gstService *GstService
@@ -50,22 +64,29 @@ func NewGstService(cfg *config.Config) *GstService {
 			scrapperService:      NewScrapperService(cfg),
 			streamerService:      NewStreamerService(cfg),
 			notificationsService: NewNotificationsService(cfg),
+			scrapeRunService:     NewScrapeRunService(cfg),
+			calendarService:      NewCalendarService(cfg),
 		}
 	})
 
 	return gstService
 }
 
-func (s *GstService) CreateGsts(req *dto.CreateGstsRequest) (string, error) {
+func (s *GstService) CreateGsts(ctx context.Context, req *dto.CreateGstsRequest) (string, error) {
 	exists, err := s.getExistingGstsInSystem(req.Gsts)
 	if err != nil {
 		return "", err
 	}
 
-	tx := s.base.Database.Begin()
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.CreatedBy)).Begin()
 
 	gsts := []dto.Gst{}
 	for _, v := range req.Gsts {
+		// a masked password sent back by the client keeps the stored one
+		if encryption.IsMasked(v.Password) {
+			v.Password = ""
+		}
+
 		payload := &models.Gst{
 			Sno:          v.Sno,
 			Fno:          v.Fno,
@@ -79,6 +100,11 @@ func (s *GstService) CreateGsts(req *dto.CreateGstsRequest) (string, error) {
 			Password:     v.Password,
 		}
 
+		if v.FilingFrequency != "" {
+			payload.FilingFrequency = v.FilingFrequency
+			payload.FilingFrequencyManual = true
+		}
+
 		if slices.Contains(exists, v.Gstin) {
 			err = tx.Updates(payload).Error
 			if err != nil {
@@ -101,16 +127,144 @@ func (s *GstService) CreateGsts(req *dto.CreateGstsRequest) (string, error) {
 
 	gstins := lo.Map(gsts, func(g dto.Gst, i int) string { return g.Gstin })
 
-	s.scrapGstPortal(req.CreatedBy, true)
+	s.scrapGstPortal(req.CreatedBy, true, constants.ManualTrigger)
 
 	return fmt.Sprintf("%s gst details already exists. %s gst details entered into the system.", exists, gstins), err
 }
 
+// ImportGsts reads the GSTs from the rows of a CSV or XLSX file and creates them like CreateGsts. The rows
+// are validated first, nothing is imported when one of them is not valid or on a dry run
+func (s *GstService) ImportGsts(ctx context.Context, req *dto.ImportGstsRequest) (*dto.GstImportResult, error) {
+	format, err := spreadsheet.FormatOf(req.File.Filename)
+	if err != nil {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.UnsupportedImportFile, Err: err}
+	}
+
+	file, err := req.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.ReadRows(file, format, req.Sheet)
+	if err != nil {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.UnsupportedImportFile, TechnicalMessage: err.Error(), Err: err}
+	}
+
+	if len(rows) < 2 {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.EmptyImportFile}
+	}
+
+	headers := rows[0]
+	mapping, err := importMapping(headers, req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	gsts, result := readImportRows(headers, mapping, rows[1:])
+	result.DryRun = req.DryRun
+
+	if result.TotalRows == 0 {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.EmptyImportFile}
+	}
+
+	if result.InvalidRows > 0 {
+		if req.DryRun {
+			return result, nil
+		}
+		return result, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportRows}
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	msg, err := s.CreateGsts(ctx, &dto.CreateGstsRequest{Gsts: gsts, BaseDto: dto.BaseDto{CreatedBy: req.CreatedBy}})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Imported = true
+	result.Message = msg
+
+	return result, nil
+}
+
 func (s *GstService) GetByFilter(ctx context.Context, req *dto.PaginationInputWithFilter) (*dto.PagedList[dto.GetGstResponse], error) {
 	return s.base.GetByFilter(ctx, req)
 }
 
-func (s *GstService) UpdateGstStatus(req *dto.UpdateGstReturnStatusRequest) (bool, error) {
+// ExportGsts returns the GSTs matching the filter of the grid, with the status of their returns, as a table
+// to export. The paging of the request is ignored
+func (s *GstService) ExportGsts(ctx context.Context, req *dto.ExportGstsRequest) (*spreadsheet.Table, error) {
+	gsts, err := FindByFilter[models.Gst](&req.DynamicFilter, s.base.Preloads, s.base.Database.WithContext(ctx))
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	table := gstExportTable(lo.Map(gsts, func(gst models.Gst, i int) dto.Gst { return prepareGstDTO(gst) }))
+
+	return &table, nil
+}
+
+type gstSearchMatch struct {
+	Id    int
+	Score float64
+}
+
+// SearchGsts looks the GSTs listed in the grid up by the words of the query, the best matches first. Every
+// word has to match the start of a word of the GSTIN, names, email, mobile number, Sno, Fno or address
+func (s *GstService) SearchGsts(ctx context.Context, req *dto.SearchGstsRequest) ([]dto.GstSearchResult, error) {
+	results := []dto.GstSearchResult{}
+
+	terms := gst_search.Terms(req.Q)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	database := s.base.Database.WithContext(ctx)
+
+	matches := []gstSearchMatch{}
+	err := database.Model(&models.Gst{}).
+		Scopes(filterScope[models.Gst](&dto.DynamicFilter{})).
+		Joins("JOIN (?) AS matches ON matches.id = gsts.id", gst_search.Match(database, terms)).
+		Select("gsts.id, matches.score").
+		Order("matches.score DESC, gsts.name").
+		Limit(req.GetLimit()).
+		Find(&matches).
+		Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return results, nil
+	}
+
+	gsts := []models.Gst{}
+	err = Preload(database, s.base.Preloads).
+		Where("id IN ?", lo.Map(matches, func(m gstSearchMatch, i int) int { return m.Id })).
+		Find(&gsts).
+		Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	byId := lo.KeyBy(gsts, func(gst models.Gst) int { return gst.Id })
+	for _, match := range matches {
+		if gst, ok := byId[match.Id]; ok {
+			results = append(results, dto.GstSearchResult{Gst: prepareGstDTO(gst), Score: match.Score})
+		}
+	}
+
+	return results, nil
+}
+
+// UpdateGstStatus moves the return of a GSTIN to the next status of its workflow and records the transition
+func (s *GstService) UpdateGstStatus(ctx context.Context, req *dto.UpdateGstReturnStatusRequest) (bool, error) {
 	exists, err := s.isGstExistsInSystem(req.Gstin)
 	if err != nil {
 		return false, err
@@ -120,9 +274,54 @@ func (s *GstService) UpdateGstStatus(req *dto.UpdateGstReturnStatusRequest) (boo
 		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
 	}
 
-	tx := s.base.Database.Begin()
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
+
+	var statuses []models.GstStatus
+	err = tx.Model(&models.GstStatus{}).Where("gstin = ? AND rtntype = ?", req.Gstin, req.ReturnType).Limit(1).Find(&statuses).Error
+	if err != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return false, err
+	}
+
+	if len(statuses) == 0 {
+		tx.Rollback()
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	current := statuses[0]
+
+	err = gst_workflow.ValidateTransition(req.ReturnType, current.Status, req.Status)
+	if err != nil {
+		tx.Rollback()
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidStatusTransition, TechnicalMessage: err.Error(), Err: err}
+	}
+
+	// The status validated against must still be the stored one, else a concurrent change would skip a workflow step
+	result := tx.Model(&models.GstStatus{}).Where("gstin = ? AND rtntype = ? AND status = ?", req.Gstin, req.ReturnType, current.Status).Updates(map[string]interface{}{
+		"status":      req.Status,
+		"modified_by": req.ModifiedBy,
+		"modified_at": time.Now(),
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, result.Error.Error(), nil)
+		return false, result.Error
+	}
 
-	err = tx.Model(&models.GstStatus{}).Where("gstin = ? AND rtntype = ?", req.Gstin, req.ReturnType).Update("status", req.Status).Error
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.StatusChanged}
+	}
+
+	err = tx.Create(&models.GstStatusTransition{
+		Gstin:      req.Gstin,
+		Rtntype:    req.ReturnType,
+		RetPrd:     current.RetPrd,
+		FromStatus: current.Status,
+		ToStatus:   req.Status,
+		Note:       req.Note,
+		BaseModel:  models.BaseModel{CreatedBy: req.ModifiedBy},
+	}).Error
 	if err != nil {
 		tx.Rollback()
 		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
@@ -132,13 +331,93 @@ func (s *GstService) UpdateGstStatus(req *dto.UpdateGstReturnStatusRequest) (boo
 	tx.Commit()
 
 	if (req.ReturnType == constants.GSTR1 && req.Status == constants.InvoiceEntry) || (req.ReturnType == constants.GSTR3B && req.Status == constants.TaxAmountReceived) {
-		go s.scrapGstPortal(req.ModifiedBy, false)
+		go s.scrapGstPortal(req.ModifiedBy, false, constants.StatusChangeTrigger)
 	}
 
 	return true, nil
 }
 
-func (s *GstService) LockGstById(req *dto.UpdateGstLockStatusRequest) (bool, error) {
+// gstStatusTransitionRow is a transition with the name of the user who made it
+type gstStatusTransitionRow struct {
+	models.GstStatusTransition
+	Username string
+}
+
+// GetGstStatusTransitions lists the workflow status changes of the returns of a GSTIN, latest first
+func (s *GstService) GetGstStatusTransitions(req *dto.GetGstStatusTransitionsRequest) ([]dto.GstStatusTransition, error) {
+	exists, err := s.isGstExistsInSystem(req.Gstin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	query := s.base.Database.Model(&models.GstStatusTransition{}).
+		Select("gst_status_transitions.*, users.username").
+		Joins("LEFT JOIN users ON users.id = gst_status_transitions.created_by").
+		Where("gst_status_transitions.gstin = ?", req.Gstin)
+
+	if req.ReturnType != "" {
+		query = query.Where("gst_status_transitions.rtntype = ?", req.ReturnType)
+	}
+
+	var transitions []gstStatusTransitionRow
+	err = query.Order("gst_status_transitions.created_at desc").Order("gst_status_transitions.id desc").Find(&transitions).Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return lo.Map(transitions, func(t gstStatusTransitionRow, i int) dto.GstStatusTransition {
+		return dto.GstStatusTransition{
+			ReturnType:    t.Rtntype,
+			ReturnPeriod:  t.RetPrd,
+			FromStatus:    t.FromStatus,
+			ToStatus:      t.ToStatus,
+			Note:          t.Note,
+			ChangedBy:     t.CreatedBy,
+			ChangedByName: t.Username,
+			ChangedAt:     t.CreatedAt,
+		}
+	}), nil
+}
+
+func (s *GstService) LockGstById(ctx context.Context, req *dto.UpdateGstLockStatusRequest) (bool, error) {
+	exists, err := s.isGstExistsInSystem(req.Gstin)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
+
+	// Unlocking clears the reason the GSTIN was locked for
+	reason := sql.NullString{String: req.Reason, Valid: req.Locked && req.Reason != ""}
+
+	err = tx.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Updates(map[string]interface{}{
+		"locked":      req.Locked,
+		"lock_reason": reason,
+		"modified_by": req.ModifiedBy,
+		"modified_at": time.Now(),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
+		return false, err
+	}
+
+	tx.Commit()
+
+	return true, nil
+}
+
+// UpdateGstFilingFrequency sets the filing frequency of a GSTIN manually, so the one detected from the portal
+// is not used. An empty frequency has it detected from the portal again
+func (s *GstService) UpdateGstFilingFrequency(ctx context.Context, req *dto.UpdateGstFilingFrequencyRequest) (bool, error) {
 	exists, err := s.isGstExistsInSystem(req.Gstin)
 	if err != nil {
 		return false, err
@@ -147,9 +426,18 @@ func (s *GstService) LockGstById(req *dto.UpdateGstLockStatusRequest) (bool, err
 		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
 	}
 
-	tx := s.base.Database.Begin()
+	values := map[string]interface{}{
+		"filing_frequency_manual": req.FilingFrequency != "",
+		"modified_by":             req.ModifiedBy,
+		"modified_at":             time.Now(),
+	}
+	if req.FilingFrequency != "" {
+		values["filing_frequency"] = req.FilingFrequency
+	}
+
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
 
-	err = tx.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Update("locked", req.Locked).Update("modified_at", time.Now()).Error
+	err = tx.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Updates(values).Error
 	if err != nil {
 		tx.Rollback()
 		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
@@ -161,7 +449,9 @@ func (s *GstService) LockGstById(req *dto.UpdateGstLockStatusRequest) (bool, err
 	return true, nil
 }
 
-func (s *GstService) DeleteGstById(req *dto.RemoveGstRequest) (bool, error) {
+// UpdateGstTurnover sets the aggregate turnover of the previous year of a GSTIN, the late fees are capped by it.
+// An empty turnover clears it
+func (s *GstService) UpdateGstTurnover(ctx context.Context, req *dto.UpdateGstTurnoverRequest) (bool, error) {
 	exists, err := s.isGstExistsInSystem(req.Gstin)
 	if err != nil {
 		return false, err
@@ -170,7 +460,80 @@ func (s *GstService) DeleteGstById(req *dto.RemoveGstRequest) (bool, error) {
 		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
 	}
 
-	tx := s.base.Database.Begin()
+	values := map[string]interface{}{
+		"turnover":    req.Turnover,
+		"modified_by": req.ModifiedBy,
+		"modified_at": time.Now(),
+	}
+
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
+
+	err = tx.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Updates(values).Error
+	if err != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
+		return false, err
+	}
+
+	tx.Commit()
+
+	return true, nil
+}
+
+// GetGstReturnFilings lists the returns filed by a GSTIN, latest period first, with how late they were filed
+func (s *GstService) GetGstReturnFilings(req *dto.GetGstReturnFilingsRequest) ([]dto.GstReturnFiling, error) {
+	gst := models.Gst{}
+	err := s.base.Database.Model(&models.Gst{}).Select("gstin", "filing_frequency").Where("gstin = ?", req.Gstin).Limit(1).Find(&gst).Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+	if gst.Gstin == "" {
+		return nil, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	query := s.base.Database.Model(&models.GstReturnFiling{}).Where("gstin = ?", req.Gstin)
+
+	if req.Fy != "" {
+		fyEndYear, err := parseFinancialYear(req.Fy)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Date(fyEndYear-1, time.April, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(fyEndYear, time.March, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("period BETWEEN ? AND ?", start, end)
+	}
+
+	if req.ReturnType != "" {
+		query = query.Where("rtntype = ?", req.ReturnType)
+	}
+
+	var filings []models.GstReturnFiling
+	err = query.Order("period desc").Order("rtntype").Find(&filings).Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	cal := s.calendarService.Calendar()
+	frequency := frequencyOf(gst)
+
+	return lo.Map(filings, func(filing models.GstReturnFiling, i int) dto.GstReturnFiling {
+		return prepareGstReturnFilingDTO(cal, filing, frequency)
+	}), nil
+}
+
+func (s *GstService) DeleteGstById(ctx context.Context, req *dto.RemoveGstRequest) (bool, error) {
+	exists, err := s.isGstExistsInSystem(req.Gstin)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	tx := s.base.Database.WithContext(audit.WithActor(ctx, req.DeletedBy)).Begin()
 
 	err = tx.Model(&models.GstStatus{}).Where("gstin = ?", req.Gstin).Delete(&models.GstStatus{Gstin: req.Gstin}).Error
 	if err != nil {
@@ -179,6 +542,19 @@ func (s *GstService) DeleteGstById(req *dto.RemoveGstRequest) (bool, error) {
 		return false, err
 	}
 
+	err = tx.Where("gstin = ?", req.Gstin).Delete(&models.GstReturnFiling{}).Error
+	if err != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
+		return false, err
+	}
+	err = tx.Where("gstin = ?", req.Gstin).Delete(&models.GstStatusTransition{}).Error
+	if err != nil {
+		tx.Rollback()
+		s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
+		return false, err
+	}
+
 	gst := &models.Gst{}
 	err = tx.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Find(gst).Error
 	if err != nil {
@@ -200,8 +576,11 @@ func (s *GstService) DeleteGstById(req *dto.RemoveGstRequest) (bool, error) {
 }
 
 func (s *GstService) GetGstStatistics() (dto.GstFiledCount, error) {
-	var gstins []string
-	s.base.Database.Model(&models.Gst{}).Where("locked = ? AND gsts.status = ?", false, "Active").Select("gstin").Find(&gstins)
+	var gsts []models.Gst
+	s.base.Database.Model(&models.Gst{}).Where("locked = ? AND gsts.status = ?", false, "Active").Select("gstin", "filing_frequency").Find(&gsts)
+
+	gstins := lo.Map(gsts, func(gst models.Gst, i int) string { return gst.Gstin })
+	frequencies := lo.SliceToMap(gsts, func(gst models.Gst) (string, constants.FilingFrequency) { return gst.Gstin, frequencyOf(gst) })
 
 	var gstFiledCount dto.GstFiledCount
 	var statuses []models.GstStatus
@@ -214,29 +593,73 @@ func (s *GstService) GetGstStatistics() (dto.GstFiledCount, error) {
 
 	group := lo.GroupBy(statuses, func(st models.GstStatus) constants.GstReturnType { return st.Rtntype })
 
-	currTxp := time.Now().Format(constants.TAXPRD)
-	gstFiledCount.GSTR1Count = getPendingReturnStatus(group[constants.GSTR1], currTxp, constants.GSTR1)
-	gstFiledCount.GSTR3BCount = getPendingReturnStatus(group[constants.GSTR3B], currTxp, constants.GSTR3B)
-	gstFiledCount.GSTR2Count = getPendingReturnStatus(group[constants.GSTR2], currTxp, constants.GSTR2)
-	gstFiledCount.GSTR9Count = getPendingReturnStatus(group[constants.GSTR9], currTxp, constants.GSTR9)
+	cal := s.calendarService.Calendar()
+	now := time.Now()
+
+	gstFiledCount.GSTR1Count, gstFiledCount.GSTR1OverdueCount = getPendingReturnStatus(cal, group[constants.GSTR1], frequencies, now)
+	gstFiledCount.GSTR3BCount, gstFiledCount.GSTR3BOverdueCount = getPendingReturnStatus(cal, group[constants.GSTR3B], frequencies, now)
+	gstFiledCount.GSTR2Count, _ = getPendingReturnStatus(cal, group[constants.GSTR2], frequencies, now)
+	gstFiledCount.GSTR9Count, gstFiledCount.GSTR9OverdueCount = getPendingReturnStatus(cal, group[constants.GSTR9], frequencies, now)
+
+	// IFF and PMT-06 are not part of the return status on the portal, the ones of the month just ended
+	// are counted as due till their due date passes
+	lastMonth := utils.StartOfMonth(now).AddDate(0, -1, 0)
+	for gstin, frequency := range frequencies {
+		if frequency != constants.QuarterlyFiling {
+			continue
+		}
+		gstFiledCount.QuarterlyGsts++
+
+		for _, due := range cal.QrmpObligations(gstin, lastMonth) {
+			if now.After(due.DueDate.AddDate(0, 0, 1)) {
+				continue
+			}
+
+			switch due.ReturnType {
+			case constants.IFF:
+				gstFiledCount.IFFDueCount++
+			case constants.PMT06:
+				gstFiledCount.PMT06DueCount++
+			}
+		}
+	}
 
 	gstFiledCount.TotalGsts = int64(len(gstins))
 
 	return gstFiledCount, err
 }
 
-func getPendingReturnStatus(statuses []models.GstStatus, currTxp string, retType constants.GstReturnType) int64 {
-	return int64(len(lo.Filter(statuses, func(st models.GstStatus, i int) bool {
-		return len(lo.Filter(st.PendingReturns, func(ss string, j int) bool { return ss != currTxp && st.Rtntype == retType })) > 0
-	})))
+// getPendingReturnStatus counts the GSTINs with returns pending and with returns past their due date
+func getPendingReturnStatus(cal *gst_calendar.Calendar, statuses []models.GstStatus, frequencies map[string]constants.FilingFrequency, now time.Time) (pending int64, overdue int64) {
+	for _, st := range statuses {
+		if len(st.PendingReturns) == 0 {
+			continue
+		}
+		pending++
+
+		isOverdue := lo.SomeBy(st.PendingReturns, func(retPrd string) bool {
+			period, err := time.Parse(constants.TAXPRD, retPrd)
+			return err == nil && cal.IsOverdue(st.Rtntype, frequencies[st.Gstin], st.Gstin, period, now)
+		})
+		if isOverdue {
+			overdue++
+		}
+	}
+
+	return pending, overdue
 }
 
 func (s *GstService) RefreshGstReturns(userId int) error {
-	go s.scrapGstPortal(userId, false)
+	go s.scrapGstPortal(userId, false, constants.ManualTrigger)
 
 	return nil
 }
 
+// ScrapGstPortalOnSchedule is the callback of the scheduled scrapping job
+func (s *GstService) ScrapGstPortalOnSchedule(userId int, useCredentialFromSettings bool) {
+	s.scrapGstPortal(userId, useCredentialFromSettings, constants.CronTrigger)
+}
+
 func (s *GstService) isGstExistsInSystem(gstin string) (bool, error) {
 	var exists bool
 	if err := s.base.Database.Model(&models.Gst{}).
@@ -267,18 +690,17 @@ func (s *GstService) getExistingGstsInSystem(gsts []dto.Gst) ([]string, error) {
 	return exists, nil
 }
 
-func (s *GstService) scrapGstPortal(userId int, useCredentialFromSettings bool) {
+func (s *GstService) scrapGstPortal(userId int, useCredentialFromSettings bool, trigger constants.ScrapeRunTrigger) {
 	var gsts []models.Gst
 
-	if s.base.Config.Server.Gst.BaseUrl == "" || s.base.Config.Server.Gst.Username == "" || s.base.Config.Server.Gst.Password == "" {
+	if !s.scrapperService.HasPortalSettings() {
 		s.streamerService.StreamData(StreamMessage{Message: "GST settings are not available. Please update it from Settings page.", MessageType: constants.ERROR})
 
 		return
 	}
 
-	err := s.base.Database.Joins("LEFT JOIN gst_statuses ON gsts.gstin = gst_statuses.gstin").
+	err := s.base.Database.
 		Where("gsts.locked = ?", false).
-		Where("gst_statuses.gstin IS NULL OR (gst_statuses.status = ? AND gst_statuses.rtntype = ?) OR (gst_statuses.status = ? AND gst_statuses.rtntype = ?) AND gsts.locked = ?", "InvoiceEntry", "GSTR1", "TaxAmountReceived", "GSTR3B", false).
 		Preload("GstStatuses").
 		Find(&gsts).Error
 
@@ -286,16 +708,23 @@ func (s *GstService) scrapGstPortal(userId int, useCredentialFromSettings bool)
 		s.base.Logger.Errorf("Could not query database. %s", err.Error())
 	}
 
-	gstins := lo.Map(gsts, func(gst models.Gst, i int) string { return gst.Gstin })
+	cal := s.calendarService.Calendar()
+	now := time.Now()
+	gsts = lo.Filter(gsts, func(gst models.Gst, i int) bool { return needsRefresh(cal, gst, now) })
 
-	left, right := lo.Difference(s.scrapperRunning, gstins)
-
-	gstins = lo.Uniq(slices.Concat(left, right))
-	s.scrapperRunning = gstins
+	gstins := s.reserveGstins(lo.Uniq(lo.Map(gsts, func(gst models.Gst, i int) string { return gst.Gstin })))
 
 	count := len(gstins)
 
 	if count > 0 {
+		run, err := s.scrapeRunService.StartRun(userId, trigger, gstins)
+		if err != nil {
+			s.streamerService.StreamData(StreamMessage{Message: "Something went wrong!. Could not process Gst Returns.", UserId: userId, MessageType: constants.ERROR, Code: "NOTIFICATION"})
+			s.releaseGstins(gstins)
+
+			return
+		}
+
 		errMsg := fmt.Sprintf("%d GSTs scheduled for return status update", count)
 
 		if len(gstins) > 5 {
@@ -308,60 +737,99 @@ func (s *GstService) scrapGstPortal(userId int, useCredentialFromSettings bool)
 
 		s.streamerService.StreamData(StreamMessage{Message: errMsg, UserId: userId})
 
-		quit, err := s.scrapperService.ScrapGstSite(lo.Filter(gsts, func(gst models.Gst, i int) bool { return lo.Contains(gstins, gst.Gstin) }), useCredentialFromSettings)
+		ctx := s.scrapeRunService.Track(run.Id)
+
+		quit, err := s.scrapperService.ScrapGstSite(ctx, lo.Filter(gsts, func(gst models.Gst, i int) bool { return lo.Contains(gstins, gst.Gstin) }), useCredentialFromSettings)
 		if err == nil {
-			go s.listenForGstReturnDetails(quit, gsts, userId, gstins)
+			go s.listenForGstReturnDetails(quit, gsts, userId, gstins, run.Id)
 
 			fmt.Printf("Total records: %d", len(gsts))
 
 			s.base.Logger.Infof("Job scheduled to update %d GSTs", len(gsts))
 		} else {
 			s.streamerService.StreamData(StreamMessage{Message: "Something went wrong!. Could not process Gst Returns.", UserId: userId, MessageType: constants.ERROR, Code: "NOTIFICATION"})
-			s.scrapperRunning = []string{}
+			s.scrapeRunService.FinishRun(run.Id, constants.ScrapeRunFailed)
+			s.releaseGstins(gstins)
 		}
 
 	} else {
 		s.streamerService.StreamData(StreamMessage{Message: "Either all GSTs are up-to-date or none of the GSTs are ready to be filed"})
-		s.scrapperRunning = []string{}
 	}
 }
 
-func (s *GstService) listenForGstReturnDetails(quit *common.SafeChannel[gst_scrapper.GstDetail], gsts []models.Gst, userId int, gstins []string) bool {
+// reserveGstins marks the GSTINs as being scrapped and returns the ones which were not already in progress
+func (s *GstService) reserveGstins(gstins []string) []string {
+	s.scrapperMutex.Lock()
+	defer s.scrapperMutex.Unlock()
+
+	gstins = lo.Without(gstins, s.scrapperRunning...)
+	s.scrapperRunning = append(s.scrapperRunning, gstins...)
+
+	return gstins
+}
+
+func (s *GstService) releaseGstins(gstins []string) {
+	s.scrapperMutex.Lock()
+	defer s.scrapperMutex.Unlock()
+
+	s.scrapperRunning = lo.Without(s.scrapperRunning, gstins...)
+}
+
+func (s *GstService) listenForGstReturnDetails(quit *common.SafeChannel[gst_scrapper.GstDetail], gsts []models.Gst, userId int, gstins []string, runId int) bool {
 	var gstDetail = gst_scrapper.GstDetail{}
-	var errMsg string
 
 	success := []string{}
+	cancelled := []string{}
 	failed := 0
 
 	for {
 		select {
 		case details, ok := <-quit.C:
 			if ok {
-				if details.ErrorMessage == "" {
+				s.scrapeRunService.RecordItem(runId, details)
+
+				if details.Cancelled {
+					cancelled = append(cancelled, details.Gstin)
+				} else if details.Error == nil {
 					success = append(success, details.Gst.Gstin)
 					gstDetail = details
-					s.updateGstAndReturns(gsts, gstDetail)
+					s.updateGstAndReturns(gsts, gstDetail, userId, runId)
 
 					s.base.Logger.Infof("Got result for GSTIN %s", gstDetail.Gst.Gstin)
 				} else {
 					failed += 1
-					s.base.Logger.Errorf("Failed to fetch data for a GSTIN - %s", details.ErrorMessage)
+					s.base.Logger.Errorf("Failed to fetch data for a GSTIN - %s", details.Error.Error())
 
-					messages := strings.Split(details.ErrorMessage, "|")
+					s.lockFailingGst(details, userId, runId)
 
-					if len(messages) > 1 && messages[0] == "NOTIFICATION" {
-						errMsg = messages[1]
-						s.streamerService.StreamData(StreamMessage{Code: "NOTIFICATION", UserId: userId, MessageType: constants.ERROR, Message: errMsg})
+					if details.Error.NeedsAttention() {
+						s.streamerService.StreamData(StreamMessage{Code: "NOTIFICATION", UserId: userId, MessageType: constants.ERROR, Message: details.Error.Message, Gstin: details.Gstin, ErrorCode: details.Error.Code, Data: details.Error})
 					}
 				}
 			} else {
 				s.base.Logger.Infof("Done with scrapping for %s GSTINs", gstins)
-				s.scrapperRunning = []string{}
+				s.releaseGstins(gstins)
+
+				if len(cancelled) > 0 {
+					s.scrapeRunService.FinishRun(runId, constants.ScrapeRunCancelled)
+				} else if failed == len(gstins) {
+					s.scrapeRunService.FinishRun(runId, constants.ScrapeRunFailed)
+				} else {
+					s.scrapeRunService.FinishRun(runId, constants.ScrapeRunCompleted)
+				}
 
 				count := len(gstins)
 				errMsg := ""
 
-				if len(success) == count {
+				if len(cancelled) > 0 {
+					if len(success) != 0 {
+						s.streamerService.StreamData(StreamMessage{Code: "REFRESH_GSTS_TABLE"})
+					}
+
+					errMsg = fmt.Sprintf("Scrapping cancelled. %d GSTINs processed, %d GSTINs failed and %d GSTINs cancelled", len(success), failed, len(cancelled))
+
+					s.streamerService.StreamData(StreamMessage{Code: "SCRAPE_CANCELLED", UserId: userId, MessageType: constants.WARN, Message: errMsg, Data: cancelled})
+				} else if len(success) == count {
 					if len(gstins) > 5 {
 						errMsg = fmt.Sprintf("GST Return status for GSTIN %s and %d+ more has been updated into the system", gstins[0], int(math.Floor(float64(count/5)*5)))
 					} else if count == 1 {
@@ -392,15 +860,52 @@ func (s *GstService) listenForGstReturnDetails(quit *common.SafeChannel[gst_scra
 	}
 }
 
-func (s *GstService) updateGstAndReturns(gsts []models.Gst, gstDetail gst_scrapper.GstDetail) {
+// lockFailingGst locks the GSTIN when it can not be scrapped anymore, so the following runs skip it until someone unlocks it
+func (s *GstService) lockFailingGst(details gst_scrapper.GstDetail, userId int, runId int) {
+	lock := details.Error.Class == constants.PermanentScrapeError
+
+	if !lock && s.base.Config.Server.Gst.LockAfterFailedRuns > 0 {
+		failing, err := s.scrapeRunService.HasFailedInARow(details.Gstin, s.base.Config.Server.Gst.LockAfterFailedRuns)
+		lock = err == nil && failing
+	}
+
+	if !lock {
+		return
+	}
+
+	reason := details.Error.Message
+
+	err := s.base.Database.WithContext(scrapeContext(userId, runId)).Model(&models.Gst{}).Where("gstin = ?", details.Gstin).Updates(map[string]interface{}{
+		"locked":      true,
+		"lock_reason": reason,
+		"modified_by": userId,
+		"modified_at": time.Now(),
+	}).Error
+	if err != nil {
+		s.base.Logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return
+	}
+
+	s.streamerService.StreamData(StreamMessage{Code: "NOTIFICATION", UserId: userId, MessageType: constants.WARN, Message: fmt.Sprintf("GSTIN %s has been locked. %s", details.Gstin, reason), Gstin: details.Gstin, ErrorCode: details.Error.Code})
+}
+
+func (s *GstService) updateGstAndReturns(gsts []models.Gst, gstDetail gst_scrapper.GstDetail, userId int, runId int) {
 	gst, found := lo.Find(gsts, func(gst models.Gst) bool { return gst.Gstin == gstDetail.Gst.Gstin })
 	if found {
-		tx := s.base.Database.Begin()
+		tx := s.base.Database.WithContext(scrapeContext(userId, runId)).Begin()
 
 		gstDetail.Gst.MobileNumber = gst.MobileNumber
 		gstDetail.Gst.Email = gst.Email
 		gstDetail.Gst.Locked = gstDetail.Gst.Status != "Active"
 		gstDetail.Gst.ModifiedAt = time.Now()
+		gstDetail.Gst.ModifiedBy = &sql.NullInt64{Int64: int64(userId), Valid: true}
+
+		frequency := frequencyOf(gst)
+		if detected, ok := detectFilingFrequency(gstDetail.Returns); ok && !gst.FilingFrequencyManual {
+			frequency = detected
+			gstDetail.Gst.FilingFrequency = detected
+		}
+		gst.FilingFrequency = frequency
 
 		err := tx.Model(&models.Gst{}).Where("gstin = ?", gst.Gstin).Updates(gstDetail.Gst).Error
 
@@ -408,9 +913,16 @@ func (s *GstService) updateGstAndReturns(gsts []models.Gst, gstDetail gst_scrapp
 			tx.Rollback()
 			s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
 		} else {
-			returns := processGstStatuses(gst.Gstin, gstDetail.Returns, len(gst.GstStatuses) == 0)
+			returns := processGstStatuses(s.calendarService.Calendar(), gst, gstDetail.Returns)
 
 			err := updateGstReturns(returns, gst, tx)
+			if err == nil {
+				err = recordScrapedTransitions(returns, gst, userId, tx)
+			}
+			if err == nil {
+				err = saveGstReturnFilings(gst.Gstin, gstDetail.Returns, tx)
+			}
+
 			if err != nil {
 				tx.Rollback()
 				s.base.Logger.Error(logging.Sqlite3, logging.Rollback, err.Error(), nil)
@@ -421,6 +933,11 @@ func (s *GstService) updateGstAndReturns(gsts []models.Gst, gstDetail gst_scrapp
 	}
 }
 
+// scrapeContext has the changes made from a scrape run audited as made by the user the run is for
+func scrapeContext(userId int, runId int) context.Context {
+	return audit.WithRequestId(audit.WithActor(context.Background(), userId), fmt.Sprintf("scrape-run-%d", runId))
+}
+
 func updateGstReturns(returns []models.GstStatus, gst models.Gst, tx *gorm.DB) error {
 	var err error
 	for _, rtn := range returns {
@@ -434,14 +951,96 @@ func updateGstReturns(returns []models.GstStatus, gst models.Gst, tx *gorm.DB) e
 	return err
 }
 
-func processGstStatuses(gstin string, returns []models.GstStatus, isNewEntry bool) []models.GstStatus {
+// recordScrapedTransitions records the status changes made from what the portal reported, a return
+// filed there or a new return period starting its workflow
+func recordScrapedTransitions(returns []models.GstStatus, gst models.Gst, userId int, tx *gorm.DB) error {
+	transitions := lo.FilterMap(returns, func(rtn models.GstStatus, i int) (models.GstStatusTransition, bool) {
+		current, _ := lo.Find(gst.GstStatuses, func(st models.GstStatus) bool { return st.Rtntype == rtn.Rtntype })
+
+		// the status is kept when it is not set
+		if rtn.Status == "" || (rtn.Status == current.Status && rtn.RetPrd == current.RetPrd) {
+			return models.GstStatusTransition{}, false
+		}
+
+		note := fmt.Sprintf("Return period %s is pending", rtn.RetPrd)
+		if rtn.Status == constants.Filed {
+			note = fmt.Sprintf("Filed on the portal on %s", rtn.Dof)
+		}
+
+		return models.GstStatusTransition{
+			Gstin:      gst.Gstin,
+			Rtntype:    rtn.Rtntype,
+			RetPrd:     rtn.RetPrd,
+			FromStatus: current.Status,
+			ToStatus:   rtn.Status,
+			Note:       note,
+			BaseModel:  models.BaseModel{CreatedBy: userId},
+		}, true
+	})
+
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	return tx.Create(&transitions).Error
+}
+
+// saveGstReturnFilings keeps every filed return seen on the portal, a refiled period has its filing updated
+func saveGstReturnFilings(gstin string, returns []models.GstStatus, tx *gorm.DB) error {
+	filings := lo.FilterMap(returns, func(ret models.GstStatus, i int) (models.GstReturnFiling, bool) {
+		if ret.Status != constants.Filed {
+			return models.GstReturnFiling{}, false
+		}
+
+		retPrd := getRetPrdFromTaxp(ret.TaxPrd, ret.FinancialYear, ret.Rtntype)
+		period, err := time.Parse(constants.TAXPRD, retPrd)
+		if err != nil {
+			return models.GstReturnFiling{}, false
+		}
+
+		filedOn, _ := time.Parse(constants.DOF, ret.Dof)
+
+		return models.GstReturnFiling{
+			Gstin:         gstin,
+			Rtntype:       ret.Rtntype,
+			RetPrd:        retPrd,
+			Period:        period,
+			TaxPrd:        ret.TaxPrd,
+			FinancialYear: ret.FinancialYear,
+			Arn:           ret.Arn,
+			Dof:           ret.Dof,
+			FiledOn:       filedOn,
+			Mof:           ret.Mof,
+			Valid:         ret.Valid,
+			BaseModel:     models.BaseModel{ModifiedAt: time.Now()},
+		}, true
+	})
+
+	// the portal may list a period more than once, the last one seen wins
+	filings = lo.UniqBy(lo.Reverse(filings), func(filing models.GstReturnFiling) string {
+		return fmt.Sprintf("%s|%s", filing.Rtntype, filing.RetPrd)
+	})
+
+	if len(filings) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "gstin"}, {Name: "rtntype"}, {Name: "ret_prd"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tax_prd", "financial_year", "arn", "dof", "filed_on", "mof", "valid", "modified_at"}),
+	}).Create(&filings).Error
+}
+
+func processGstStatuses(cal *gst_calendar.Calendar, gst models.Gst, returns []models.GstStatus) []models.GstStatus {
 	returnGroups := lo.GroupBy(returns, func(ret models.GstStatus) constants.GstReturnType { return ret.Rtntype })
 
 	newReturns := []models.GstStatus{}
 	for rty, retn := range returnGroups {
-		rtns := getLatestReturnStatus(rty, retn, isNewEntry)
+		current, _ := lo.Find(gst.GstStatuses, func(st models.GstStatus) bool { return st.Rtntype == rty })
+
+		rtns := getLatestReturnStatus(cal, gst.Gstin, frequencyOf(gst), rty, retn, current)
 		if rtns != nil {
-			rtns.Gstin = gstin
+			rtns.Gstin = gst.Gstin
 			newReturns = append(newReturns, *rtns)
 		}
 	}
@@ -449,7 +1048,7 @@ func processGstStatuses(gstin string, returns []models.GstStatus, isNewEntry boo
 	return newReturns
 }
 
-func getLatestReturnStatus(gstReturnType constants.GstReturnType, returns []models.GstStatus, isNewEntry bool) *models.GstStatus {
+func getLatestReturnStatus(cal *gst_calendar.Calendar, gstin string, frequency constants.FilingFrequency, gstReturnType constants.GstReturnType, returns []models.GstStatus, current models.GstStatus) *models.GstStatus {
 	filed := lo.FilterMap(returns, func(ret models.GstStatus, i int) (models.GstStatus, bool) {
 		return models.GstStatus{
 				Dof:           ret.Dof,
@@ -464,7 +1063,7 @@ func getLatestReturnStatus(gstReturnType constants.GstReturnType, returns []mode
 	})
 
 	if len(filed) > 0 {
-		return getUpdateGstReturnStatus(filed, gstReturnType, isNewEntry)
+		return getUpdateGstReturnStatus(cal, gstin, frequency, filed, current)
 	} else {
 		fmt.Printf("No returns found!")
 	}
@@ -472,7 +1071,7 @@ func getLatestReturnStatus(gstReturnType constants.GstReturnType, returns []mode
 	return nil
 }
 
-func getUpdateGstReturnStatus(filed []models.GstStatus, gstReturnType constants.GstReturnType, isNewEntry bool) *models.GstStatus {
+func getUpdateGstReturnStatus(cal *gst_calendar.Calendar, gstin string, frequency constants.FilingFrequency, filed []models.GstStatus, current models.GstStatus) *models.GstStatus {
 	slices.SortFunc(filed,
 		func(a, b models.GstStatus) int {
 			dof1, err1 := time.Parse(constants.DOF, a.Dof)
@@ -489,94 +1088,394 @@ func getUpdateGstReturnStatus(filed []models.GstStatus, gstReturnType constants.
 			return 1
 		})
 
-	lastFiledDate, _ := time.Parse(constants.DOF, filed[0].Dof)
 	lastTaxPeriod, _ := time.Parse(constants.TAXPRD, filed[0].RetPrd)
+	// a GSTIN which moved to QRMP has its last monthly return count for the quarter
+	lastTaxPeriod = gst_calendar.PeriodOf(filed[0].Rtntype, frequency, lastTaxPeriod)
 
-	years, months, _, _, _, _ := utils.Diff(time.Now(), lastTaxPeriod)
+	newReturnsStatus := getGstReturn(cal, gstin, filed, frequency, lastTaxPeriod, current, time.Now())
 
-	var pendingCount int
-	var dueDays int
+	return &newReturnsStatus
+}
 
-	if gstReturnType == constants.GSTR9 {
-		pendingCount = years
-		dueDays = 21
+func getGstReturn(cal *gst_calendar.Calendar, gstin string, filed []models.GstStatus, frequency constants.FilingFrequency, lastTaxPeriod time.Time, current models.GstStatus, now time.Time) models.GstStatus {
+	newReturnsStatus := models.GstStatus{TaxPrd: filed[0].TaxPrd, Rtntype: filed[0].Rtntype,
+		FinancialYear: filed[0].FinancialYear, Arn: filed[0].Arn, Mof: filed[0].Mof}
+
+	pendings := cal.PendingPeriods(newReturnsStatus.Rtntype, frequency, lastTaxPeriod, now)
+
+	if len(pendings) > 0 {
+		newReturnsStatus.Dof = ""
+		newReturnsStatus.RetPrd = pendings[0].Format(constants.TAXPRD)
+		newReturnsStatus.TaxPrd = pendings[0].Month().String()
+		newReturnsStatus.DueDate = cal.DueDate(newReturnsStatus.Rtntype, frequency, gstin, pendings[0]).DueDate
+
+		// the workflow starts over for a new pending period, otherwise its progress is kept
+		if current.Status == "" || current.Status == constants.Filed || current.RetPrd != newReturnsStatus.RetPrd {
+			newReturnsStatus.Status = gst_workflow.InitialStatus(newReturnsStatus.Rtntype)
+		}
 	} else {
-		pendingCount = months
-		dueDays = 12
+		newReturnsStatus.Dof = filed[0].Dof
+		newReturnsStatus.RetPrd = filed[0].RetPrd
+		newReturnsStatus.TaxPrd = lastTaxPeriod.Month().String()
+		newReturnsStatus.Status = constants.Filed
+		newReturnsStatus.DueDate = cal.DueDate(newReturnsStatus.Rtntype, frequency, gstin, gst_calendar.NextPeriod(newReturnsStatus.Rtntype, frequency, lastTaxPeriod)).DueDate
 	}
 
-	newReturnsStatus := getGstReturn(filed, pendingCount, lastTaxPeriod, lastFiledDate, dueDays, isNewEntry)
+	newReturnsStatus.PendingReturns = lo.Map(pendings, func(period time.Time, i int) string { return period.Format(constants.TAXPRD) })
 
-	return &newReturnsStatus
+	return newReturnsStatus
 }
 
-func getGstReturn(filed []models.GstStatus, pendingCount int, lastTaxPeriod time.Time, lastFiledDate time.Time, dueDays int, isNewEntry bool) models.GstStatus {
+func getRetPrdFromTaxp(taxp, fy string, returnType constants.GstReturnType) string {
+	years := strings.Split(fy, "-")
 
-	newReturnsStatus := models.GstStatus{TaxPrd: filed[0].TaxPrd, Rtntype: filed[0].Rtntype,
-		FinancialYear: filed[0].FinancialYear, Arn: filed[0].Arn, Mof: filed[0].Mof}
-	pendings := []string{}
+	if returnType == constants.GSTR9 {
+		return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", 3), years[1])
+	} else {
+		index := getTaxpMonth(taxp)
+		if index >= 0 && time.March >= index {
+			return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", index), years[1])
+		} else {
+			return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", index), years[0])
+		}
+	}
+}
 
-	if pendingCount > 0 {
-		for count := range pendingCount {
-			if newReturnsStatus.Rtntype == constants.GSTR9 {
-				pendings = append(pendings, lastTaxPeriod.AddDate(count+1, 0, 0).Format(constants.TAXPRD))
-			} else {
-				pendings = append(pendings, lastTaxPeriod.AddDate(0, count+1, 0).Format(constants.TAXPRD))
+// getTaxpMonth returns the month of a tax period as the portal names it, either a month
+// or a quarter as Apr-Jun, for which the last month of the quarter is returned
+func getTaxpMonth(taxp string) time.Month {
+	if month, ok := utils.Months[taxp]; ok {
+		return month
+	}
+
+	parts := strings.Split(taxp, "-")
+	last := strings.TrimSpace(parts[len(parts)-1])
+
+	for name, month := range utils.Months {
+		if len(last) >= 3 && strings.HasPrefix(name, last[:3]) {
+			return month
+		}
+	}
+
+	return 0
+}
+
+// detectFilingFrequency tells whether the GSTIN files GSTR-1 and GSTR-3B monthly or quarterly from
+// the returns it filed on the portal. It is not detected when there are too few returns to tell
+func detectFilingFrequency(returns []models.GstStatus) (constants.FilingFrequency, bool) {
+	if lo.SomeBy(returns, func(ret models.GstStatus) bool { return ret.Rtntype == constants.IFF }) {
+		return constants.QuarterlyFiling, true
+	}
+
+	periods := lo.FilterMap(returns, func(ret models.GstStatus, i int) (time.Time, bool) {
+		if ret.Rtntype != constants.GSTR3B || ret.Status != constants.Filed {
+			return time.Time{}, false
+		}
+
+		period, err := time.Parse(constants.TAXPRD, getRetPrdFromTaxp(ret.TaxPrd, ret.FinancialYear, ret.Rtntype))
+		return period, err == nil
+	})
+
+	periods = lo.UniqBy(periods, func(period time.Time) int64 { return period.Unix() })
+	slices.SortFunc(periods, func(a, b time.Time) int { return b.Compare(a) })
+
+	// the latest filings tell the current frequency, a GSTIN may have opted in or out of QRMP
+	if len(periods) < 2 {
+		return "", false
+	}
+	periods = periods[:min(len(periods), 3)]
+
+	if lo.EveryBy(periods, gst_calendar.IsQuarterEnd) {
+		return constants.QuarterlyFiling, true
+	}
+
+	return constants.MonthlyFiling, true
+}
+
+// frequencyOf returns the filing frequency of the GSTIN, monthly unless it is known to be quarterly
+func frequencyOf(gst models.Gst) constants.FilingFrequency {
+	if gst.FilingFrequency == "" {
+		return constants.MonthlyFiling
+	}
+
+	return gst.FilingFrequency
+}
+
+// needsRefresh tells whether the returns of the GSTIN have to be scrapped from the portal. That is when it
+// was never scrapped, a return is ready to be filed or a new return period has ended since the last one filed
+func needsRefresh(cal *gst_calendar.Calendar, gst models.Gst, now time.Time) bool {
+	if len(gst.GstStatuses) == 0 {
+		return true
+	}
+
+	return lo.SomeBy(gst.GstStatuses, func(st models.GstStatus) bool {
+		if (st.Rtntype == constants.GSTR1 && st.Status == constants.InvoiceEntry) || (st.Rtntype == constants.GSTR3B && st.Status == constants.TaxAmountReceived) {
+			return true
+		}
+
+		if st.Status != constants.Filed {
+			return false
+		}
+
+		period, err := time.Parse(constants.TAXPRD, st.RetPrd)
+
+		return err == nil && len(cal.PendingPeriods(st.Rtntype, frequencyOf(gst), period, now)) > 0
+	})
+}
+
+func prepareGstReturnFilingDTO(cal *gst_calendar.Calendar, filing models.GstReturnFiling, frequency constants.FilingFrequency) dto.GstReturnFiling {
+	// the GSTIN may have moved in or out of QRMP since, the period tells what it filed then
+	switch {
+	case !gst_calendar.IsQuarterEnd(filing.Period):
+		frequency = constants.MonthlyFiling
+	case strings.Contains(filing.TaxPrd, "-"):
+		frequency = constants.QuarterlyFiling
+	}
+
+	due := cal.DueDate(filing.Rtntype, frequency, filing.Gstin, filing.Period)
+
+	daysLate := 0
+	if !filing.FiledOn.IsZero() && filing.FiledOn.After(due.DueDate) {
+		daysLate = int(filing.FiledOn.Sub(due.DueDate).Hours() / 24)
+	}
+
+	return dto.GstReturnFiling{
+		ReturnType:    filing.Rtntype,
+		ReturnPeriod:  filing.RetPrd,
+		TaxPrd:        filing.TaxPrd,
+		FinancialYear: filing.FinancialYear,
+		Arn:           filing.Arn,
+		FiledDate:     filing.Dof,
+		ModeOfFiling:  filing.Mof,
+		Valid:         filing.Valid,
+		DueDate:       due.DueDate,
+		DaysLate:      daysLate,
+	}
+}
+
+// exportReturnTypes is the order the returns are exported in
+var exportReturnTypes = []constants.GstReturnType{
+	constants.GSTR1, constants.GSTR3B, constants.GSTR9, constants.GSTR4, constants.CMP08, constants.GSTR2, constants.IFF, constants.PMT06,
+}
+
+// gstExportTable has a row for each GST with the period, status, due date and pending periods of each of its return types
+func gstExportTable(gsts []dto.Gst) spreadsheet.Table {
+	table := spreadsheet.Table{
+		Title:   "GST Return Status",
+		Headers: []string{"S.No", "F.No", "GSTIN", "Name", "Trade Name", "Mobile Number", "Email", "Filing Frequency", "Locked"},
+		Rows:    [][]string{},
+	}
+
+	returnTypes := lo.Filter(exportReturnTypes, func(rt constants.GstReturnType, i int) bool {
+		return lo.SomeBy(gsts, func(gst dto.Gst) bool {
+			return lo.SomeBy(gst.GstStatuses, func(st dto.GstStatus) bool { return st.ReturnType == rt })
+		})
+	})
+
+	for _, rt := range returnTypes {
+		table.Headers = append(table.Headers, fmt.Sprintf("%s Period", rt), fmt.Sprintf("%s Status", rt), fmt.Sprintf("%s Due Date", rt), fmt.Sprintf("%s Pending Returns", rt))
+	}
+
+	for _, gst := range gsts {
+		row := []string{gst.Sno, gst.Fno, gst.Gstin, gst.Name, gst.TradeName, gst.MobileNumber, gst.Email, string(gst.FilingFrequency), "No"}
+		if gst.Locked {
+			row[8] = "Yes"
+		}
+
+		for _, rt := range returnTypes {
+			st, ok := lo.Find(gst.GstStatuses, func(st dto.GstStatus) bool { return st.ReturnType == rt })
+			if !ok {
+				row = append(row, "", "", "", "")
+				continue
 			}
+
+			dueDate := ""
+			if !st.DueDate.IsZero() {
+				dueDate = st.DueDate.Format(constants.DOF)
+			}
+
+			row = append(row, exportPeriod(st.ReturnPeriod), string(st.Status), dueDate, strings.Join(lo.Map(st.PendingReturns, func(p string, i int) string { return exportPeriod(p) }), ", "))
 		}
 
-		newReturnsStatus.Dof = ""
-		newReturnsStatus.RetPrd = lastTaxPeriod.AddDate(0, 1, 0).Format(constants.TAXPRD)
-		newReturnsStatus.TaxPrd = time.Month(lastTaxPeriod.Month() + 1).String()
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
 
-		if isNewEntry {
-			setReturnStatus(newReturnsStatus)
+// exportPeriod names a return period like Mar 2024
+func exportPeriod(retPrd string) string {
+	period, err := time.Parse(constants.TAXPRD, retPrd)
+	if err != nil {
+		return retPrd
+	}
+
+	return period.Format("Jan 2006")
+}
+
+// importFields sets the GST fields a column of an import file can be mapped to
+var importFields = map[string]func(gst *dto.Gst, value string){
+	"sno":             func(gst *dto.Gst, value string) { gst.Sno = value },
+	"fno":             func(gst *dto.Gst, value string) { gst.Fno = value },
+	"gstin":           func(gst *dto.Gst, value string) { gst.Gstin = strings.ToUpper(value) },
+	"name":            func(gst *dto.Gst, value string) { gst.Name = value },
+	"tradeName":       func(gst *dto.Gst, value string) { gst.TradeName = value },
+	"email":           func(gst *dto.Gst, value string) { gst.Email = value },
+	"mobileNumber":    func(gst *dto.Gst, value string) { gst.MobileNumber = value },
+	"type":            func(gst *dto.Gst, value string) { gst.Type = value },
+	"username":        func(gst *dto.Gst, value string) { gst.Username = value },
+	"password":        func(gst *dto.Gst, value string) { gst.Password = value },
+	"filingFrequency": func(gst *dto.Gst, value string) { gst.FilingFrequency = constants.FilingFrequency(value) },
+}
+
+// importHeaders are the column headers mapped to a field without a mapping, lower cased and without spaces or punctuation
+var importHeaders = map[string]string{
+	"sno":             "sno",
+	"serialno":        "sno",
+	"fno":             "fno",
+	"fileno":          "fno",
+	"gstin":           "gstin",
+	"gstno":           "gstin",
+	"gstnumber":       "gstin",
+	"name":            "name",
+	"legalname":       "name",
+	"tradename":       "tradeName",
+	"email":           "email",
+	"emailid":         "email",
+	"mobilenumber":    "mobileNumber",
+	"mobileno":        "mobileNumber",
+	"mobile":          "mobileNumber",
+	"type":            "type",
+	"username":        "username",
+	"password":        "password",
+	"filingfrequency": "filingFrequency",
+	"frequency":       "filingFrequency",
+}
+
+var nonAlphanumeric = regexp.MustCompile("[^A-Za-z0-9]")
+
+var emailValidator = validator.New()
+
+// importMapping returns the field each column header is read into. The mapping given as JSON replaces the default one
+func importMapping(headers []string, mappingJson string) (map[string]string, error) {
+	mapping := map[string]string{}
+
+	if mappingJson == "" {
+		for _, header := range headers {
+			key := strings.ToLower(nonAlphanumeric.ReplaceAllString(header, ""))
+			if field, ok := importHeaders[key]; ok && !slices.Contains(lo.Values(mapping), field) {
+				mapping[header] = field
+			}
 		}
 	} else {
-		if lastFiledDate.Before(utils.StartOfMonth(time.Now()).AddDate(0, 1, dueDays)) {
-			newReturnsStatus.Dof = filed[0].Dof
-			newReturnsStatus.RetPrd = filed[0].RetPrd
-			newReturnsStatus.TaxPrd = lastTaxPeriod.Month().String()
-			newReturnsStatus.Status = constants.Filed
-		} else {
-			newReturnsStatus.Dof = ""
-			newReturnsStatus.RetPrd = lastTaxPeriod.AddDate(0, 1, 0).Format(constants.TAXPRD)
-			newReturnsStatus.TaxPrd = lastTaxPeriod.Month().String()
-			setReturnStatus(newReturnsStatus)
+		err := json.Unmarshal([]byte(mappingJson), &mapping)
+		if err != nil {
+			return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportMapping, TechnicalMessage: err.Error(), Err: err}
+		}
+
+		for header, field := range mapping {
+			if field == "" {
+				delete(mapping, header)
+				continue
+			}
+			if _, ok := importFields[field]; !ok {
+				return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportMapping, TechnicalMessage: fmt.Sprintf("%s is not a GST field", field)}
+			}
+			if !slices.Contains(headers, header) {
+				return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportMapping, TechnicalMessage: fmt.Sprintf("column %s is not in the file", header)}
+			}
+		}
+
+		fields := lo.Values(mapping)
+		if len(lo.Uniq(fields)) != len(fields) {
+			return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportMapping, TechnicalMessage: "a field is mapped to more than one column"}
 		}
 	}
 
-	newReturnsStatus.PendingReturns = pendings
+	if !slices.Contains(lo.Values(mapping), "gstin") {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportMapping, TechnicalMessage: "no column is mapped to gstin"}
+	}
 
-	return newReturnsStatus
+	return mapping, nil
 }
 
-func setReturnStatus(newReturnsStatus models.GstStatus) {
-	switch newReturnsStatus.Rtntype {
-	case constants.GSTR1:
-		newReturnsStatus.Status = constants.CallForInvoice
-		break
-	case constants.GSTR3B:
-		newReturnsStatus.Status = constants.TaxPayable
-		break
-	default:
-		newReturnsStatus.Status = constants.CallForInvoice
+// readImportRows reads the GSTs of the rows and validates them. Empty rows are skipped
+func readImportRows(headers []string, mapping map[string]string, rows [][]string) ([]dto.Gst, *dto.GstImportResult) {
+	result := &dto.GstImportResult{Headers: headers, Mapping: mapping, Errors: []dto.GstImportError{}}
+	gsts := []dto.Gst{}
+	seen := map[string]int{}
+
+	for i, row := range rows {
+		// the header is row 1
+		rowNumber := i + 2
+
+		if lo.EveryBy(row, func(cell string) bool { return cell == "" }) {
+			continue
+		}
+
+		gst := dto.Gst{}
+		for col, header := range headers {
+			field, ok := mapping[header]
+			if !ok || col >= len(row) {
+				continue
+			}
+			importFields[field](&gst, row[col])
+		}
+
+		errs := validateImportRow(&gst)
+		if first, ok := seen[gst.Gstin]; ok && gst.Gstin != "" {
+			errs = append(errs, dto.GstImportError{Field: "gstin", Message: fmt.Sprintf("GSTIN is repeated, first in row %d", first)})
+		} else {
+			seen[gst.Gstin] = rowNumber
+		}
+
+		result.TotalRows++
+		if len(errs) > 0 {
+			result.InvalidRows++
+			for _, e := range errs {
+				e.Row = rowNumber
+				e.Gstin = gst.Gstin
+				result.Errors = append(result.Errors, e)
+			}
+			continue
+		}
+
+		result.ValidRows++
+		gsts = append(gsts, gst)
 	}
+
+	return gsts, result
 }
 
-func getRetPrdFromTaxp(taxp, fy string, returnType constants.GstReturnType) string {
-	years := strings.Split(fy, "-")
+func validateImportRow(gst *dto.Gst) []dto.GstImportError {
+	errs := []dto.GstImportError{}
 
-	if returnType == constants.GSTR9 {
-		return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", 2), years[1])
-	} else {
-		index := utils.Months[taxp]
-		if index >= 0 && 2 >= index {
-			return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", index), years[1])
+	if gst.Gstin == "" {
+		errs = append(errs, dto.GstImportError{Field: "gstin", Message: "GSTIN is required"})
+	} else if !common.CheckGstin(gst.Gstin) {
+		errs = append(errs, dto.GstImportError{Field: "gstin", Message: "GSTIN is not valid"})
+	}
+
+	if gst.MobileNumber != "" && !common.IndianMobileNumberValidate(gst.MobileNumber) {
+		errs = append(errs, dto.GstImportError{Field: "mobileNumber", Message: "Mobile number is not valid"})
+	}
+
+	if gst.Email != "" && emailValidator.Var(gst.Email, "email") != nil {
+		errs = append(errs, dto.GstImportError{Field: "email", Message: "Email is not valid"})
+	}
+
+	if gst.FilingFrequency != "" {
+		frequency, ok := lo.Find([]constants.FilingFrequency{constants.MonthlyFiling, constants.QuarterlyFiling}, func(f constants.FilingFrequency) bool {
+			return strings.EqualFold(string(f), string(gst.FilingFrequency))
+		})
+		if ok {
+			gst.FilingFrequency = frequency
 		} else {
-			return fmt.Sprintf("%s%s", fmt.Sprintf("%02d", index), years[0])
+			errs = append(errs, dto.GstImportError{Field: "filingFrequency", Message: "Filing frequency is either Monthly or Quarterly"})
 		}
 	}
+
+	return errs
 }
 
 func mapGSTStatus(statuses []dto.GstStatus) []models.GstStatus {
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	gst_calendar "github.com/jaganathanb/dapps-api/pkg/gst-calendar"
+	gst_scrapper "github.com/jaganathanb/dapps-api/pkg/gst-scrapper"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const mocksPath = "../data/db/mocks"
+
+// newFixtureGstService returns a GST service on an in-memory database, scrapping through the fixture fetcher
+func newFixtureGstService(t *testing.T) (*GstService, *gorm.DB) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir(), Level: "error"},
+		Server: config.ServerConfig{Gst: config.GstServer{
+			Fetcher:       gst_scrapper.FixtureFetcher,
+			FixturePath:   mocksPath,
+			ArtifactsPath: t.TempDir(),
+		}},
+		Encryption: config.EncryptionConfig{MasterKey: key},
+	}
+
+	keyring, err := encryption.NewKeyring(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.Register(keyring)
+
+	database, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDb, _ := database.DB()
+		sqlDb.Close()
+	})
+
+	err = database.AutoMigrate(&models.Gst{}, &models.GstStatus{}, &models.PermenantAddress{}, &models.AdditionalAddress{},
+		&models.Address{}, &models.Notifications{}, &models.ScrapeRun{}, &models.ScrapeRunItem{}, &models.ScrapeRunArtifact{},
+		&models.DueDateExtension{}, &models.GstReturnFiling{}, &models.GstStatusTransition{}, &models.GstTaxAmount{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.NewLogger(cfg)
+	notifications := &NotificationsService{logger: logger, cfg: cfg, database: database}
+	streamer := &StreamerService{logger: logger, cfg: cfg, streamer: getNewServer(logger), notificationService: notifications}
+
+	service := &GstService{
+		base: &BaseService[models.Gst, dto.CreateGstRequest, dto.UpdateGstReturnStatusRequest, dto.GetGstResponse]{
+			Database: database,
+			Logger:   logger,
+			Config:   cfg,
+		},
+		scrapperService:      &ScrapperService{logger: logger, cfg: cfg, DB: database, streamer: streamer, scrapper: gst_scrapper.NewFixtureScrapper(cfg)},
+		streamerService:      streamer,
+		notificationsService: notifications,
+		scrapeRunService:     &ScrapeRunService{logger: logger, cfg: cfg, database: database, controls: map[int]*scrapeRunControl{}},
+		calendarService:      &CalendarService{logger: logger, cfg: cfg, database: database, calendar: gst_calendar.NewCalendar(nil)},
+	}
+
+	return service, database
+}
+
+// filedReturnTypes reads the return types each GSTIN of the fixture has filed
+func filedReturnTypes(t *testing.T) map[string][]constants.GstReturnType {
+	data, err := os.ReadFile(filepath.Join(mocksPath, "returns.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := map[string][]models.GstStatus{}
+	err = json.Unmarshal(data, &recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filed := map[string][]constants.GstReturnType{}
+	for gstin, returns := range recorded {
+		filed[gstin] = []constants.GstReturnType{}
+		for _, ret := range returns {
+			if ret.Status == constants.Filed && !slices.Contains(filed[gstin], ret.Rtntype) {
+				filed[gstin] = append(filed[gstin], ret.Rtntype)
+			}
+		}
+		slices.Sort(filed[gstin])
+	}
+
+	return filed
+}
+
+func TestScrapGstPortalWithFixtures(t *testing.T) {
+	service, database := newFixtureGstService(t)
+	filed := filedReturnTypes(t)
+
+	if len(filed) != 5 {
+		t.Fatalf("fixture GSTINs = %d, want 5", len(filed))
+	}
+
+	for gstin := range filed {
+		gst := models.Gst{Gstin: gstin, Status: "Active", BaseModel: models.BaseModel{CreatedBy: 1}}
+		if err := database.Create(&gst).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service.scrapGstPortal(1, false, constants.ManualTrigger)
+
+	var run models.ScrapeRun
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		if err := database.First(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+		if run.Status != constants.ScrapeRunRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if run.Status != constants.ScrapeRunCompleted {
+		t.Fatalf("run status = %s, want %s", run.Status, constants.ScrapeRunCompleted)
+	}
+	if run.Trigger != constants.ManualTrigger {
+		t.Errorf("run trigger = %s, want %s", run.Trigger, constants.ManualTrigger)
+	}
+
+	var items []models.ScrapeRunItem
+	if err := database.Where("scrape_run_id = ?", run.Id).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != len(filed) {
+		t.Fatalf("run items = %d, want %d", len(items), len(filed))
+	}
+
+	for _, item := range items {
+		if _, ok := filed[item.Gstin]; !ok {
+			t.Errorf("run item of GSTIN %s is not in the fixtures", item.Gstin)
+		}
+		if item.Status != constants.ScrapeItemSucceeded || item.ErrorCode != "" {
+			t.Errorf("GSTIN %s item = %s %s, want %s", item.Gstin, item.Status, item.ErrorCode, constants.ScrapeItemSucceeded)
+		}
+		if item.Attempts != 1 {
+			t.Errorf("GSTIN %s attempts = %d, want 1", item.Gstin, item.Attempts)
+		}
+	}
+
+	for gstin, returnTypes := range filed {
+		var statuses []models.GstStatus
+		if err := database.Where("gstin = ?", gstin).Order("rtntype").Find(&statuses).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		got := []constants.GstReturnType{}
+		for _, status := range statuses {
+			got = append(got, status.Rtntype)
+
+			if status.Status == "" || status.RetPrd == "" {
+				t.Errorf("GSTIN %s %s status = %q of period %q", gstin, status.Rtntype, status.Status, status.RetPrd)
+			}
+		}
+
+		if !slices.Equal(got, returnTypes) {
+			t.Errorf("GSTIN %s return statuses = %v, want %v", gstin, got, returnTypes)
+		}
+	}
+
+	if running := service.reserveGstins([]string{"33AOSPA7307Q1ZI"}); len(running) != 1 {
+		t.Errorf("GSTINs of the run are still reserved")
+	}
+}
+
+func TestUpdateGstStatusChangedMeanwhile(t *testing.T) {
+	service, database := newFixtureGstService(t)
+
+	gstin := "33AOSPA7307Q1ZI"
+	database.Create(&models.Gst{Gstin: gstin, Status: "Active", BaseModel: models.BaseModel{CreatedBy: 1}})
+	database.Create(&models.GstStatus{Gstin: gstin, Rtntype: constants.GSTR1, RetPrd: "012024", Status: constants.CallForInvoice})
+
+	// another request moves the return on between the status being read and updated
+	changed := false
+	database.Callback().Update().Before("gorm:update").Register("test:change_status", func(db *gorm.DB) {
+		if changed || db.Statement.Table != "gst_statuses" {
+			return
+		}
+		changed = true
+
+		db.Session(&gorm.Session{NewDB: true}).Exec("UPDATE gst_statuses SET status = ? WHERE gstin = ?", constants.InvoiceReceived, gstin)
+	})
+
+	req := &dto.UpdateGstReturnStatusRequest{Gstin: gstin, ReturnType: constants.GSTR1, Status: constants.InvoiceReceived}
+	req.ModifiedBy = 1
+
+	_, err := service.UpdateGstStatus(context.Background(), req)
+
+	var serviceErr *service_errors.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.EndUserMessage != service_errors.StatusChanged {
+		t.Fatalf("error = %v, want %s", err, service_errors.StatusChanged)
+	}
+
+	var transitions int64
+	database.Model(&models.GstStatusTransition{}).Count(&transitions)
+	if transitions != 0 {
+		t.Errorf("transitions = %d, want 0", transitions)
+	}
+
+	// the simulated change ran in the transaction rolled back, so the return is still where it was
+	if _, err = service.UpdateGstStatus(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	database.Model(&models.GstStatusTransition{}).Count(&transitions)
+	if transitions != 1 {
+		t.Errorf("transitions = %d, want 1", transitions)
+	}
+}
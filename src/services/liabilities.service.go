@@ -0,0 +1,266 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	gst_calendar "github.com/jaganathanb/dapps-api/pkg/gst-calendar"
+	gst_liability "github.com/jaganathanb/dapps-api/pkg/gst-liability"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LiabilityService struct {
+	logger          logging.Logger
+	cfg             *config.Config
+	database        *gorm.DB
+	calendarService *CalendarService
+}
+
+var liabilityService *LiabilityService
+var liabilityServiceOnce sync.Once
+
+func NewLiabilityService(cfg *config.Config) *LiabilityService {
+	liabilityServiceOnce.Do(func() {
+		liabilityService = &LiabilityService{
+			logger:          logging.NewLogger(cfg),
+			cfg:             cfg,
+			database:        db.GetDb(),
+			calendarService: NewCalendarService(cfg),
+		}
+	})
+
+	return liabilityService
+}
+
+// GetLiabilities estimates the late fee and interest of the pending returns of a GSTIN
+func (s *LiabilityService) GetLiabilities(req *dto.GetLiabilitiesRequest) (*dto.GstLiabilities, error) {
+	var gsts []models.Gst
+	err := s.database.Model(&models.Gst{}).Where("gstin = ?", req.Gstin).Preload("GstStatuses").Find(&gsts).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	if len(gsts) == 0 {
+		return nil, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	amounts, err := s.getTaxAmounts([]string{req.Gstin})
+	if err != nil {
+		return nil, err
+	}
+
+	turnover := turnoverOf(gsts[0])
+	if req.Turnover != nil {
+		turnover = *req.Turnover
+	}
+
+	liabilities := calculateLiabilities(s.calendarService.Calendar(), gsts[0], amounts, turnover, asOfOrNow(req.AsOf))
+
+	return &liabilities, nil
+}
+
+// GetLiabilitySummary estimates the late fee and interest of the pending returns of all the active GSTINs
+func (s *LiabilityService) GetLiabilitySummary(req *dto.GetLiabilitySummaryRequest) (*dto.LiabilitySummary, error) {
+	var gsts []models.Gst
+	err := s.database.Model(&models.Gst{}).Where("locked = ? AND gsts.status = ?", false, "Active").Preload("GstStatuses").Find(&gsts).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	amounts, err := s.getTaxAmounts(lo.Map(gsts, func(gst models.Gst, i int) string { return gst.Gstin }))
+	if err != nil {
+		return nil, err
+	}
+
+	cal := s.calendarService.Calendar()
+	asOf := asOfOrNow(req.AsOf)
+
+	summary := &dto.LiabilitySummary{AsOf: asOf, Gsts: []dto.GstLiabilities{}}
+	returnTypes := map[constants.GstReturnType]*dto.ReturnTypeLiability{}
+
+	for _, gst := range gsts {
+		liabilities := calculateLiabilities(cal, gst, amounts, turnoverOf(gst), asOf)
+		if len(liabilities.Liabilities) == 0 {
+			continue
+		}
+
+		for _, l := range liabilities.Liabilities {
+			rt, ok := returnTypes[l.ReturnType]
+			if !ok {
+				rt = &dto.ReturnTypeLiability{ReturnType: l.ReturnType}
+				returnTypes[l.ReturnType] = rt
+			}
+
+			rt.Returns++
+			rt.LateFee = roundAmount(rt.LateFee + l.LateFee)
+			rt.Interest = roundAmount(rt.Interest + l.Interest)
+		}
+
+		summary.TotalLateFee = roundAmount(summary.TotalLateFee + liabilities.TotalLateFee)
+		summary.TotalInterest = roundAmount(summary.TotalInterest + liabilities.TotalInterest)
+		summary.Estimate = summary.Estimate || liabilities.Estimate
+
+		liabilities.Liabilities = nil
+		summary.Gsts = append(summary.Gsts, liabilities)
+	}
+
+	summary.Total = roundAmount(summary.TotalLateFee + summary.TotalInterest)
+	summary.ReturnTypes = lo.Map(lo.Values(returnTypes), func(rt *dto.ReturnTypeLiability, i int) dto.ReturnTypeLiability { return *rt })
+
+	slices.SortFunc(summary.ReturnTypes, func(a, b dto.ReturnTypeLiability) int { return cmp.Compare(b.LateFee+b.Interest, a.LateFee+a.Interest) })
+	slices.SortFunc(summary.Gsts, func(a, b dto.GstLiabilities) int { return cmp.Compare(b.Total, a.Total) })
+
+	return summary, nil
+}
+
+// UpdateTaxAmount records the tax a GSTIN has to pay with a return, the interest on late payment is charged on it
+func (s *LiabilityService) UpdateTaxAmount(req *dto.UpdateTaxAmountRequest) (bool, error) {
+	exists, err := gstExists(s.database, req.Gstin)
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return false, err
+	}
+	if !exists {
+		return false, &service_errors.ServiceError{EndUserMessage: fmt.Sprintf(service_errors.GstNotFound, req.Gstin)}
+	}
+
+	_, err = time.Parse(constants.TAXPRD, req.ReturnPeriod)
+	if err != nil {
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidReturnPeriod, Err: err}
+	}
+
+	amount := models.GstTaxAmount{
+		Gstin:     req.Gstin,
+		Rtntype:   req.ReturnType,
+		RetPrd:    req.ReturnPeriod,
+		TaxAmount: req.TaxAmount,
+		NilReturn: req.Nil,
+		BaseModel: models.BaseModel{CreatedBy: req.ModifiedBy, ModifiedAt: time.Now()},
+	}
+
+	err = s.database.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "gstin"}, {Name: "rtntype"}, {Name: "ret_prd"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tax_amount", "nil_return", "modified_at"}),
+	}).Create(&amount).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// getTaxAmounts returns the tax amounts entered for the GSTINs by GSTIN, return type and period
+func (s *LiabilityService) getTaxAmounts(gstins []string) (map[string]models.GstTaxAmount, error) {
+	var amounts []models.GstTaxAmount
+
+	err := s.database.Model(&models.GstTaxAmount{}).Where("gstin IN ?", gstins).Find(&amounts).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return lo.SliceToMap(amounts, func(amount models.GstTaxAmount) (string, models.GstTaxAmount) {
+		return taxAmountKey(amount.Gstin, amount.Rtntype, amount.RetPrd), amount
+	}), nil
+}
+
+func calculateLiabilities(cal *gst_calendar.Calendar, gst models.Gst, amounts map[string]models.GstTaxAmount, turnover float64, asOf time.Time) dto.GstLiabilities {
+	liabilities := dto.GstLiabilities{Gstin: gst.Gstin, Name: gst.Name, AsOf: asOf, Liabilities: []dto.Liability{}}
+	frequency := frequencyOf(gst)
+
+	for _, st := range gst.GstStatuses {
+		for _, retPrd := range st.PendingReturns {
+			period, err := time.Parse(constants.TAXPRD, retPrd)
+			if err != nil {
+				continue
+			}
+
+			amount := amounts[taxAmountKey(gst.Gstin, st.Rtntype, retPrd)]
+			due := cal.DueDate(st.Rtntype, frequency, gst.Gstin, period)
+
+			l := gst_liability.Calculate(gst_liability.Return{
+				ReturnType: st.Rtntype,
+				Period:     period,
+				DueDate:    due.DueDate,
+				Nil:        amount.NilReturn,
+				TaxAmount:  amount.TaxAmount,
+			}, turnover, asOf)
+
+			if l.DaysLate == 0 {
+				continue
+			}
+
+			liabilities.Liabilities = append(liabilities.Liabilities, dto.Liability{
+				ReturnType:    l.ReturnType,
+				ReturnPeriod:  retPrd,
+				DueDate:       l.DueDate,
+				DaysLate:      l.DaysLate,
+				Nil:           l.Nil,
+				TaxAmount:     l.TaxAmount,
+				LateFee:       l.LateFee,
+				LateFeeCapped: l.Capped,
+				Interest:      l.Interest,
+				Total:         roundAmount(l.Total()),
+				// GSTR-9 late fees run without a cap until the turnover is set
+				LateFeeUncapped: l.Uncapped,
+			})
+
+			liabilities.Estimate = liabilities.Estimate || l.Uncapped
+			liabilities.TotalLateFee = roundAmount(liabilities.TotalLateFee + l.LateFee)
+			liabilities.TotalInterest = roundAmount(liabilities.TotalInterest + l.Interest)
+		}
+	}
+
+	liabilities.Total = roundAmount(liabilities.TotalLateFee + liabilities.TotalInterest)
+
+	return liabilities
+}
+
+// turnoverOf is the turnover set on the GSTIN, the late fees are estimated without the caps of the turnover
+// when it is not set
+func turnoverOf(gst models.Gst) float64 {
+	if gst.Turnover == nil {
+		return gst_liability.UnknownTurnover
+	}
+
+	return *gst.Turnover
+}
+
+func taxAmountKey(gstin string, returnType constants.GstReturnType, retPrd string) string {
+	return fmt.Sprintf("%s|%s|%s", gstin, returnType, retPrd)
+}
+
+func asOfOrNow(asOf time.Time) time.Time {
+	if asOf.IsZero() {
+		return time.Now()
+	}
+
+	return asOf
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func gstExists(database *gorm.DB, gstin string) (bool, error) {
+	var count int64
+	err := database.Model(&models.Gst{}).Where("gstin = ?", gstin).Count(&count).Error
+
+	return count > 0, err
+}
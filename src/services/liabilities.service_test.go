@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+	sqlite_custom_type "github.com/jaganathanb/dapps-api/pkg/sqlite-custom-type"
+)
+
+func TestGetLiabilitySummaryTurnover(t *testing.T) {
+	service, database := newFixtureGstService(t)
+	liabilities := &LiabilityService{
+		logger:          service.base.Logger,
+		cfg:             service.base.Config,
+		database:        database,
+		calendarService: service.calendarService,
+	}
+
+	gstin := "33AOSPA7307Q1ZI"
+	database.Create(&models.Gst{Gstin: gstin, Status: "Active", BaseModel: models.BaseModel{CreatedBy: 1}})
+	database.Create(&models.GstStatus{Gstin: gstin, Rtntype: constants.GSTR9, RetPrd: "032023", Status: constants.CallForInvoice,
+		PendingReturns: sqlite_custom_type.SqliteStrArray{"032023"}})
+
+	req := &dto.GetLiabilitySummaryRequest{AsOf: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)}
+
+	summary, err := liabilities.GetLiabilitySummary(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Estimate || len(summary.Gsts) != 1 || !summary.Gsts[0].Estimate {
+		t.Fatalf("summary = %+v, want an estimate without the turnover", summary)
+	}
+	uncapped := summary.TotalLateFee
+
+	turnover := 4e7
+	update := &dto.UpdateGstTurnoverRequest{Gstin: gstin, Turnover: &turnover}
+	update.ModifiedBy = 1
+	if _, err = service.UpdateGstTurnover(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err = liabilities.GetLiabilitySummary(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Estimate || summary.Gsts[0].Estimate {
+		t.Errorf("summary is an estimate with the turnover of the GSTIN")
+	}
+	// 0.04% of the turnover, CGST and SGST together
+	if summary.TotalLateFee != 16000 || summary.TotalLateFee >= uncapped {
+		t.Errorf("late fee = %v, want 16000 capped by the turnover, was %v uncapped", summary.TotalLateFee, uncapped)
+	}
+}
@@ -1,13 +1,16 @@
 package services
 
 import (
+	"context"
 	"sync"
 
 	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/common"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/audit"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 	"gorm.io/gorm"
 )
@@ -36,8 +39,8 @@ func NewNotificationsService(cfg *config.Config) *NotificationsService {
 }
 
 // Add notifications
-func (s *NotificationsService) AddNotification(req *dto.NotificationsPayload) (bool, error) {
-	tx := s.database.Begin()
+func (s *NotificationsService) AddNotification(ctx context.Context, req *dto.NotificationsPayload) (bool, error) {
+	tx := s.database.WithContext(audit.WithActor(ctx, req.CreatedBy)).Begin()
 
 	notifications := models.Notifications{
 		Message:     req.Message,
@@ -45,6 +48,8 @@ func (s *NotificationsService) AddNotification(req *dto.NotificationsPayload) (b
 		Title:       req.Title,
 		UserId:      req.UserId,
 		IsRead:      req.IsRead,
+		Gstin:       req.Gstin,
+		ErrorCode:   string(req.ErrorCode),
 		BaseModel: models.BaseModel{
 			CreatedBy: req.BaseDto.CreatedBy,
 		},
@@ -63,10 +68,16 @@ func (s *NotificationsService) AddNotification(req *dto.NotificationsPayload) (b
 }
 
 // Get notifications
-func (s *NotificationsService) GetNotifications(userId int) ([]dto.NotificationsPayload, error) {
+func (s *NotificationsService) GetNotifications(userId int, errorCode constants.ScrapeErrorCode) ([]dto.NotificationsPayload, error) {
 	notifications := []models.Notifications{}
 
-	err := s.database.Model(&models.Notifications{}).Where("deleted_at is null AND (user_id = ? OR user_id = -1)", userId).Find(&notifications).Error
+	query := s.database.Model(&models.Notifications{}).Where("deleted_at is null AND (user_id = ? OR user_id = -1)", userId)
+
+	if errorCode != "" {
+		query = query.Where("error_code = ?", errorCode)
+	}
+
+	err := query.Find(&notifications).Error
 
 	if err != nil {
 		return []dto.NotificationsPayload{}, nil
@@ -83,7 +94,7 @@ func (s *NotificationsService) GetNotifications(userId int) ([]dto.Notifications
 }
 
 // Update notifications
-func (s *NotificationsService) UpdateNotifications(req *dto.NotificationsPayload) (bool, error) {
+func (s *NotificationsService) UpdateNotifications(ctx context.Context, req *dto.NotificationsPayload) (bool, error) {
 	var notifications models.Notifications
 	err := s.database.Model(&models.Notifications{}).Where("id = ?", req.Id).First(&notifications).Error
 
@@ -91,7 +102,7 @@ func (s *NotificationsService) UpdateNotifications(req *dto.NotificationsPayload
 		return false, err
 	}
 
-	tx := s.database.Begin()
+	tx := s.database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
 
 	notifications.IsRead = req.IsRead
 	notifications.DeletedAt = req.DeletedAt
@@ -109,7 +120,7 @@ func (s *NotificationsService) UpdateNotifications(req *dto.NotificationsPayload
 }
 
 // Delete notifications
-func (s *NotificationsService) DeleteNotifications(req *dto.NotificationsPayload) (bool, error) {
+func (s *NotificationsService) DeleteNotifications(ctx context.Context, req *dto.NotificationsPayload) (bool, error) {
 	var notifications models.Notifications
 	err := s.database.Model(&models.Notifications{}).Where("id = ?", req.Id).First(&notifications).Error
 
@@ -117,7 +128,7 @@ func (s *NotificationsService) DeleteNotifications(req *dto.NotificationsPayload
 		return false, err
 	}
 
-	tx := s.database.Begin()
+	tx := s.database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
 
 	err = tx.Model(&models.Notifications{}).Where("id = ?", req.Id).Delete(&notifications).Error
 
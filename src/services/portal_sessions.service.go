@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PortalSessionService is the store of the GST portal sessions reused by the scrapper
+type PortalSessionService struct {
+	logger   logging.Logger
+	cfg      *config.Config
+	database *gorm.DB
+}
+
+var portalSessionService *PortalSessionService
+var portalSessionServiceOnce sync.Once
+
+func NewPortalSessionService(cfg *config.Config) *PortalSessionService {
+	portalSessionServiceOnce.Do(func() {
+		portalSessionService = &PortalSessionService{
+			logger:   logging.NewLogger(cfg),
+			cfg:      cfg,
+			database: db.GetDb(),
+		}
+	})
+
+	return portalSessionService
+}
+
+func (s *PortalSessionService) Load(username string) ([]*proto.NetworkCookie, bool) {
+	var session models.PortalSession
+	err := s.database.Where("username_hash = ? AND expires_at > ?", encryption.GetKeyring().Hash(username), time.Now()).First(&session).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		}
+
+		return nil, false
+	}
+
+	var cookies []*proto.NetworkCookie
+	err = json.Unmarshal([]byte(session.Cookies), &cookies)
+	if err != nil {
+		s.logger.Errorf("Could not read portal session of %s. %s", username, err.Error())
+		return nil, false
+	}
+
+	return cookies, len(cookies) > 0
+}
+
+func (s *PortalSessionService) Save(username string, cookies []*proto.NetworkCookie, expiresAt time.Time) error {
+	data, err := json.Marshal(cookies)
+	if err != nil {
+		return err
+	}
+
+	session := models.PortalSession{
+		UsernameHash: encryption.GetKeyring().Hash(username),
+		Username:     username,
+		Cookies:      string(data),
+		ExpiresAt:    expiresAt,
+		BaseModel:    models.BaseModel{CreatedAt: time.Now(), ModifiedAt: time.Now()},
+	}
+
+	err = s.database.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "username_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cookies", "expires_at", "modified_at"}),
+	}).Create(&session).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+	}
+
+	return err
+}
+
+func (s *PortalSessionService) Remove(username string) error {
+	err := s.database.Where("username_hash = ?", encryption.GetKeyring().Hash(username)).Delete(&models.PortalSession{}).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Delete, err.Error(), nil)
+	}
+
+	return err
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+type SavedViewService struct {
+	logger   logging.Logger
+	database *gorm.DB
+}
+
+var savedViewService *SavedViewService
+var savedViewServiceOnce sync.Once
+
+func NewSavedViewService(cfg *config.Config) *SavedViewService {
+	savedViewServiceOnce.Do(func() {
+		savedViewService = &SavedViewService{
+			logger:   logging.NewLogger(cfg),
+			database: db.GetDb(),
+		}
+	})
+
+	return savedViewService
+}
+
+// GetSavedViews lists the views of the user and the ones shared to the roles of the user, of the resource
+// or of all of them
+func (s *SavedViewService) GetSavedViews(ctx context.Context, userId int, req *dto.GetSavedViewsRequest) ([]dto.SavedView, error) {
+	query, err := s.visibleViews(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Resource != "" {
+		query = query.Where("resource = ?", req.Resource)
+	}
+
+	views := []models.SavedView{}
+	err = query.Order("name").Find(&views).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return lo.Map(views, func(view models.SavedView, i int) dto.SavedView {
+		return prepareSavedViewDTO(view, userId)
+	}), nil
+}
+
+// GetSavedView returns a view the user can see
+func (s *SavedViewService) GetSavedView(ctx context.Context, userId int, id int) (*dto.SavedView, error) {
+	view, err := s.visibleView(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := prepareSavedViewDTO(*view, userId)
+
+	return &res, nil
+}
+
+// CreateSavedView saves a view of the user, the names of the views of a user are unique by resource
+func (s *SavedViewService) CreateSavedView(ctx context.Context, req *dto.CreateSavedViewRequest) (*dto.SavedView, error) {
+	view := &models.SavedView{
+		UserId:    req.CreatedBy,
+		Resource:  req.Resource,
+		BaseModel: models.BaseModel{CreatedBy: req.CreatedBy},
+	}
+
+	err := s.assign(ctx, view, &req.UpdateSavedViewRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.WithContext(ctx).Create(view).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+		return nil, err
+	}
+
+	res := prepareSavedViewDTO(*view, req.CreatedBy)
+
+	return &res, nil
+}
+
+// UpdateSavedView changes a view of the user, the views shared to the user can not be changed
+func (s *SavedViewService) UpdateSavedView(ctx context.Context, userId int, id int, req *dto.UpdateSavedViewRequest) (*dto.SavedView, error) {
+	view, err := s.ownedView(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.assign(ctx, view, req)
+	if err != nil {
+		return nil, err
+	}
+
+	view.ModifiedAt = time.Now().UTC()
+	view.ModifiedBy = &sql.NullInt64{Int64: int64(userId), Valid: true}
+
+	err = s.database.WithContext(ctx).
+		Select("name", "filter", "columns", "shared_roles", "modified_at", "modified_by").
+		Updates(view).
+		Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return nil, err
+	}
+
+	res := prepareSavedViewDTO(*view, userId)
+
+	return &res, nil
+}
+
+// DeleteSavedView deletes a view of the user
+func (s *SavedViewService) DeleteSavedView(ctx context.Context, userId int, id int) (bool, error) {
+	view, err := s.ownedView(ctx, userId, id)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.database.WithContext(ctx).Model(view).Updates(map[string]interface{}{
+		"deleted_by": &sql.NullInt64{Int64: int64(userId), Valid: true},
+		"deleted_at": time.Now().UTC(),
+	}).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Delete, err.Error(), nil)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ApplySavedView merges the view into the sort and filters of a page of the resource. The filters sent on a
+// column replace the ones of the view on the column, the filter trees are ANDed and the sort sent replaces
+// the one of the view
+func (s *SavedViewService) ApplySavedView(ctx context.Context, userId int, id int, resource constants.ViewResource, req *dto.PaginationInputWithFilter) error {
+	view, err := s.visibleView(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	if view.Resource != resource {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+
+	filter, err := savedViewFilter(*view)
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return err
+	}
+
+	req.DynamicFilter = mergeDynamicFilters(filter, req.DynamicFilter)
+
+	return nil
+}
+
+// assign sets the fields of the view from the request, after checking its name is not taken and the roles
+// it is shared to exist
+func (s *SavedViewService) assign(ctx context.Context, view *models.SavedView, req *dto.UpdateSavedViewRequest) error {
+	database := s.database.WithContext(ctx)
+	name := strings.TrimSpace(req.Name)
+
+	var count int64
+	err := database.Model(&models.SavedView{}).
+		Where("user_id = ? AND resource = ? AND name = ? AND id <> ? AND deleted_by IS NULL", view.UserId, view.Resource, name, view.Id).
+		Count(&count).
+		Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return err
+	}
+	if count > 0 {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.SavedViewExists}
+	}
+
+	roles := lo.Uniq(req.SharedRoles)
+	if len(roles) > 0 {
+		err = database.Model(&models.Role{}).Where("name IN ? AND deleted_by IS NULL", roles).Count(&count).Error
+		if err != nil {
+			s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+			return err
+		}
+		if int(count) != len(roles) {
+			return &service_errors.ServiceError{EndUserMessage: service_errors.RoleNotFound}
+		}
+	}
+
+	filter, err := json.Marshal(req.DynamicFilter)
+	if err != nil {
+		return err
+	}
+
+	view.Name = name
+	view.Filter = string(filter)
+	view.Columns = req.Columns
+	view.SharedRoles = roles
+
+	return nil
+}
+
+// visibleViews queries the views of the user and the ones shared to a role of the user
+func (s *SavedViewService) visibleViews(ctx context.Context, userId int) (*gorm.DB, error) {
+	database := s.database.WithContext(ctx)
+
+	roles := []string{}
+	err := database.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id AND user_roles.deleted_by IS NULL").
+		Where("user_roles.user_id = ? AND roles.deleted_by IS NULL", userId).
+		Pluck("roles.name", &roles).
+		Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	// the roles are stored as a JSON array, a quoted name only matches a role of that name
+	conditions := []string{"user_id = ?"}
+	values := []interface{}{userId}
+	for _, role := range roles {
+		quoted, _ := json.Marshal(role)
+		conditions = append(conditions, "shared_roles LIKE ?")
+		values = append(values, "%"+string(quoted)+"%")
+	}
+
+	return database.Model(&models.SavedView{}).
+		Where("deleted_by IS NULL").
+		Where(strings.Join(conditions, " OR "), values...), nil
+}
+
+func (s *SavedViewService) visibleView(ctx context.Context, userId int, id int) (*models.SavedView, error) {
+	query, err := s.visibleViews(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.SavedView{}
+	err = query.Where("id = ?", id).First(view).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return view, nil
+}
+
+// ownedView returns a view of the user, a view only shared to the user is denied
+func (s *SavedViewService) ownedView(ctx context.Context, userId int, id int) (*models.SavedView, error) {
+	view, err := s.visibleView(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if view.UserId != userId {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+
+	return view, nil
+}
+
+func savedViewFilter(view models.SavedView) (dto.DynamicFilter, error) {
+	filter := dto.DynamicFilter{}
+	if view.Filter == "" {
+		return filter, nil
+	}
+
+	err := json.Unmarshal([]byte(view.Filter), &filter)
+
+	return filter, err
+}
+
+// mergeDynamicFilters applies the sort and filters sent over the ones of a view
+func mergeDynamicFilters(view dto.DynamicFilter, adhoc dto.DynamicFilter) dto.DynamicFilter {
+	merged := dto.DynamicFilter{Sort: view.Sort, Filter: map[string]dto.Filter{}, Where: view.Where}
+
+	if adhoc.Sort != nil && len(*adhoc.Sort) > 0 {
+		merged.Sort = adhoc.Sort
+	}
+
+	for name, filter := range view.Filter {
+		merged.Filter[name] = filter
+	}
+	for name, filter := range adhoc.Filter {
+		merged.Filter[name] = filter
+	}
+
+	switch {
+	case view.Where != nil && adhoc.Where != nil:
+		merged.Where = &dto.FilterNode{Operator: "and", Nodes: []dto.FilterNode{*view.Where, *adhoc.Where}}
+	case adhoc.Where != nil:
+		merged.Where = adhoc.Where
+	}
+
+	return merged
+}
+
+func prepareSavedViewDTO(view models.SavedView, userId int) dto.SavedView {
+	// a view saved with a filter that can not be read any more is still listed, without it
+	filter, _ := savedViewFilter(view)
+
+	roles := []string(view.SharedRoles)
+	if roles == nil {
+		roles = []string{}
+	}
+
+	return dto.SavedView{
+		Id:            view.Id,
+		Resource:      view.Resource,
+		Name:          view.Name,
+		DynamicFilter: filter,
+		Columns:       view.Columns,
+		SharedRoles:   roles,
+		Owned:         view.UserId == userId,
+		CreatedAt:     view.CreatedAt,
+		ModifiedAt:    view.ModifiedAt,
+	}
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jaganathanb/dapps-api/api/dto"
+	"github.com/jaganathanb/dapps-api/common"
+	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/db"
+	"github.com/jaganathanb/dapps-api/data/models"
+	gst_scrapper "github.com/jaganathanb/dapps-api/pkg/gst-scrapper"
+	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+type ScrapeRunService struct {
+	logger   logging.Logger
+	cfg      *config.Config
+	database *gorm.DB
+	controls map[int]*scrapeRunControl
+	mutex    sync.Mutex
+}
+
+// scrapeRunControl steers a run which is in progress
+type scrapeRunControl struct {
+	cancel context.CancelFunc
+	gate   *common.PauseGate
+}
+
+var scrapeRunService *ScrapeRunService
+var scrapeRunServiceOnce sync.Once
+
+func NewScrapeRunService(cfg *config.Config) *ScrapeRunService {
+	scrapeRunServiceOnce.Do(func() {
+		scrapeRunService = &ScrapeRunService{
+			logger:   logging.NewLogger(cfg),
+			cfg:      cfg,
+			database: db.GetDb(),
+			controls: map[int]*scrapeRunControl{},
+		}
+
+		scrapeRunService.reconcileRuns()
+	})
+
+	return scrapeRunService
+}
+
+// StartRun records a new scrape run with a pending item for each of the GSTINs
+func (s *ScrapeRunService) StartRun(userId int, trigger constants.ScrapeRunTrigger, gstins []string) (*models.ScrapeRun, error) {
+	run := &models.ScrapeRun{
+		Trigger:   trigger,
+		Status:    constants.ScrapeRunRunning,
+		StartedAt: time.Now(),
+		Total:     len(gstins),
+		BaseModel: models.BaseModel{CreatedBy: userId},
+		Items: lo.Map(gstins, func(gstin string, i int) models.ScrapeRunItem {
+			return models.ScrapeRunItem{
+				Gstin:     gstin,
+				Status:    constants.ScrapeItemPending,
+				BaseModel: models.BaseModel{CreatedBy: userId},
+			}
+		}),
+	}
+
+	tx := s.database.Begin()
+
+	err := tx.Create(run).Error
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+		return nil, err
+	}
+
+	tx.Commit()
+
+	return run, nil
+}
+
+// Track returns the context the run has to be scrapped with, so it can be cancelled, paused and resumed
+func (s *ScrapeRunService) Track(runId int) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	gate := common.NewPauseGate()
+
+	s.mutex.Lock()
+	s.controls[runId] = &scrapeRunControl{cancel: cancel, gate: gate}
+	s.mutex.Unlock()
+
+	return common.WithPauseGate(ctx, gate)
+}
+
+// CancelRun stops the run. GSTINs which are not yet done are reported as cancelled
+func (s *ScrapeRunService) CancelRun(runId int) (*dto.ScrapeRun, error) {
+	control, err := s.control(runId)
+	if err == nil {
+		control.cancel()
+
+		return s.GetScrapeRunById(runId)
+	}
+
+	// a run left open without anyone scrapping it is closed straight in the database
+	run, e := s.GetScrapeRunById(runId)
+	if e != nil {
+		return nil, e
+	}
+
+	if run.Status != constants.ScrapeRunRunning && run.Status != constants.ScrapeRunPaused {
+		return nil, err
+	}
+
+	err = s.abandonRun(runId, constants.ScrapeRunCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetScrapeRunById(runId)
+}
+
+// PauseRun holds the GSTINs of the run which are not yet started until the run is resumed
+func (s *ScrapeRunService) PauseRun(runId int) (*dto.ScrapeRun, error) {
+	control, err := s.control(runId)
+	if err != nil {
+		return nil, err
+	}
+
+	control.gate.Pause()
+
+	return s.setRunStatus(runId, constants.ScrapeRunPaused)
+}
+
+func (s *ScrapeRunService) ResumeRun(runId int) (*dto.ScrapeRun, error) {
+	control, err := s.control(runId)
+	if err != nil {
+		return nil, err
+	}
+
+	control.gate.Resume()
+
+	return s.setRunStatus(runId, constants.ScrapeRunRunning)
+}
+
+func (s *ScrapeRunService) control(runId int) (*scrapeRunControl, error) {
+	s.mutex.Lock()
+	control, found := s.controls[runId]
+	s.mutex.Unlock()
+
+	if found {
+		return control, nil
+	}
+
+	_, err := s.GetScrapeRunById(runId)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, &service_errors.ServiceError{EndUserMessage: service_errors.ScrapeRunNotActive}
+}
+
+func (s *ScrapeRunService) setRunStatus(runId int, status constants.ScrapeRunStatus) (*dto.ScrapeRun, error) {
+	tx := s.database.Begin()
+
+	err := tx.Model(&models.ScrapeRun{}).
+		Where("id = ?", runId).
+		Updates(map[string]interface{}{"status": status, "modified_at": time.Now()}).Error
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return nil, err
+	}
+
+	tx.Commit()
+
+	return s.GetScrapeRunById(runId)
+}
+
+// reconcileRuns closes the runs which were left running or paused by a previous process. Nothing scraps them
+// anymore, so they are marked failed and their pending GSTINs cancelled
+func (s *ScrapeRunService) reconcileRuns() {
+	var runs []models.ScrapeRun
+	err := s.database.Model(&models.ScrapeRun{}).
+		Where("status IN ? AND deleted_by is null", []constants.ScrapeRunStatus{constants.ScrapeRunRunning, constants.ScrapeRunPaused}).
+		Select("id").
+		Find(&runs).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return
+	}
+
+	for _, run := range runs {
+		err = s.abandonRun(run.Id, constants.ScrapeRunFailed)
+		if err != nil {
+			return
+		}
+
+		s.logger.Infof("Closed scrape run %d left open by a previous process", run.Id)
+	}
+}
+
+// abandonRun cancels the pending GSTINs of a run nobody scraps and closes it with the given status
+func (s *ScrapeRunService) abandonRun(runId int, status constants.ScrapeRunStatus) error {
+	tx := s.database.Begin()
+
+	err := tx.Model(&models.ScrapeRunItem{}).
+		Where("scrape_run_id = ? AND status = ?", runId, constants.ScrapeItemPending).
+		Updates(map[string]interface{}{"status": constants.ScrapeItemCancelled, "modified_at": time.Now()}).Error
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return err
+	}
+
+	tx.Commit()
+
+	return s.FinishRun(runId, status)
+}
+
+// RecordItem stores the outcome of a GSTIN scrapped as part of the run
+func (s *ScrapeRunService) RecordItem(runId int, detail gst_scrapper.GstDetail) error {
+	status := constants.ScrapeItemSucceeded
+	if detail.Cancelled {
+		status = constants.ScrapeItemCancelled
+	} else if detail.Error != nil {
+		status = constants.ScrapeItemFailed
+	}
+
+	values := map[string]interface{}{
+		"status":        status,
+		"error_message": nil,
+		"error_class":   nil,
+		"error_code":    nil,
+		"attempts":      detail.Attempts,
+		"duration_ms":   detail.Duration.Milliseconds(),
+		"started_at":    detail.StartedAt,
+		"finished_at":   detail.StartedAt.Add(detail.Duration),
+		"modified_at":   time.Now(),
+	}
+
+	if detail.Error != nil {
+		values["error_message"] = detail.Error.Error()
+		values["error_class"] = detail.Error.Class
+		values["error_code"] = detail.Error.Code
+	}
+
+	tx := s.database.Begin()
+
+	err := tx.Model(&models.ScrapeRunItem{}).
+		Where("scrape_run_id = ? AND gstin = ?", runId, detail.Gstin).
+		Updates(values).Error
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return err
+	}
+
+	if len(detail.Artifacts) > 0 {
+		err = s.saveArtifacts(tx, runId, detail)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+// saveArtifacts writes the artifacts under `server.gst.artifactsPath`/<run id>/<gstin> and records them against the run item
+func (s *ScrapeRunService) saveArtifacts(tx *gorm.DB, runId int, detail gst_scrapper.GstDetail) error {
+	var item models.ScrapeRunItem
+	err := tx.Model(&models.ScrapeRunItem{}).Where("scrape_run_id = ? AND gstin = ?", runId, detail.Gstin).First(&item).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return err
+	}
+
+	dir := filepath.Join(s.artifactsPath(), strconv.Itoa(runId), detail.Gstin)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		s.logger.Errorf("Could not create artifacts directory %s. %s", dir, err.Error())
+		return err
+	}
+
+	for _, artifact := range detail.Artifacts {
+		path := filepath.Join(dir, artifact.FileName)
+
+		err = os.WriteFile(path, artifact.Data, 0644)
+		if err != nil {
+			s.logger.Errorf("Could not write artifact %s. %s", path, err.Error())
+			return err
+		}
+
+		err = tx.Create(&models.ScrapeRunArtifact{
+			ScrapeRunItemId: item.Id,
+			Kind:            artifact.Kind,
+			FileName:        artifact.FileName,
+			ContentType:     artifact.ContentType,
+			Path:            path,
+			Size:            int64(len(artifact.Data)),
+			BaseModel:       models.BaseModel{CreatedBy: item.CreatedBy},
+		}).Error
+		if err != nil {
+			s.logger.Error(logging.Sqlite3, logging.Insert, err.Error(), nil)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ScrapeRunService) artifactsPath() string {
+	if s.cfg.Server.Gst.ArtifactsPath == "" {
+		return filepath.Join("data", "artifacts")
+	}
+
+	return s.cfg.Server.Gst.ArtifactsPath
+}
+
+// GetScrapeRunArtifact returns the artifact captured for one of the GSTINs of the run
+func (s *ScrapeRunService) GetScrapeRunArtifact(runId int, artifactId int) (*models.ScrapeRunArtifact, error) {
+	var artifact models.ScrapeRunArtifact
+	err := s.database.
+		Where("id = ? AND deleted_by is null", artifactId).
+		Where("scrape_run_item_id IN (?)", s.database.Model(&models.ScrapeRunItem{}).Select("id").Where("scrape_run_id = ?", runId)).
+		First(&artifact).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+		}
+
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	return &artifact, nil
+}
+
+// GetScrapeErrorSummary counts the failed GSTINs by the cause of failure
+func (s *ScrapeRunService) GetScrapeErrorSummary(req *dto.GetScrapeErrorSummaryRequest) ([]dto.ScrapeErrorSummary, error) {
+	query := s.database.Model(&models.ScrapeRunItem{}).Where("status = ? AND deleted_by is null", constants.ScrapeItemFailed)
+
+	if req.RunId != 0 {
+		query = query.Where("scrape_run_id = ?", req.RunId)
+	}
+
+	if !req.From.IsZero() {
+		query = query.Where("started_at >= ?", req.From)
+	}
+
+	if !req.To.IsZero() {
+		query = query.Where("started_at < ?", req.To.AddDate(0, 0, 1))
+	}
+
+	var items []models.ScrapeRunItem
+	err := query.Select("gstin", "error_code", "error_class").Find(&items).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	summary := []dto.ScrapeErrorSummary{}
+	for code, failed := range lo.GroupBy(items, func(item models.ScrapeRunItem) constants.ScrapeErrorCode { return item.ErrorCode }) {
+		summary = append(summary, dto.ScrapeErrorSummary{
+			Code:   code,
+			Class:  failed[0].ErrorClass,
+			Count:  len(failed),
+			Gstins: lo.Uniq(lo.Map(failed, func(item models.ScrapeRunItem, i int) string { return item.Gstin })),
+		})
+	}
+
+	slices.SortFunc(summary, func(a, b dto.ScrapeErrorSummary) int { return b.Count - a.Count })
+
+	return summary, nil
+}
+
+// HasFailedInARow tells whether the latest `runs` scrape attempts of the GSTIN all failed. Cancelled attempts are not counted
+func (s *ScrapeRunService) HasFailedInARow(gstin string, runs int) (bool, error) {
+	var items []models.ScrapeRunItem
+	err := s.database.Model(&models.ScrapeRunItem{}).
+		Where("gstin = ? AND status IN ?", gstin, []constants.ScrapeRunItemStatus{constants.ScrapeItemSucceeded, constants.ScrapeItemFailed}).
+		Order("id desc").
+		Limit(runs).
+		Select("status").
+		Find(&items).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return false, err
+	}
+
+	return len(items) == runs && lo.EveryBy(items, func(item models.ScrapeRunItem) bool { return item.Status == constants.ScrapeItemFailed }), nil
+}
+
+// FinishRun closes the run with the given status and the tally of its items
+func (s *ScrapeRunService) FinishRun(runId int, status constants.ScrapeRunStatus) error {
+	s.mutex.Lock()
+	if control, found := s.controls[runId]; found {
+		control.cancel()
+		delete(s.controls, runId)
+	}
+	s.mutex.Unlock()
+
+	var items []models.ScrapeRunItem
+	err := s.database.Model(&models.ScrapeRunItem{}).Where("scrape_run_id = ?", runId).Select("status").Find(&items).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return err
+	}
+
+	tx := s.database.Begin()
+
+	err = tx.Model(&models.ScrapeRun{}).
+		Where("id = ?", runId).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"finished_at": time.Now(),
+			"succeeded":   lo.CountBy(items, func(item models.ScrapeRunItem) bool { return item.Status == constants.ScrapeItemSucceeded }),
+			"failed":      lo.CountBy(items, func(item models.ScrapeRunItem) bool { return item.Status == constants.ScrapeItemFailed }),
+			"modified_at": time.Now(),
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error(logging.Sqlite3, logging.Update, err.Error(), nil)
+		return err
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+func (s *ScrapeRunService) GetScrapeRuns(req *dto.GetScrapeRunsRequest) (*dto.PagedList[dto.ScrapeRun], error) {
+	if req.PageNumber == 0 {
+		req.PageNumber = 1
+	}
+
+	if req.PageSize == 0 {
+		req.PageSize = 10
+	}
+
+	query := s.database.Model(&models.ScrapeRun{}).Where("deleted_by is null")
+
+	// struct conditions get quoted column names, `trigger` being a keyword
+	query = query.Where(&models.ScrapeRun{Trigger: req.Trigger, Status: req.Status})
+
+	if req.Gstin != "" {
+		query = query.Where("id IN (?)", s.database.Model(&models.ScrapeRunItem{}).Select("scrape_run_id").Where("gstin = ?", req.Gstin))
+	}
+
+	if req.ErrorCode != "" {
+		query = query.Where("id IN (?)", s.database.Model(&models.ScrapeRunItem{}).Select("scrape_run_id").Where("error_code = ?", req.ErrorCode))
+	}
+
+	var totalRows int64
+	err := query.Count(&totalRows).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	var runs []models.ScrapeRun
+	err = query.
+		Order("id desc").
+		Offset((req.PageNumber - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&runs).Error
+	if err != nil {
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	items := lo.Map(runs, func(run models.ScrapeRun, i int) dto.ScrapeRun { return prepareScrapeRunDTO(run) })
+
+	return NewPagedList(&items, totalRows, req.PageNumber, int64(req.PageSize)), nil
+}
+
+func (s *ScrapeRunService) GetScrapeRunById(id int) (*dto.ScrapeRun, error) {
+	var run models.ScrapeRun
+	err := s.database.
+		Preload("Items", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Preload("Items.Artifacts").
+		Where("id = ? AND deleted_by is null", id).
+		First(&run).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+		}
+
+		s.logger.Error(logging.Sqlite3, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	res := prepareScrapeRunDTO(run)
+
+	return &res, nil
+}
+
+func prepareScrapeRunDTO(run models.ScrapeRun) dto.ScrapeRun {
+	return dto.ScrapeRun{
+		Id:         run.Id,
+		Trigger:    run.Trigger,
+		Status:     run.Status,
+		StartedBy:  run.CreatedBy,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		Total:      run.Total,
+		Succeeded:  run.Succeeded,
+		Failed:     run.Failed,
+		Items: lo.Map(run.Items, func(item models.ScrapeRunItem, i int) dto.ScrapeRunItem {
+			return dto.ScrapeRunItem{
+				Id:           item.Id,
+				Gstin:        item.Gstin,
+				Status:       item.Status,
+				ErrorMessage: item.ErrorMessage,
+				ErrorClass:   item.ErrorClass,
+				ErrorCode:    item.ErrorCode,
+				Attempts:     item.Attempts,
+				DurationMs:   item.DurationMs,
+				StartedAt:    item.StartedAt,
+				FinishedAt:   item.FinishedAt,
+				Artifacts: lo.Map(item.Artifacts, func(artifact models.ScrapeRunArtifact, i int) dto.ScrapeRunArtifact {
+					return dto.ScrapeRunArtifact{
+						Id:          artifact.Id,
+						Kind:        artifact.Kind,
+						FileName:    artifact.FileName,
+						ContentType: artifact.ContentType,
+						Size:        artifact.Size,
+					}
+				}),
+			}
+		}),
+	}
+}
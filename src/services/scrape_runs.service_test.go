@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jaganathanb/dapps-api/constants"
+	"github.com/jaganathanb/dapps-api/data/models"
+)
+
+func TestReconcileRuns(t *testing.T) {
+	service, database := newFixtureGstService(t)
+	runs := service.scrapeRunService
+
+	for _, status := range []constants.ScrapeRunStatus{constants.ScrapeRunRunning, constants.ScrapeRunPaused, constants.ScrapeRunCompleted} {
+		run, err := runs.StartRun(1, constants.ManualTrigger, []string{"33AOSPA7307Q1ZI", "33AKBPA6032B1Z6"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		database.Model(&models.ScrapeRunItem{}).Where("scrape_run_id = ? AND gstin = ?", run.Id, "33AOSPA7307Q1ZI").Update("status", constants.ScrapeItemSucceeded)
+		database.Model(&models.ScrapeRun{}).Where("id = ?", run.Id).Update("status", status)
+	}
+
+	runs.reconcileRuns()
+
+	tests := []struct {
+		id     int
+		status constants.ScrapeRunStatus
+		item   constants.ScrapeRunItemStatus
+	}{
+		{1, constants.ScrapeRunFailed, constants.ScrapeItemCancelled},
+		{2, constants.ScrapeRunFailed, constants.ScrapeItemCancelled},
+		{3, constants.ScrapeRunCompleted, constants.ScrapeItemPending},
+	}
+
+	for _, test := range tests {
+		run, err := runs.GetScrapeRunById(test.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if run.Status != test.status {
+			t.Errorf("run %d status = %s, want %s", test.id, run.Status, test.status)
+		}
+		if run.Items[0].Status != constants.ScrapeItemSucceeded || run.Items[1].Status != test.item {
+			t.Errorf("run %d items = %s %s, want %s %s", test.id, run.Items[0].Status, run.Items[1].Status, constants.ScrapeItemSucceeded, test.item)
+		}
+	}
+}
+
+func TestCancelRunWithoutControl(t *testing.T) {
+	service, _ := newFixtureGstService(t)
+	runs := service.scrapeRunService
+
+	run, err := runs.StartRun(1, constants.ManualTrigger, []string{"33AOSPA7307Q1ZI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, err := runs.CancelRun(run.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != constants.ScrapeRunCancelled || cancelled.Items[0].Status != constants.ScrapeItemCancelled {
+		t.Errorf("run = %s with item %s, want %s", cancelled.Status, cancelled.Items[0].Status, constants.ScrapeRunCancelled)
+	}
+
+	if _, err = runs.CancelRun(run.Id); err == nil {
+		t.Errorf("cancelling a closed run succeeded")
+	}
+}
@@ -1,15 +1,22 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"sync"
 
+	"github.com/jaganathanb/dapps-api/api/dto"
 	"github.com/jaganathanb/dapps-api/common"
 	"github.com/jaganathanb/dapps-api/config"
+	"github.com/jaganathanb/dapps-api/constants"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	captcha_solver "github.com/jaganathanb/dapps-api/pkg/captcha-solver"
 	gst_scrapper "github.com/jaganathanb/dapps-api/pkg/gst-scrapper"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
+	service_errors "github.com/jaganathanb/dapps-api/pkg/service-errors"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +26,7 @@ type ScrapperService struct {
 	httpClient http.Client
 	DB         *gorm.DB
 	streamer   *StreamerService
-	scrapper   *gst_scrapper.GstScrapper
+	scrapper   gst_scrapper.ReturnsFetcher
 }
 
 var scrapperService *ScrapperService
@@ -31,14 +38,55 @@ func NewScrapperService(cfg *config.Config) *ScrapperService {
 		logger := logging.NewLogger(cfg)
 		client := http.Client{}
 		streamer := NewStreamerService(cfg)
-		scrapper := gst_scrapper.NewGstScrapper(cfg)
+		scrapper := gst_scrapper.NewReturnsFetcher(cfg, NewPortalSessionService(cfg))
 
 		scrapperService = &ScrapperService{logger: logger, cfg: cfg, httpClient: client, DB: DB, streamer: streamer, scrapper: scrapper}
+
+		if cfg.Captcha.Solver == captcha_solver.ManualSolverName {
+			captcha_solver.NewManualSolver(cfg).OnCaptcha(scrapperService.streamCaptcha)
+		}
 	})
 
 	return scrapperService
 }
 
-func (s *ScrapperService) ScrapGstSite(gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[gst_scrapper.GstDetail], error) {
-	return s.scrapper.ScrapGstReturnsDetail(gsts, useCredentialFromSettings)
+func (s *ScrapperService) ScrapGstSite(ctx context.Context, gsts []models.Gst, useCredentialFromSettings bool) (*common.SafeChannel[gst_scrapper.GstDetail], error) {
+	return s.scrapper.ScrapGstReturnsDetail(ctx, gsts, useCredentialFromSettings)
+}
+
+// HasPortalSettings tells whether the active backend has what it needs to reach the GST portal.
+// Recorded fixtures do not talk to the portal, so they never need the GST settings.
+func (s *ScrapperService) HasPortalSettings() bool {
+	if s.cfg.Server.Gst.Fetcher == gst_scrapper.FixtureFetcher {
+		return true
+	}
+
+	return s.cfg.Server.Gst.BaseUrl != "" && s.cfg.Server.Gst.Username != "" && s.cfg.Server.Gst.Password != ""
+}
+
+// AnswerCaptcha passes the code an operator read off a captcha to the scrapper waiting on it.
+func (s *ScrapperService) AnswerCaptcha(req *dto.CaptchaAnswerRequest) (bool, error) {
+	if s.cfg.Captcha.Solver != captcha_solver.ManualSolverName {
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.CaptchaNotManual}
+	}
+
+	if !captcha_solver.NewManualSolver(s.cfg).Answer(req.Id, req.Code) {
+		return false, &service_errors.ServiceError{EndUserMessage: service_errors.CaptchaNotFound}
+	}
+
+	return true, nil
+}
+
+func (s *ScrapperService) streamCaptcha(captcha captcha_solver.Captcha) {
+	s.streamer.StreamData(StreamMessage{
+		Code:        "CAPTCHA",
+		MessageType: constants.INFO,
+		Title:       captcha.Gstin,
+		Message:     fmt.Sprintf("Please enter the captcha to login into GST portal for GSTIN %s", captcha.Gstin),
+		Data: dto.CaptchaChallenge{
+			Id:    captcha.Id,
+			Gstin: captcha.Gstin,
+			Image: fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(captcha.Data)),
+		},
+	})
 }
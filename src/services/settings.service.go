@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"sync"
 
@@ -8,6 +9,8 @@ import (
 	"github.com/jaganathanb/dapps-api/config"
 	"github.com/jaganathanb/dapps-api/data/db"
 	"github.com/jaganathanb/dapps-api/data/models"
+	"github.com/jaganathanb/dapps-api/pkg/audit"
+	"github.com/jaganathanb/dapps-api/pkg/encryption"
 	"github.com/jaganathanb/dapps-api/pkg/logging"
 	scrap_scheduler "github.com/jaganathanb/dapps-api/pkg/scrap-scheduler"
 	"gorm.io/gorm"
@@ -17,7 +20,7 @@ type SettingsService struct {
 	logger     logging.Logger
 	cfg        *config.Config
 	database   *gorm.DB
-	gstService GstService
+	gstService *GstService
 	scheduler  scrap_scheduler.DAppsJobScheduler
 }
 
@@ -32,7 +35,7 @@ func NewSettingsService(cfg *config.Config) *SettingsService {
 			cfg:        cfg,
 			database:   database,
 			logger:     logger,
-			gstService: *NewGstService(cfg),
+			gstService: NewGstService(cfg),
 			scheduler:  *scrap_scheduler.NewDAppsJobScheduler(cfg),
 		}
 	})
@@ -55,7 +58,7 @@ func (s *SettingsService) GetSettings() (*dto.SettingsPayload, error) {
 	return &dto.SettingsPayload{
 		Crontab:     settings.Crontab,
 		GstUsername: settings.GstUsername,
-		GstPassword: settings.GstPassword,
+		GstPassword: encryption.MaskValue(settings.GstPassword),
 		GstBaseUrl:  settings.GstBaseUrl,
 		BaseDto: dto.BaseDto{
 			Id: settings.BaseModel.Id,
@@ -64,14 +67,14 @@ func (s *SettingsService) GetSettings() (*dto.SettingsPayload, error) {
 }
 
 // Get settings
-func (s *SettingsService) UpdateSettings(req *dto.SettingsPayload) (*dto.SettingsPayload, error) {
+func (s *SettingsService) UpdateSettings(ctx context.Context, req *dto.SettingsPayload) (*dto.SettingsPayload, error) {
 	var settings models.Settings
 
 	s.database.Model(&models.Settings{}).First(&settings)
 
 	crontab := settings.Crontab
 
-	tx := s.database.Begin()
+	tx := s.database.WithContext(audit.WithActor(ctx, req.ModifiedBy)).Begin()
 
 	settings.Id = req.Id
 	settings.ModifiedBy = &sql.NullInt64{
@@ -81,7 +84,9 @@ func (s *SettingsService) UpdateSettings(req *dto.SettingsPayload) (*dto.Setting
 	settings.CreatedBy = req.ModifiedBy
 	settings.Crontab = req.Crontab
 	settings.GstUsername = req.GstUsername
-	settings.GstPassword = req.GstPassword
+	if !encryption.IsMasked(req.GstPassword) {
+		settings.GstPassword = req.GstPassword
+	}
 	settings.GstBaseUrl = req.GstBaseUrl
 
 	err := tx.Model(&models.Settings{}).Where("id = ?", settings.Id).Save(&settings).Error
@@ -93,7 +98,7 @@ func (s *SettingsService) UpdateSettings(req *dto.SettingsPayload) (*dto.Setting
 
 	if crontab != req.Crontab {
 		s.scheduler.RemoveJobs("gst")
-		s.scheduler.AddJob(settings.Crontab, "gst", s.gstService.scrapGstPortal, -1, false)
+		s.scheduler.AddJob(settings.Crontab, "gst", s.gstService.ScrapGstPortalOnSchedule, -1, false)
 	}
 
 	tx.Commit()
@@ -105,7 +110,7 @@ func (s *SettingsService) UpdateSettings(req *dto.SettingsPayload) (*dto.Setting
 	return &dto.SettingsPayload{
 		Crontab:     settings.Crontab,
 		GstUsername: settings.GstUsername,
-		GstPassword: settings.GstPassword,
+		GstPassword: encryption.MaskValue(settings.GstPassword),
 		GstBaseUrl:  settings.GstBaseUrl,
 		BaseDto: dto.BaseDto{
 			Id: settings.BaseModel.Id,
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"sync"
@@ -25,6 +26,9 @@ type StreamMessage struct {
 	Title       string                            `json:"title"`
 	Code        string                            `json:"code"`
 	UserId      int                               `json:"userId"`
+	Gstin       string                            `json:"gstin,omitempty"`
+	ErrorCode   constants.ScrapeErrorCode         `json:"errorCode,omitempty"`
+	Data        any                               `json:"data,omitempty"`
 }
 
 type Streamer struct {
@@ -67,7 +71,7 @@ func (s *StreamerService) StreamData(message StreamMessage) {
 	}
 
 	if message.Code == "NOTIFICATION" {
-		s.notificationService.AddNotification(&dto.NotificationsPayload{Message: message.Message, MessageType: message.MessageType, Title: message.Title, UserId: message.UserId, BaseDto: dto.BaseDto{CreatedBy: message.UserId}})
+		s.notificationService.AddNotification(context.Background(), &dto.NotificationsPayload{Message: message.Message, MessageType: message.MessageType, Title: message.Title, UserId: message.UserId, Gstin: message.Gstin, ErrorCode: message.ErrorCode, BaseDto: dto.BaseDto{CreatedBy: message.UserId}})
 	}
 
 	s.streamer.Message <- string(msg)